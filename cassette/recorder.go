@@ -0,0 +1,208 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mode 录制还是回放
+type Mode int
+
+const (
+	ModeReplay Mode = iota //从cassette文件回放, 不访问网络
+	ModeRecord             //请求真实网关并录制, Save 时写入文件
+)
+
+// Recorder 录制/回放http交互, 通过 direct.Client.WrapTransport / order.Client.WrapTransport 安装;
+// 可以同时装到多个客户端上, 它们共用同一个cassette
+//
+//	rec, err := cassette.New("testdata/open_position.json", cassette.ModeReplay, nil)
+//	cli := order.NewClient(nil, &order.InitParams{Address: "http://mt5-gateway"})
+//	cli.WrapTransport(rec.Wrap)
+//	defer rec.Save()
+//
+// 请求按 method + path + query + body 匹配, 按录制顺序回放: 优先使用还没回放过的交互,
+// 都回放过了则重复最后一个匹配的交互(方便轮询类的测试).
+// 录制时按 utils.LogConfig 的规则自动脱敏(密码/签名/api key等), 回放时对请求做同样的脱敏再匹配
+type Recorder struct {
+	path   string
+	mode   Mode
+	redact *utils.LogConfig
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// New 回放模式会立即读取path, redact为nil时使用默认的脱敏规则
+func New(path string, mode Mode, redact *utils.LogConfig) (*Recorder, error) {
+	r := &Recorder{
+		path:     path,
+		mode:     mode,
+		redact:   redact,
+		cassette: &Cassette{RecordedAt: time.Now().UTC()},
+	}
+	if mode == ModeReplay {
+		c, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = c
+		r.used = make([]bool, len(c.Interactions))
+	}
+	return r, nil
+}
+
+// Wrap 作为 WrapTransport 的参数, 每次调用返回一个新的 http.RoundTripper, 录制时请求发给各自的next
+func (r *Recorder) Wrap(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{recorder: r, next: next}
+}
+
+type transport struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.recorder.roundTrip(req, t.next)
+}
+
+// Save 录制模式下写入cassette文件, 回放模式下什么都不做
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+// Unused 回放模式下还没有被回放过的交互, 可以用来检查测试是否覆盖了所有录制的请求
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var list []Interaction
+	for i, used := range r.used {
+		if !used {
+			list = append(list, r.cassette.Interactions[i])
+		}
+	}
+	return list
+}
+
+func (r *Recorder) roundTrip(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	recorded, err := r.request(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.mode == ModeRecord {
+		return r.record(req, recorded, next)
+	}
+	return r.replay(req, recorded)
+}
+
+// request 读出请求体并脱敏, 读完后把body放回去, 不影响真正发出的请求
+func (r *Recorder) request(req *http.Request) (Request, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return Request{}, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  r.redact.RedactQuery(req.URL.Query()).Encode(),
+		Header: r.redact.RedactHeader(req.Header),
+		Body:   string(r.redact.RedactBody(body)),
+	}, nil
+}
+
+func (r *Recorder) record(req *http.Request, recorded Request, next http.RoundTripper) (*http.Response, error) {
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	//脱敏后body长度会变
+	header := r.redact.RedactHeader(resp.Header)
+	header.Del("Content-Length")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       string(r.redact.RedactBody(body)),
+		},
+	})
+	return resp, nil
+}
+
+// replay 没有匹配的交互时返回404, 让请求直接失败而不是被当成网络问题重试
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, it := range r.cassette.Interactions {
+		if !matches(it.Request, recorded) {
+			continue
+		}
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if match < 0 {
+		msg := fmt.Sprintf("cassette %s: no recorded interaction for %s %s?%s", r.path, recorded.Method, recorded.Path, recorded.Query)
+		return response(req, http.StatusNotFound, http.Header{"Content-Type": {"text/plain"}}, msg), nil
+	}
+
+	r.used[match] = true
+	it := r.cassette.Interactions[match].Response
+	return response(req, it.StatusCode, it.Header.Clone(), it.Body), nil
+}
+
+func matches(a, b Request) bool {
+	return a.Method == b.Method && a.Path == b.Path && a.Query == b.Query && a.Body == b.Body
+}
+
+func response(req *http.Request, status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,270 @@
+package cassette_test
+
+import (
+	"errors"
+	"github.com/asaka1234/go-mt5-sdk/cassette"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// offline 回放时不能访问网络
+type offline struct{}
+
+func (offline) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("network access during replay")
+}
+
+func do(t *testing.T, client *http.Client, method, url, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body == "" {
+		req.Body = http.NoBody
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+// echo 返回 method path?query body
+func echo(hits *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		io.WriteString(w, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+" "+string(body))
+	}))
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	var hits atomic.Int32
+	srv := echo(&hits)
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "cassettes", "echo.json")
+
+	rec, err := cassette.New(path, cassette.ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: rec.Wrap(nil)}
+	requests := []struct{ method, url, body string }{
+		{http.MethodGet, "/v1/tick/review?symbol=EURUSD", ""},
+		{http.MethodPost, "/v1/position/open", `{"login":1,"lots":"0.1"}`},
+	}
+	var want []string
+	for _, r := range requests {
+		_, body := do(t, client, r.method, srv.URL+r.url, r.body)
+		want = append(want, body)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err = cassette.New(path, cassette.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//回放和网关地址无关
+	client = &http.Client{Transport: rec.Wrap(offline{})}
+	for i, r := range requests {
+		status, body := do(t, client, r.method, "http://replay.invalid"+r.url, r.body)
+		if status != http.StatusOK || body != want[i] {
+			t.Errorf("replay %s %s = %d %q, want %q", r.method, r.url, status, body, want[i])
+		}
+	}
+	if hits.Load() != 2 {
+		t.Fatalf("server hit %d times, want 2", hits.Load())
+	}
+	if unused := rec.Unused(); len(unused) != 0 {
+		t.Fatalf("unused = %+v", unused)
+	}
+}
+
+func writeCassette(t *testing.T, interactions ...cassette.Interaction) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := (&cassette.Cassette{Interactions: interactions}).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func interaction(method, path, query, body, response string) cassette.Interaction {
+	return cassette.Interaction{
+		Request:  cassette.Request{Method: method, Path: path, Query: query, Body: body},
+		Response: cassette.Response{StatusCode: http.StatusOK, Body: response},
+	}
+}
+
+func TestReplayMatching(t *testing.T) {
+	path := writeCassette(t,
+		interaction("GET", "/v1/position/list", "login=1&symbol=EURUSD", "", "login 1"),
+		interaction("GET", "/v1/position/list", "login=2", "", "login 2"),
+		interaction("POST", "/v1/position/open", "", `{"lots":"0.1"}`, "lots 0.1"),
+		interaction("POST", "/v1/position/open", "", `{"lots":"0.2"}`, "lots 0.2"),
+		interaction("GET", "/v1/order/get", "ticket=7", "", "placed"),
+		interaction("GET", "/v1/order/get", "ticket=7", "", "filled"),
+		interaction("GET", "/v1/symbol/list", "", "", "never requested"),
+	)
+	rec, err := cassette.New(path, cassette.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: rec.Wrap(offline{})}
+	base := "http://gateway"
+
+	tests := []struct {
+		name, method, url, body string
+		status                  int
+		want                    string
+	}{
+		{"query in any order", "GET", "/v1/position/list?symbol=EURUSD&login=1", "", 200, "login 1"},
+		{"query value", "GET", "/v1/position/list?login=2", "", 200, "login 2"},
+		{"body", "POST", "/v1/position/open", `{"lots":"0.2"}`, 200, "lots 0.2"},
+		{"method", "PUT", "/v1/position/open", `{"lots":"0.2"}`, 404, ""},
+		{"path", "GET", "/v1/position/get?login=2", "", 404, ""},
+		{"unknown query", "GET", "/v1/position/list?login=3", "", 404, ""},
+		{"unknown body", "POST", "/v1/position/open", `{"lots":"0.3"}`, 404, ""},
+		//同样的请求按录制顺序回放, 用完后重复最后一个
+		{"first poll", "GET", "/v1/order/get?ticket=7", "", 200, "placed"},
+		{"second poll", "GET", "/v1/order/get?ticket=7", "", 200, "filled"},
+		{"repeated poll", "GET", "/v1/order/get?ticket=7", "", 200, "filled"},
+	}
+	for _, tt := range tests {
+		status, body := do(t, client, tt.method, base+tt.url, tt.body)
+		if status != tt.status || tt.status == 200 && body != tt.want {
+			t.Errorf("%s: %s %s = %d %q, want %d %q", tt.name, tt.method, tt.url, status, body, tt.status, tt.want)
+		}
+		if status == 404 && !strings.Contains(body, "no recorded interaction for "+tt.method) {
+			t.Errorf("%s: 404 body = %q", tt.name, body)
+		}
+	}
+
+	unused := rec.Unused()
+	if len(unused) != 2 || unused[0].Response.Body != "lots 0.1" || unused[1].Response.Body != "never requested" {
+		t.Fatalf("unused = %+v", unused)
+	}
+}
+
+func TestRecordRedacts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=abcdef")
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"code":0,"data":{"login":1001,"master_pass":"Mp#123456","investor_pass":"Ip#123456"}}`)
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "redact.json")
+	rec, err := cassette.New(path, cassette.ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: rec.Wrap(nil)}
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/user/create?token=tk-secret&group=demo", strings.NewReader(`{"password":"Pw#secret","leverage":100}`))
+	req.Header.Set(utils.HeaderAPIKey, "key-secret")
+	req.Header.Set(utils.HeaderSignature, "sig-secret")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	//调用方拿到的是原始的返回
+	if !strings.Contains(string(body), "Mp#123456") {
+		t.Fatalf("caller got a redacted body: %s", body)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"tk-secret", "Pw#secret", "key-secret", "sig-secret", "Mp#123456", "Ip#123456", "abcdef"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+	c, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	it := c.Interactions[0]
+	if it.Request.Query != "group=demo&token=%2A%2A%2A" || !strings.Contains(it.Request.Body, `"leverage":100`) {
+		t.Errorf("request = %+v", it.Request)
+	}
+	//脱敏后长度变了, 不能保留原来的 Content-Length
+	if it.Response.Header.Get("Content-Length") != "" {
+		t.Errorf("Content-Length = %q was kept", it.Response.Header.Get("Content-Length"))
+	}
+	if it.Response.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("response header = %v", it.Response.Header)
+	}
+
+	//回放时对请求做同样的脱敏, 带着真实的secret也能匹配
+	rec, err = cassette.New(path, cassette.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = &http.Client{Transport: rec.Wrap(offline{})}
+	req, _ = http.NewRequest(http.MethodPost, "http://gateway/v1/user/create?group=demo&token=other", strings.NewReader(`{"password":"other","leverage":100}`))
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ContentLength <= 0 {
+		t.Fatalf("replay = %d, content length %d", resp.StatusCode, resp.ContentLength)
+	}
+}
+
+// counting 记录经过它的请求数
+type counting struct {
+	n atomic.Int32
+}
+
+func (c *counting) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.n.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// 同一个 Recorder 装到两个客户端上, 录制时请求各自走自己的next, 录到同一个cassette里
+func TestWrapPerClient(t *testing.T) {
+	var hits atomic.Int32
+	srv := echo(&hits)
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "two.json")
+
+	rec, err := cassette.New(path, cassette.ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	directNext, orderNext := &counting{}, &counting{}
+	direct := &http.Client{Transport: rec.Wrap(directNext)}
+	order := &http.Client{Transport: rec.Wrap(orderNext)}
+	do(t, direct, http.MethodGet, srv.URL+"/v1/symbol/list", "")
+	do(t, order, http.MethodPost, srv.URL+"/v1/position/open", `{}`)
+	do(t, direct, http.MethodGet, srv.URL+"/v1/symbol/list", "")
+	if directNext.n.Load() != 2 || orderNext.n.Load() != 1 {
+		t.Fatalf("requests: direct transport %d, order transport %d", directNext.n.Load(), orderNext.n.Load())
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := cassette.Load(path)
+	if err != nil || len(c.Interactions) != 3 {
+		t.Fatalf("cassette = %+v, %v", c, err)
+	}
+}
@@ -0,0 +1,167 @@
+// Package mt5 把 direct/order/pumping 三个客户端组合在一起, 用同一份配置创建, 共用logger/监控/中间件
+//
+//	cli, err := mt5.New(cfg, mt5.WithLogger(logger), mt5.WithMetrics(m))
+//	cli.Stream().Handler.RegisterTypedHandler(pumping.REQUEST_TYPE_DEAL, nil, onDeal)
+//	if err := cli.Start(); err != nil { ... }
+//	defer cli.Close()
+//	cli.Stream().SubscribeDeal()
+//	resp, err := cli.Trading().OpenPositionWithContext(ctx, req)
+package mt5
+
+import (
+	"errors"
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/direct"
+	"github.com/asaka1234/go-mt5-sdk/order"
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"sync"
+)
+
+// Client 可以在多个goroutine之间共享
+type Client struct {
+	logger utils.Logger
+	direct *direct.Client
+	order  *order.Client
+	stream *Stream //没有配置pumping时为nil
+
+	mu      sync.Mutex
+	started bool
+	closed  bool
+}
+
+// Stream pumping推送, 内嵌的 TCPClient 负责连接和订阅, Handler 用来注册消息处理器
+type Stream struct {
+	*pumping.TCPClient
+	Handler *pumping.SubscriptionMessageHandler
+}
+
+// New 校验配置(见 Config.Validate)并创建所有客户端, 交易请求结果不明确时默认通过查询接口确认是否落地(order.NewTradeConfirmer)
+//
+// 配置了备用网关或 failover 时, New 就会开始后台的网关健康检查(不需要 Start), 不用时要 Close
+func New(cfg Config, opts ...Option) (*Client, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	logger := o.logger
+	if logger == nil {
+		logger = utils.NopLogger{}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	trading := cfg.Gateway
+	if cfg.Trading != nil {
+		trading = *cfg.Trading
+	}
+
+	cli := &Client{
+		logger: logger,
+		direct: direct.NewClient(logger, cfg.Gateway.params()),
+		order:  order.NewClient(logger, trading.params()),
+	}
+	cli.order.SetTradeConfirmer(order.NewTradeConfirmer(cli.direct))
+	cli.direct.Use(o.middlewares...)
+	cli.order.Use(o.middlewares...)
+	cli.direct.UseAttempt(o.attemptMiddlewares...)
+	cli.order.UseAttempt(o.attemptMiddlewares...)
+
+	if cfg.Pumping != nil {
+		cli.stream = newStream(cfg.Pumping, logger, o)
+	}
+	return cli, nil
+}
+
+func newStream(cfg *pumping.Config, logger utils.Logger, o options) *Stream {
+	handler := pumping.NewSubscriptionMessageHandler()
+	handler.OnConnectedFunc = func() {
+		logger.Infof("MT5#pumping->connected to %s", cfg.ServerAddr)
+	}
+	handler.OnDisconnectedFunc = func() {
+		logger.Warnf("MT5#pumping->disconnected from %s", cfg.ServerAddr)
+	}
+	handler.OnErrorFunc = func(err error) {
+		logger.Warnf("MT5#pumping->%v", err)
+	}
+
+	tcp := pumping.NewTCPClient(cfg, handler)
+	if observer := o.observer(); observer != nil {
+		handler.SetObserver(observer)
+		tcp.SetObserver(observer)
+	}
+	if hook := o.dispatchHook(); hook != nil {
+		handler.SetDispatchHook(hook)
+	}
+	return &Stream{TCPClient: tcp, Handler: handler}
+}
+
+// Start 连接pumping, 没有配置pumping时什么都不做; 需要先通过 Stream().Handler 注册好处理器
+func (c *Client) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.closed:
+		return errors.New("mt5: client is closed")
+	case c.started:
+		return nil
+	}
+	if c.stream != nil {
+		if err := c.stream.Connect(); err != nil {
+			return fmt.Errorf("mt5: connect pumping: %w", err)
+		}
+	}
+	c.started = true
+	return nil
+}
+
+// Close 断开pumping并停止后台的健康检查, 可以重复调用
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	var err error
+	if c.stream != nil {
+		err = c.stream.Disconnect()
+	}
+	c.direct.Close()
+	c.order.Close()
+	return err
+}
+
+// Market 品种和报价
+func (c *Client) Market() direct.MarketAPI {
+	return c.direct
+}
+
+// Accounts 开户/出入金/资金/持仓和挂单查询
+func (c *Client) Accounts() direct.AccountAPI {
+	return c.direct
+}
+
+// Trading 开平仓和挂单
+func (c *Client) Trading() order.TradingAPI {
+	return c.order
+}
+
+// Stream pumping推送, 没有配置 Config.Pumping 时返回nil
+func (c *Client) Stream() *Stream {
+	return c.stream
+}
+
+// Direct 底层的查询客户端, 用于 SetRateLimiter/WrapTransport 等高级配置
+func (c *Client) Direct() *direct.Client {
+	return c.direct
+}
+
+// Order 底层的交易客户端, 用于 SetRateLimiter/WrapTransport 等高级配置
+func (c *Client) Order() *order.Client {
+	return c.order
+}
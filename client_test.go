@@ -0,0 +1,199 @@
+package mt5_test
+
+import (
+	"context"
+	"github.com/asaka1234/go-mt5-sdk"
+	"github.com/asaka1234/go-mt5-sdk/order"
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"github.com/asaka1234/go-mt5-sdk/simulator"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newGateway(t *testing.T) (*simulator.Gateway, *httptest.Server) {
+	t.Helper()
+	g := simulator.NewGateway()
+	srv := httptest.NewServer(g)
+	t.Cleanup(srv.Close)
+	return g, srv
+}
+
+func newPumpingServer(t *testing.T) *simulator.PumpingServer {
+	t.Helper()
+	srv, err := simulator.NewPumpingServer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+func TestClientLifecycle(t *testing.T) {
+	g, gw := newGateway(t)
+	ps := newPumpingServer(t)
+	pumpingCfg := pumping.DefaultConfig()
+	pumpingCfg.ServerAddr = ps.Addr()
+	pumpingCfg.Reconnect = false
+
+	cli, err := mt5.New(mt5.Config{Gateway: mt5.GatewayConfig{Address: gw.URL}, Pumping: pumpingCfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticks := make(chan pumping.MT5Tick, 1)
+	cli.Stream().Handler.RegisterTypedHandler(pumping.REQUEST_TYPE_TICK, nil, func(_ *pumping.TCPResponse, payload interface{}) error {
+		for _, tick := range payload.([]pumping.MT5Tick) {
+			ticks <- tick
+		}
+		return nil
+	})
+	//New 不会连接pumping
+	if cli.Stream().IsConnected() || ps.Connections() != 0 {
+		t.Fatal("New connected to pumping")
+	}
+
+	if err := cli.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := cli.Start(); err != nil {
+		t.Fatalf("second Start: %v", err)
+	}
+	if err := cli.Stream().SubscribeTick("EURUSD"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := ps.WaitSubscribed(ctx, pumping.REQUEST_TYPE_TICK); err != nil {
+		t.Fatal(err)
+	}
+	if n := ps.Connections(); n != 1 {
+		t.Fatalf("%d pumping connections, want 1", n)
+	}
+	if err := ps.Tick(pumping.MT5Tick{Symbol: "EURUSD", AskE8: 108520000, BidE8: 108500000}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case tick := <-ticks:
+		if tick.Symbol != "EURUSD" {
+			t.Fatalf("tick = %+v", tick)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no tick received")
+	}
+
+	//REST 客户端和交易确认都指向同一个网关
+	login := g.CreateAccount(utils.MustParseMoney("10000"), 100)
+	if _, err := cli.Trading().OpenPosition(order.OpenPositionRequest{Login: login, Lots: utils.MustParseVolume("0.1"), Symbol: "EURUSD", Type: order.MtRequestTypeBuy}); err != nil {
+		t.Fatalf("OpenPosition: %v", err)
+	}
+	positions, err := cli.Accounts().ListPosition(login)
+	if err != nil || len(positions.Data) != 1 {
+		t.Fatalf("ListPosition = %+v, %v", positions, err)
+	}
+
+	if err := cli.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if cli.Stream().IsConnected() {
+		t.Fatal("stream is still connected after Close")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for ps.Connections() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("pumping server still has a connection after Close")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := cli.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if err := cli.Start(); err == nil {
+		t.Fatal("Start after Close succeeded")
+	}
+}
+
+func TestClientWithoutPumping(t *testing.T) {
+	_, gw := newGateway(t)
+	cli, err := mt5.New(mt5.Config{Gateway: mt5.GatewayConfig{Address: gw.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cli.Stream() != nil {
+		t.Fatal("Stream() is not nil without a pumping config")
+	}
+	if err := cli.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := cli.Market().ListSymbol(); err != nil {
+		t.Fatalf("ListSymbol: %v", err)
+	}
+	if err := cli.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestClientStartFails(t *testing.T) {
+	_, gw := newGateway(t)
+	ps := newPumpingServer(t)
+	addr := ps.Addr()
+	ps.Close()
+
+	cfg := pumping.DefaultConfig()
+	cfg.ServerAddr = addr
+	cfg.Timeout = time.Second
+	cli, err := mt5.New(mt5.Config{Gateway: mt5.GatewayConfig{Address: gw.URL}, Pumping: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.Start(); err == nil {
+		t.Fatal("Start succeeded without a pumping server")
+	}
+	if err := cli.Close(); err != nil {
+		t.Fatalf("Close after a failed Start: %v", err)
+	}
+}
+
+// 网关的健康检查在 New 时就开始, Close 后停止
+func TestClientHealthProbes(t *testing.T) {
+	var probes atomic.Int32
+	handler := func(g http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/health" {
+				probes.Add(1)
+				return
+			}
+			g.ServeHTTP(w, r)
+		})
+	}
+	primary := httptest.NewServer(handler(simulator.NewGateway()))
+	defer primary.Close()
+	backup := httptest.NewServer(handler(simulator.NewGateway()))
+	defer backup.Close()
+
+	cli, err := mt5.New(mt5.Config{Gateway: mt5.GatewayConfig{
+		Address:   primary.URL,
+		Addresses: []string{backup.URL},
+		Failover:  &utils.FailoverConfig{HealthPath: "/health", HealthInterval: 10 * time.Millisecond},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for probes.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no health probe after New")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cli.Close()
+	time.Sleep(50 * time.Millisecond)
+	n := probes.Load()
+	time.Sleep(100 * time.Millisecond)
+	if probes.Load() != n {
+		t.Fatal("health probes continue after Close")
+	}
+}
@@ -0,0 +1,616 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/direct"
+	"github.com/asaka1234/go-mt5-sdk/order"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sides open 的 -side
+var sides = map[string]order.MtRequestType{
+	"buy":  order.MtRequestTypeBuy,
+	"sell": order.MtRequestTypeSell,
+}
+
+// pendingTypes pending place 的 -type
+var pendingTypes = map[string]order.MtRequestType{
+	"buy_limit":       order.MtRequestTypeBuyLimit,
+	"sell_limit":      order.MtRequestTypeSellLimit,
+	"buy_stop":        order.MtRequestTypeBuyStop,
+	"sell_stop":       order.MtRequestTypeSellStop,
+	"buy_stop_limit":  order.MtRequestTypeBuyStopLimit,
+	"sell_stop_limit": order.MtRequestTypeSellStopLimit,
+}
+
+// expireTypes pending place/modify 的 -expire
+var expireTypes = map[string]order.MtOrderTime{
+	"gtc":           order.MtOrderTimeGTC,
+	"day":           order.MtOrderTimeDay,
+	"specified":     order.MtOrderTimeSpecified,
+	"specified_day": order.MtOrderTimeSpecifiedDay,
+}
+
+func runSymbols(a *app, args []string) error {
+	fs := a.flags("symbols", "")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	resp, err := a.cli.Market().ListSymbolWithContext(ctx)
+	if err != nil {
+		return err
+	}
+	return a.out.print(resp.Data, "symbol", "digit", "category", "currency_base", "currency_profit", "contract_size",
+		"volume_min", "volume_max", "volume_step", "trade_mode")
+}
+
+// tickRow 把E8价格换成小数, 时间换成可读的格式
+type tickRow struct {
+	Symbol string      `json:"symbol"`
+	Ask    utils.Price `json:"ask"`
+	Bid    utils.Price `json:"bid"`
+	Last   utils.Price `json:"last"`
+	Volume uint64      `json:"volume"`
+	Time   string      `json:"time"`
+}
+
+func newTickRow(symbol string, ask, bid, last utils.Price, volume uint64, timeMs int64) tickRow {
+	return tickRow{
+		Symbol: symbol,
+		Ask:    ask,
+		Bid:    bid,
+		Last:   last,
+		Volume: volume,
+		Time:   time.UnixMilli(timeMs).UTC().Format("2006-01-02 15:04:05.000"),
+	}
+}
+
+func runTicks(a *app, args []string) error {
+	fs := a.flags("ticks", "[-symbols A,B]")
+	symbols := fs.String("symbols", "", "comma separated symbols, empty for all")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	want := make(map[string]bool)
+	for _, s := range strings.Split(*symbols, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			want[s] = true
+		}
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	resp, err := a.cli.Market().TickReviewWithContext(ctx)
+	if err != nil {
+		return err
+	}
+	rows := make([]tickRow, 0, len(resp.Data))
+	for _, t := range resp.Data {
+		if len(want) == 0 || want[t.Symbol] {
+			rows = append(rows, newTickRow(t.Symbol, t.Ask(), t.Bid(), t.Last(), t.Volume, t.Time))
+		}
+	}
+	return a.out.print(rows)
+}
+
+func runUser(a *app, args []string) error {
+	const usage = "create [-uid N] [-internal 1|2] [-leverage N]"
+	if len(args) == 0 || args[0] != "create" {
+		fmt.Fprintf(a.stderr, "Usage: mt5ctl user %s\n", usage)
+		return errUsage
+	}
+	fs := a.flags("user", usage)
+	uid := fs.Int64("uid", 0, "uid of the account owner, saved in the comment")
+	internal := fs.Uint("internal", 0, "1 for an internal test account, 2 otherwise")
+	leverage := fs.Uint("leverage", 0, "leverage, default 500")
+	if _, err := parse(fs, args[1:], 0); err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	resp, err := a.cli.Accounts().UserCreateWithContext(ctx, direct.UserCreateReq{Uid: *uid, Internal: *internal, Leverage: *leverage})
+	if err != nil {
+		return err
+	}
+	return a.out.print(resp.Data)
+}
+
+func runAccount(a *app, args []string) error {
+	fs := a.flags("account", "<login>")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	login, err := parseUint("login", pos[0])
+	if err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	resp, err := a.cli.Accounts().UserAccountDetailWithContext(ctx, login)
+	if err != nil {
+		return err
+	}
+	return a.out.print(resp.Data)
+}
+
+func runPositions(a *app, args []string) error {
+	fs := a.flags("positions", "<login> | -ticket N")
+	ticket := fs.Uint64("ticket", 0, "show one position instead of listing an account")
+	login, err := loginOrTicket(fs, args, ticket)
+	if err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	if *ticket != 0 {
+		resp, err := a.cli.Accounts().PositionGetWithContext(ctx, *ticket)
+		if err != nil {
+			return err
+		}
+		return a.out.print(resp.Data)
+	}
+	resp, err := a.cli.Accounts().ListPositionWithContext(ctx, login)
+	if err != nil {
+		return err
+	}
+	return a.out.print(resp.Data, "ticket", "login", "symbol", "action", "volume", "price_open", "price_sl", "price_tp", "profit", "storage", "comment")
+}
+
+func runOrders(a *app, args []string) error {
+	fs := a.flags("orders", "<login> | -ticket N")
+	ticket := fs.Uint64("ticket", 0, "show one order instead of listing an account")
+	login, err := loginOrTicket(fs, args, ticket)
+	if err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	if *ticket != 0 {
+		resp, err := a.cli.Accounts().OrderGetWithContext(ctx, *ticket)
+		if err != nil {
+			return err
+		}
+		return a.out.print(resp.Data)
+	}
+	resp, err := a.cli.Accounts().ListPendingOrderWithContext(ctx, login)
+	if err != nil {
+		return err
+	}
+	return a.out.print(resp.Data, "ticket", "login", "symbol", "type", "volume", "price_order", "price_trigger", "price_sl", "price_tp", "comment")
+}
+
+// loginOrTicket 位置参数是login, 或者用 -ticket 查单个
+func loginOrTicket(fs *flag.FlagSet, args []string, ticket *uint64) (uint64, error) {
+	pos, err := parse(fs, args, -1)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case *ticket != 0 && len(pos) == 0:
+		return 0, nil
+	case *ticket == 0 && len(pos) == 1:
+		return parseUint("login", pos[0])
+	}
+	fs.Usage()
+	return 0, errUsage
+}
+
+func runOpen(a *app, args []string) error {
+	fs := a.flags("open", "-login N -symbol S -side buy|sell -lots L [-sl P] [-tp P] [-comment C]")
+	login := fs.Uint64("login", 0, "account login")
+	symbol := fs.String("symbol", "", "symbol")
+	side := fs.String("side", "", "buy or sell")
+	lots := volumeFlag(fs, "lots", "volume in `lots`, e.g. 0.1")
+	sl := priceFlag(fs, "sl", "stop loss `price`")
+	tp := priceFlag(fs, "tp", "take profit `price`")
+	comment := fs.String("comment", "", "comment")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := required(fs, "login", "symbol", "side", "lots"); err != nil {
+		return err
+	}
+	typ, ok := sides[*side]
+	if !ok {
+		return fmt.Errorf("invalid -side %q, use buy or sell", *side)
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if err := a.confirm("open %s %s lots %s for login %d%s", *side, *lots, *symbol, *login, stops(*sl, *tp)); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	resp, err := a.cli.Trading().OpenPositionWithContext(ctx, order.OpenPositionRequest{
+		Login:   *login,
+		Symbol:  *symbol,
+		Type:    typ,
+		Lots:    *lots,
+		Sl:      *sl,
+		Tp:      *tp,
+		Comment: *comment,
+	})
+	return a.printTrade(resp, err)
+}
+
+func runModify(a *app, args []string) error {
+	fs := a.flags("modify", "<ticket> [-sl P] [-tp P]")
+	sl := priceFlag(fs, "sl", "new stop loss `price`")
+	tp := priceFlag(fs, "tp", "new take profit `price`")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	ticket, err := parseUint("ticket", pos[0])
+	if err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if err := a.confirm("modify position %d%s", ticket, stops(*sl, *tp)); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	resp, err := a.cli.Trading().ModifyPositionWithContext(ctx, order.ModifyPositionRequest{Ticket: ticket, Sl: *sl, Tp: *tp})
+	return a.printTrade(resp, err)
+}
+
+func runClose(a *app, args []string) error {
+	fs := a.flags("close", "<ticket> [-lots L] [-comment C]")
+	lots := volumeFlag(fs, "lots", "`lots` to close, empty to close the whole position")
+	comment := fs.String("comment", "", "comment")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	ticket, err := strconv.Atoi(pos[0])
+	if err != nil {
+		return fmt.Errorf("invalid ticket %q", pos[0])
+	}
+
+	what := "all lots"
+	if lots.IsSet() {
+		what = lots.String() + " lots"
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if err := a.confirm("close %s of position %d", what, ticket); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	resp, err := a.cli.Trading().ClosePositionWithContext(ctx, order.ClosePositionRequest{Ticket: ticket, Lots: *lots, Comment: *comment})
+	return a.printTrade(resp, err)
+}
+
+func runCloseAll(a *app, args []string) error {
+	fs := a.flags("close-all", "<login> [-comment C]")
+	comment := fs.String("comment", "", "comment")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	login, err := parseUint("login", pos[0])
+	if err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if err := a.confirm("close ALL positions of login %d", login); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	resp, err := a.cli.Trading().CloseAllPositionsWithContext(ctx, order.CloseAllPositionsRequest{Login: login, Comment: *comment})
+	return a.printTrade(resp, err)
+}
+
+func runPending(a *app, args []string) error {
+	const usage = "place|modify|remove|remove-all ..."
+	if len(args) == 0 {
+		fmt.Fprintf(a.stderr, "Usage: mt5ctl pending %s\n", usage)
+		return errUsage
+	}
+	switch args[0] {
+	case "place":
+		return runPendingPlace(a, args[1:])
+	case "modify":
+		return runPendingModify(a, args[1:])
+	case "remove":
+		return runPendingRemove(a, args[1:])
+	case "remove-all":
+		return runPendingRemoveAll(a, args[1:])
+	}
+	fmt.Fprintf(a.stderr, "mt5ctl pending: unknown command %q\nUsage: mt5ctl pending %s\n", args[0], usage)
+	return errUsage
+}
+
+// expiry -expire 和 -expire-at 共用的参数
+type expiry struct {
+	typ string
+	at  string
+}
+
+func (e *expiry) register(fs *flag.FlagSet) {
+	fs.StringVar(&e.typ, "expire", "gtc", "gtc, day, specified or specified_day")
+	fs.StringVar(&e.at, "expire-at", "", "expiry time for specified/specified_day, unix seconds or RFC3339")
+}
+
+func (e *expiry) parse() (order.MtOrderTime, int64, error) {
+	typ, ok := expireTypes[e.typ]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid -expire %q, use gtc, day, specified or specified_day", e.typ)
+	}
+	if e.at == "" {
+		return typ, 0, nil
+	}
+	if n, err := strconv.ParseInt(e.at, 10, 64); err == nil {
+		return typ, n, nil
+	}
+	t, err := time.Parse(time.RFC3339, e.at)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid -expire-at %q, use unix seconds or RFC3339", e.at)
+	}
+	return typ, t.Unix(), nil
+}
+
+func runPendingPlace(a *app, args []string) error {
+	fs := a.flags("pending place", "-login N -symbol S -type T -lots L -price P [-trigger P] [-expire E] [-expire-at T] [-sl P] [-tp P] [-comment C]")
+	login := fs.Uint64("login", 0, "account login")
+	symbol := fs.String("symbol", "", "symbol")
+	typ := fs.String("type", "", "buy_limit, sell_limit, buy_stop, sell_stop, buy_stop_limit or sell_stop_limit")
+	lots := volumeFlag(fs, "lots", "volume in `lots`, e.g. 0.1")
+	price := priceFlag(fs, "price", "order `price`")
+	trigger := priceFlag(fs, "trigger", "limit `price` placed when a stop limit order triggers")
+	sl := priceFlag(fs, "sl", "stop loss `price`")
+	tp := priceFlag(fs, "tp", "take profit `price`")
+	comment := fs.String("comment", "", "comment")
+	var exp expiry
+	exp.register(fs)
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := required(fs, "login", "symbol", "type", "lots", "price"); err != nil {
+		return err
+	}
+	orderType, ok := pendingTypes[*typ]
+	if !ok {
+		return fmt.Errorf("invalid -type %q", *typ)
+	}
+	expireType, expireAt, err := exp.parse()
+	if err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if err := a.confirm("place %s %s lots %s @ %s for login %d%s", *typ, *lots, *symbol, *price, *login, stops(*sl, *tp)); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	resp, err := a.cli.Trading().PlacePendingOrderWithContext(ctx, order.PlacePendingOrderRequest{
+		Login:          *login,
+		Symbol:         *symbol,
+		Lots:           *lots,
+		Type:           orderType,
+		Price:          *price,
+		ExpireTimeType: expireType,
+		ExpireTime:     expireAt,
+		TriggerPrice:   *trigger,
+		Comment:        *comment,
+		Sl:             *sl,
+		Tp:             *tp,
+	})
+	return a.printTrade(resp, err)
+}
+
+func runPendingModify(a *app, args []string) error {
+	fs := a.flags("pending modify", "<ticket> [-price P] [-trigger P] [-expire E] [-expire-at T] [-sl P] [-tp P] [-comment C]")
+	price := priceFlag(fs, "price", "new order `price`, empty to keep")
+	trigger := priceFlag(fs, "trigger", "new limit `price` of a stop limit order")
+	sl := priceFlag(fs, "sl", "new stop loss `price`")
+	tp := priceFlag(fs, "tp", "new take profit `price`")
+	comment := fs.String("comment", "", "comment")
+	var exp expiry
+	exp.register(fs)
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	ticket, err := parseUint("ticket", pos[0])
+	if err != nil {
+		return err
+	}
+	expireType, expireAt, err := exp.parse()
+	if err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if err := a.confirm("modify pending order %d", ticket); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	resp, err := a.cli.Trading().ModifyPendingOrderWithContext(ctx, order.ModifyPendingOrderRequest{
+		Ticket:         ticket,
+		Price:          *price,
+		TriggerPrice:   *trigger,
+		Sl:             *sl,
+		Tp:             *tp,
+		ExpireTimeType: expireType,
+		ExpireTime:     expireAt,
+		Comment:        *comment,
+	})
+	return a.printTrade(resp, err)
+}
+
+func runPendingRemove(a *app, args []string) error {
+	fs := a.flags("pending remove", "<ticket> [-comment C]")
+	comment := fs.String("comment", "", "comment")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	ticket, err := parseUint("ticket", pos[0])
+	if err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if err := a.confirm("remove pending order %d", ticket); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	resp, err := a.cli.Trading().RemovePendingOrderWithContext(ctx, order.RemovePendingOrderRequest{Ticket: ticket, Comment: *comment})
+	return a.printTrade(resp, err)
+}
+
+func runPendingRemoveAll(a *app, args []string) error {
+	fs := a.flags("pending remove-all", "<login> [-symbol S] [-comment C]")
+	symbol := fs.String("symbol", "", "only remove orders of this symbol")
+	comment := fs.String("comment", "", "comment")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	login, err := parseUint("login", pos[0])
+	if err != nil {
+		return err
+	}
+
+	what := "ALL pending orders"
+	if *symbol != "" {
+		what = "all " + *symbol + " pending orders"
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if err := a.confirm("remove %s of login %d", what, login); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	resp, err := a.cli.Trading().RemoveAllPendingOrdersWithContext(ctx, order.RemoveAllPendingOrdersRequest{Login: login, Symbol: *symbol, Comment: *comment})
+	return a.printTrade(resp, err)
+}
+
+func runBalance(a *app, args []string) error {
+	fs := a.flags("balance", "<login> <amount> [-comment C]")
+	comment := fs.String("comment", "", "comment, e.g. the id of the payment")
+	pos, err := parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	login, err := parseUint("login", pos[0])
+	if err != nil {
+		return err
+	}
+	amount, err := utils.ParseMoney(pos[1])
+	if err != nil || amount.Decimal().IsZero() {
+		return fmt.Errorf("invalid amount %q", pos[1])
+	}
+
+	what := "deposit"
+	if amount.Decimal().IsNegative() {
+		what = "withdraw"
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if err := a.confirm("%s %s for login %d", what, amount.Decimal().Abs(), login); err != nil {
+		return err
+	}
+	ctx, cancel := a.call()
+	defer cancel()
+	resp, err := a.cli.Accounts().BalanceOperationWithContext(ctx, direct.BalanceOperationReq{Login: login, Balance: amount, Comment: *comment})
+	if err != nil {
+		return err
+	}
+	return a.out.print(resp.Data)
+}
+
+// printTrade 交易接口成功时打印data(ticket/deal/成交价等)
+func (a *app) printTrade(resp *order.CommonResp, err error) error {
+	if err != nil {
+		return err
+	}
+	if columns, _ := flatten(resp.Data); len(columns) > 0 {
+		return a.out.print(resp.Data)
+	}
+	return a.out.print(resp)
+}
+
+func stops(sl, tp utils.Price) string {
+	var s string
+	if sl.IsSet() {
+		s += " sl " + sl.String()
+	}
+	if tp.IsSet() {
+		s += " tp " + tp.String()
+	}
+	return s
+}
+
+// priceFlag 没有传则为没有设置的 utils.Price
+func priceFlag(fs *flag.FlagSet, name, usage string) *utils.Price {
+	p := new(utils.Price)
+	fs.Func(name, usage, func(s string) (err error) {
+		*p, err = utils.ParsePrice(s)
+		return err
+	})
+	return p
+}
+
+func volumeFlag(fs *flag.FlagSet, name, usage string) *utils.Volume {
+	v := new(utils.Volume)
+	fs.Func(name, usage, func(s string) (err error) {
+		*v, err = utils.ParseVolume(s)
+		return err
+	})
+	return v
+}
@@ -0,0 +1,307 @@
+// mt5ctl 在命令行里调用mt5网关的接口, 以及查看pumping推送
+//
+//	mt5ctl -config mt5.yaml symbols
+//	mt5ctl -gateway.address http://127.0.0.1:8351 -o json positions 100001
+//	mt5ctl open -login 100001 -symbol XAUUSD -side buy -lots 0.1
+//	mt5ctl balance 100001 -500 -comment withdraw#123
+//	mt5ctl -pumping.server_addr 127.0.0.1:8355 stream tick -symbols XAUUSD,EURUSD
+//
+// 配置和SDK相同(见 mt5.Loader): -config 指定yaml文件(没有指定时使用环境变量 MT5_CONFIG),
+// MT5_* 环境变量和 -gateway.address 这样的参数覆盖文件里的值.
+// 会动到资金/持仓的命令(open, close, close-all, pending, balance)执行前需要确认, -y 跳过确认
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	mt5 "github.com/asaka1234/go-mt5-sdk"
+	"github.com/asaka1234/go-mt5-sdk/logadapter"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errAborted 用户没有确认
+var errAborted = errors.New("aborted")
+
+// errUsage 参数错误, 已经打印过用法
+var errUsage = errors.New("usage")
+
+type command struct {
+	name  string
+	args  string //位置参数和flag的说明
+	help  string
+	money bool //会动到资金/持仓, 执行前需要确认
+	run   func(a *app, args []string) error
+}
+
+var commands = []command{
+	{name: "symbols", args: "", help: "list symbols", run: runSymbols},
+	{name: "ticks", args: "[-symbols A,B]", help: "latest quotes", run: runTicks},
+	{name: "user", args: "create [-uid N] [-internal 1|2] [-leverage N]", help: "create an account", run: runUser},
+	{name: "account", args: "<login>", help: "balance, equity and margin of an account", run: runAccount},
+	{name: "positions", args: "<login> | -ticket N", help: "open positions", run: runPositions},
+	{name: "orders", args: "<login> | -ticket N", help: "pending orders", run: runOrders},
+	{name: "open", args: "-login N -symbol S -side buy|sell -lots L [-sl P] [-tp P] [-comment C]", help: "open a position", money: true, run: runOpen},
+	{name: "modify", args: "<ticket> [-sl P] [-tp P]", help: "change sl/tp of a position", money: true, run: runModify},
+	{name: "close", args: "<ticket> [-lots L] [-comment C]", help: "close a position, partially with -lots", money: true, run: runClose},
+	{name: "close-all", args: "<login> [-comment C]", help: "close all positions of an account", money: true, run: runCloseAll},
+	{name: "pending", args: "place|modify|remove|remove-all ...", help: "place, modify or remove pending orders", money: true, run: runPending},
+	{name: "balance", args: "<login> <amount> [-comment C]", help: "deposit (positive amount) or withdraw (negative amount)", money: true, run: runBalance},
+	{name: "stream", args: "<type> [-symbols A,B] [-n N]", help: "print pumping messages: " + strings.Join(streamTypes(), ", "), run: runStream},
+}
+
+// app 所有命令共用的状态
+type app struct {
+	ctx     context.Context
+	loader  *mt5.Loader
+	verbose bool
+	cli     *mt5.Client //connect 之后才有
+	timeout time.Duration
+	yes     bool
+	out     *printer
+	stdin   *bufio.Reader
+	stderr  io.Writer
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run 返回进程的退出码: 0成功, 1执行失败, 2参数错误
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	loader := &mt5.Loader{}
+	fs := flag.NewFlagSet("mt5ctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("o", formatTable, "output `format`: table, json or csv")
+	columns := fs.String("columns", "", "comma separated `columns` to print, default depends on the command")
+	yes := fs.Bool("y", false, "do not ask for confirmation")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout of each gateway call")
+	verbose := fs.Bool("v", false, "log requests to stderr")
+	loader.RegisterFlags(fs)
+	fs.Usage = func() { usage(fs) }
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	cmd, ok := lookup(fs.Arg(0))
+	if !ok {
+		fmt.Fprintf(stderr, "mt5ctl: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		return 2
+	}
+	out, err := newPrinter(stdout, *format, *columns)
+	if err != nil {
+		fmt.Fprintf(stderr, "mt5ctl: %v\n", err)
+		return 2
+	}
+
+	if len(loader.Files) == 0 && os.Getenv("MT5_CONFIG") != "" {
+		loader.Files = []string{os.Getenv("MT5_CONFIG")}
+	}
+	a := &app{
+		ctx:     ctx,
+		loader:  loader,
+		verbose: *verbose,
+		timeout: *timeout,
+		yes:     *yes,
+		out:     out,
+		stdin:   bufio.NewReader(stdin),
+		stderr:  stderr,
+	}
+	defer a.close()
+	switch err := cmd.run(a, fs.Args()[1:]); {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	default:
+		fmt.Fprintf(stderr, "mt5ctl %s: %v\n", cmd.name, err)
+		return 1
+	}
+}
+
+func lookup(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintf(w, "Usage: mt5ctl [flags] <command> [args]\n\nCommands:\n")
+	for _, cmd := range commands {
+		help := cmd.help
+		if cmd.money {
+			help += " (asks for confirmation)"
+		}
+		fmt.Fprintf(w, "  %-10s %s\n  %-10s   %s\n", cmd.name, cmd.args, "", help)
+	}
+	fmt.Fprintf(w, "\nFlags:\n")
+	all := fs.Lookup("v").Value.String() == "true"
+	fs.VisitAll(func(f *flag.Flag) {
+		if strings.Contains(f.Name, ".") && !all {
+			return
+		}
+		name, usage := flag.UnquoteUsage(f)
+		if f.DefValue != "" && f.DefValue != "false" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Fprintf(w, "  -%s %s\n    \t%s\n", f.Name, name, usage)
+	})
+	if !all {
+		fmt.Fprintf(w, "\nEvery config field is also a flag named after its yaml path, e.g. -gateway.address or\n"+
+			"-pumping.server_addr, and an environment variable, e.g. MT5_GATEWAY_ADDRESS. Run 'mt5ctl -v -h' to list them all.\n")
+	}
+}
+
+// connect 加载配置并创建客户端, 在参数检查通过之后调用, 这样参数错误时不需要先有配置
+func (a *app) connect() error {
+	if a.cli != nil {
+		return nil
+	}
+	cfg, err := a.loader.Load()
+	if err != nil {
+		return err
+	}
+	var logger utils.Logger = utils.NopLogger{}
+	if a.verbose {
+		logger = logadapter.NewSlog(slog.New(slog.NewTextHandler(a.stderr, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	}
+	a.cli, err = mt5.New(*cfg, mt5.WithLogger(logger))
+	return err
+}
+
+func (a *app) close() {
+	if a.cli != nil {
+		a.cli.Close()
+	}
+}
+
+// call 单次网关调用的ctx
+func (a *app) call() (context.Context, context.CancelFunc) {
+	if a.timeout <= 0 {
+		return context.WithCancel(a.ctx)
+	}
+	return context.WithTimeout(a.ctx, a.timeout)
+}
+
+// confirm 打印将要执行的操作, 输入y/yes才继续
+func (a *app) confirm(format string, args ...interface{}) error {
+	if a.yes {
+		return nil
+	}
+	fmt.Fprintf(a.stderr, format+"? [y/N] ", args...)
+	line, _ := a.stdin.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return nil
+	}
+	return errAborted
+}
+
+// flags 子命令的参数, 出错时打印子命令的用法
+func (a *app) flags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet("mt5ctl "+name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: mt5ctl %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse 允许flag和位置参数混着写; 负数(比如 balance 100001 -500)当作位置参数. want为位置参数的个数, <0不检查
+func parse(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+	var flags, positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			positional = append(positional, args[i+1:]...)
+			i = len(args)
+		case !strings.HasPrefix(arg, "-") || arg == "-" || isNumber(arg):
+			positional = append(positional, arg)
+		default:
+			flags = append(flags, arg)
+			name := strings.TrimLeft(arg, "-")
+			if strings.Contains(name, "=") {
+				continue
+			}
+			if f := fs.Lookup(name); f != nil && !isBoolFlag(f) && i+1 < len(args) {
+				i++
+				flags = append(flags, args[i])
+			}
+		}
+	}
+	if err := fs.Parse(flags); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errUsage
+	}
+	if want >= 0 && len(positional) != want {
+		fmt.Fprintf(fs.Output(), "expected %d argument(s), got %d\n", want, len(positional))
+		fs.Usage()
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// required 检查必填的flag
+func required(fs *flag.FlagSet, names ...string) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	var missing []string
+	for _, name := range names {
+		if !set[name] {
+			missing = append(missing, "-"+name)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(fs.Output(), "missing required flag(s): %s\n", strings.Join(missing, ", "))
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func parseUint(name, s string) (uint64, error) {
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, s)
+	}
+	return n, nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/asaka1234/go-mt5-sdk/direct"
+	"github.com/asaka1234/go-mt5-sdk/simulator"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// mt5ctl 执行一次命令, 返回退出码和输出
+func mt5ctl(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// newGateway 启动模拟网关, 返回指向它的参数
+func newGateway(t *testing.T) (*simulator.Gateway, string) {
+	t.Helper()
+	t.Setenv("MT5_CONFIG", "")
+	g := simulator.NewGateway()
+	srv := httptest.NewServer(g)
+	t.Cleanup(srv.Close)
+	return g, "-gateway.address=" + srv.URL
+}
+
+func positions(t *testing.T, gw string, login uint64) []direct.MTPosition {
+	t.Helper()
+	code, stdout, stderr := mt5ctl(t, "", gw, "-o", "json", "positions", strconv.FormatUint(login, 10))
+	if code != 0 {
+		t.Fatalf("positions: exit %d: %s", code, stderr)
+	}
+	var list []direct.MTPosition
+	if err := json.Unmarshal([]byte(stdout), &list); err != nil {
+		t.Fatalf("positions: %v\n%s", err, stdout)
+	}
+	return list
+}
+
+func TestConfirm(t *testing.T) {
+	g, gw := newGateway(t)
+	login := g.CreateAccount(utils.MustParseMoney("10000"), 100)
+	open := []string{gw, "open", "-login", strconv.FormatUint(login, 10), "-symbol", "EURUSD", "-side", "buy", "-lots", "0.1"}
+	prompt := "open buy 0.1 lots EURUSD for login " + strconv.FormatUint(login, 10) + "? [y/N] "
+
+	for _, answer := range []string{"n\n", "\n", "", "yes please\n"} {
+		code, _, stderr := mt5ctl(t, answer, open...)
+		if code != 1 || !strings.HasPrefix(stderr, prompt) || !strings.Contains(stderr, "aborted") {
+			t.Fatalf("answer %q: exit %d, stderr %q", answer, code, stderr)
+		}
+	}
+	if list := positions(t, gw, login); len(list) != 0 {
+		t.Fatalf("aborted open created %d positions", len(list))
+	}
+
+	code, stdout, stderr := mt5ctl(t, "Y\n", open...)
+	if code != 0 || stderr != prompt || !strings.Contains(stdout, "TICKET") {
+		t.Fatalf("confirmed: exit %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+	//-y 不询问, 也不读stdin
+	code, _, stderr = mt5ctl(t, "n\n", append([]string{"-y"}, open...)...)
+	if code != 0 || stderr != "" {
+		t.Fatalf("-y: exit %d, stderr %q", code, stderr)
+	}
+	if list := positions(t, gw, login); len(list) != 2 {
+		t.Fatalf("%d positions, want 2", len(list))
+	}
+
+	//不动资金的命令不需要确认
+	if code, _, stderr = mt5ctl(t, "", gw, "account", strconv.FormatUint(login, 10)); code != 0 || stderr != "" {
+		t.Fatalf("account: exit %d, stderr %q", code, stderr)
+	}
+}
+
+func TestArgs(t *testing.T) {
+	g, gw := newGateway(t)
+	login := strconv.FormatUint(g.CreateAccount(utils.MustParseMoney("1000"), 100), 10)
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stderr string
+	}{
+		//参数错误在加载配置之前检查, 不需要网关地址
+		{"no command", nil, 2, "Usage: mt5ctl"},
+		{"unknown command", []string{"deposit"}, 2, `unknown command "deposit"`},
+		{"bad output format", []string{"-o", "xml", "symbols"}, 2, `invalid output format "xml"`},
+		{"missing argument", []string{"balance", login}, 2, "expected 2 argument(s), got 1"},
+		{"extra argument", []string{"account", login, "2"}, 2, "expected 1 argument(s), got 2"},
+		{"missing flags", []string{"open", "-login", login, "-symbol", "EURUSD"}, 2, "missing required flag(s): -side, -lots"},
+		{"unknown flag", []string{"close", "1", "-force"}, 2, "flag provided but not defined: -force"},
+		{"bad lots", []string{"open", "-login", login, "-symbol", "EURUSD", "-side", "buy", "-lots", "abc"}, 2, `invalid value "abc" for flag -lots`},
+		{"positions needs login or ticket", []string{"positions"}, 2, "Usage: mt5ctl positions"},
+		{"bad login", []string{"account", "abc"}, 1, `invalid login "abc"`},
+		{"negative login", []string{"positions", "-1001"}, 1, `invalid login "-1001"`},
+		{"bad ticket", []string{"close", "1.5"}, 1, `invalid ticket "1.5"`},
+		{"zero amount", []string{"balance", login, "0"}, 1, `invalid amount "0"`},
+		{"bad amount", []string{"balance", login, "1e3x"}, 1, `invalid amount "1e3x"`},
+		{"bad side", []string{"open", "-login", login, "-symbol", "EURUSD", "-side", "long", "-lots", "0.1"}, 1, `invalid -side "long"`},
+		{"bad expire", []string{"pending", "modify", "1", "-expire", "week"}, 1, `invalid -expire "week"`},
+		{"unknown pending command", []string{"pending", "cancel"}, 2, `unknown command "cancel"`},
+		{"help", []string{"-h"}, 0, "Commands:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := mt5ctl(t, "", tt.args...)
+			if code != tt.code || !strings.Contains(stderr, tt.stderr) {
+				t.Fatalf("exit %d, want %d; stderr %q, want %q", code, tt.code, stderr, tt.stderr)
+			}
+		})
+	}
+
+	//负数金额是位置参数, flag可以写在任意位置
+	code, _, stderr := mt5ctl(t, "y\n", gw, "balance", "-comment", "withdraw#1", login, "-250.5")
+	if code != 0 || !strings.HasPrefix(stderr, "withdraw 250.5 for login "+login+"? ") {
+		t.Fatalf("withdraw: exit %d, stderr %q", code, stderr)
+	}
+	code, _, stderr = mt5ctl(t, "y\n", gw, "balance", login, "100", "-comment=deposit#1")
+	if code != 0 || !strings.HasPrefix(stderr, "deposit 100 for login "+login+"? ") {
+		t.Fatalf("deposit: exit %d, stderr %q", code, stderr)
+	}
+	code, stdout, _ := mt5ctl(t, "", gw, "-o", "json", "account", login)
+	var acc direct.MTUserAccount
+	if code != 0 || json.Unmarshal([]byte(stdout), &acc) != nil || acc.Balance.String() != "849.5" {
+		t.Fatalf("account: exit %d, %s", code, stdout)
+	}
+}
+
+func TestOutputFormats(t *testing.T) {
+	g, gw := newGateway(t)
+	login := strconv.FormatUint(g.CreateAccount(utils.MustParseMoney("10000.5"), 100), 10)
+
+	//表格默认只显示部分列
+	code, stdout, _ := mt5ctl(t, "", gw, "symbols")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if code != 0 || len(lines) < 2 || strings.Fields(lines[0])[0] != "SYMBOL" || strings.Contains(lines[0], "DESC") {
+		t.Fatalf("table: exit %d\n%s", code, stdout)
+	}
+	if !strings.Contains(stdout, "EURUSD") {
+		t.Fatalf("table has no EURUSD:\n%s", stdout)
+	}
+
+	//json 输出原始结构
+	code, stdout, _ = mt5ctl(t, "", gw, "-o", "json", "symbols")
+	var symbols []direct.MT5SymbolBase
+	if code != 0 || json.Unmarshal([]byte(stdout), &symbols) != nil || len(symbols) != len(lines)-1 {
+		t.Fatalf("json: exit %d\n%s", code, stdout)
+	}
+
+	//csv 默认输出所有列, -columns 指定列
+	code, stdout, _ = mt5ctl(t, "", gw, "-o", "csv", "symbols")
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if code != 0 || err != nil || len(records) != len(symbols)+1 || !contains(records[0], "desc") {
+		t.Fatalf("csv: exit %d, %v\n%s", code, err, stdout)
+	}
+	code, stdout, _ = mt5ctl(t, "", gw, "-o", "csv", "-columns", "login,balance,margin_level", "account", login)
+	if want := "login,balance,margin_level\n" + login + ",10000.5,0.00\n"; code != 0 || stdout != want {
+		t.Fatalf("csv columns: exit %d, got %q, want %q", code, stdout, want)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGatewayErrors(t *testing.T) {
+	g, gw := newGateway(t)
+	login := strconv.FormatUint(g.CreateAccount(utils.MustParseMoney("100"), 100), 10)
+
+	//网关返回的业务错误
+	code, stdout, stderr := mt5ctl(t, "", gw, "account", "999999")
+	if code != 1 || stdout != "" || !strings.HasPrefix(stderr, "mt5ctl account: ") {
+		t.Fatalf("unknown login: exit %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+	code, _, stderr = mt5ctl(t, "", gw, "-y", "open", "-login", login, "-symbol", "EURUSD", "-side", "buy", "-lots", "50")
+	if code != 1 || !strings.Contains(stderr, "not enough money") {
+		t.Fatalf("no money: exit %d, stderr %q", code, stderr)
+	}
+
+	//网关不可用
+	srv := httptest.NewServer(simulator.NewGateway())
+	srv.Close()
+	code, _, stderr = mt5ctl(t, "", "-gateway.address="+srv.URL, "-gateway.retry.max_attempts=1", "symbols")
+	if code != 1 || !strings.HasPrefix(stderr, "mt5ctl symbols: ") {
+		t.Fatalf("gateway down: exit %d, stderr %q", code, stderr)
+	}
+
+	//配置错误
+	code, _, stderr = mt5ctl(t, "", "-gateway.address=ftp://127.0.0.1", "symbols")
+	if code != 1 || !strings.Contains(stderr, "gateway.address") {
+		t.Fatalf("bad config: exit %d, stderr %q", code, stderr)
+	}
+}
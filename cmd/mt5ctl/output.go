@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// printer 按 -o 输出结果. 表格和csv的列是结构体的json字段名(内嵌的结构体展开, 列表类型的字段跳过),
+// map按key排序; json直接输出原始结构
+type printer struct {
+	w       io.Writer
+	format  string
+	columns []string //-columns 指定的列
+
+	streamColumns []string //stream 已经输出过表头的列
+	streamWidths  []int    //stream 表格每一列的宽度, 只增不减, 这样后面的行和表头大致对齐
+}
+
+func newPrinter(w io.Writer, format, columns string) (*printer, error) {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+	default:
+		return nil, fmt.Errorf("invalid output format %q, use table, json or csv", format)
+	}
+	return &printer{w: w, format: format, columns: splitComma(columns)}, nil
+}
+
+// print 输出一次结果, defaults为表格默认显示的列(字段太多时), 为空则显示所有列
+func (p *printer) print(v interface{}, defaults ...string) error {
+	if p.format == formatJSON {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	all, rows := flatten(v)
+	columns := all
+	switch {
+	case len(p.columns) > 0:
+		columns = p.columns
+	case len(defaults) > 0 && p.format == formatTable:
+		columns = defaults
+	}
+	return p.write(columns, rows, true)
+}
+
+// stream 持续输出: json每条一行, 表格和csv只在第一次输出表头
+func (p *printer) stream(v interface{}) error {
+	if p.format == formatJSON {
+		items := reflect.ValueOf(v)
+		if items.Kind() != reflect.Slice {
+			return json.NewEncoder(p.w).Encode(v)
+		}
+		enc := json.NewEncoder(p.w)
+		for i := 0; i < items.Len(); i++ {
+			if err := enc.Encode(items.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	all, rows := flatten(v)
+	header := p.streamColumns == nil
+	if header {
+		p.streamColumns = all
+		if len(p.columns) > 0 {
+			p.streamColumns = p.columns
+		}
+	}
+	if p.format == formatTable {
+		return p.writeStream(rows, header)
+	}
+	return p.write(p.streamColumns, rows, header)
+}
+
+func (p *printer) writeStream(rows []map[string]string, header bool) error {
+	if header {
+		p.streamWidths = make([]int, len(p.streamColumns))
+		for i, c := range p.streamColumns {
+			p.streamWidths[i] = len(c)
+		}
+	}
+	for _, row := range rows {
+		for i, c := range p.streamColumns {
+			p.streamWidths[i] = max(p.streamWidths[i], len(row[c]))
+		}
+	}
+
+	var b strings.Builder
+	line := func(cell func(column string) string) {
+		var l strings.Builder
+		for i, c := range p.streamColumns {
+			if i > 0 {
+				l.WriteString("  ")
+			}
+			fmt.Fprintf(&l, "%-*s", p.streamWidths[i], cell(c))
+		}
+		b.WriteString(strings.TrimRight(l.String(), " ") + "\n")
+	}
+	if header {
+		line(strings.ToUpper)
+	}
+	for _, row := range rows {
+		line(func(c string) string { return row[c] })
+	}
+	_, err := io.WriteString(p.w, b.String())
+	return err
+}
+
+func (p *printer) write(columns []string, rows []map[string]string, header bool) error {
+	records := make([][]string, 0, len(rows)+1)
+	if header {
+		records = append(records, append([]string(nil), columns...))
+	}
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for i, c := range columns {
+			record[i] = row[c]
+		}
+		records = append(records, record)
+	}
+
+	if p.format == formatCSV {
+		w := csv.NewWriter(p.w)
+		if err := w.WriteAll(records); err != nil {
+			return err
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	for i, record := range records {
+		if header && i == 0 {
+			for j := range record {
+				record[j] = strings.ToUpper(record[j])
+			}
+		}
+		fmt.Fprintln(w, strings.Join(record, "\t"))
+	}
+	return w.Flush()
+}
+
+// flatten 把结构体/结构体列表/map转成行, 返回所有的列(按出现顺序)
+func flatten(v interface{}) ([]string, []map[string]string) {
+	val := indirect(reflect.ValueOf(v))
+	if !val.IsValid() {
+		return nil, nil
+	}
+
+	var items []reflect.Value
+	if val.Kind() == reflect.Slice || val.Kind() == reflect.Array {
+		for i := 0; i < val.Len(); i++ {
+			items = append(items, indirect(val.Index(i)))
+		}
+	} else {
+		items = []reflect.Value{val}
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+	rows := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		row := make(map[string]string)
+		var keys []string
+		flattenValue(item, row, &keys)
+		for _, k := range keys {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows
+}
+
+func flattenValue(v reflect.Value, row map[string]string, keys *[]string) {
+	set := func(k, s string) {
+		if _, ok := row[k]; !ok {
+			*keys = append(*keys, k)
+		}
+		row[k] = s
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			fv := indirect(v.Field(i))
+			if f.Anonymous && fv.Kind() == reflect.Struct {
+				flattenValue(fv, row, keys) //内嵌的结构体(CommonResp/MTOrder)展开
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if s, ok := scalar(fv); ok {
+				set(name, s)
+			}
+		}
+	case reflect.Map:
+		mapKeys := make([]string, 0, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+		for _, k := range v.MapKeys() {
+			key := fmt.Sprint(k.Interface())
+			mapKeys = append(mapKeys, key)
+			values[key] = v.MapIndex(k)
+		}
+		sort.Strings(mapKeys)
+		for _, k := range mapKeys {
+			if s, ok := scalar(indirect(values[k])); ok {
+				set(k, s)
+			}
+		}
+	default:
+		if s, ok := scalar(v); ok {
+			set("value", s)
+		}
+	}
+}
+
+// scalar 可以放在一个单元格里的值, 列表/结构体返回false
+func scalar(v reflect.Value) (string, bool) {
+	if !v.IsValid() {
+		return "", true
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String(), true
+	}
+	return "", false
+}
+
+// indirect 去掉指针和interface
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func splitComma(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	mt5 "github.com/asaka1234/go-mt5-sdk"
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"sort"
+	"sync"
+)
+
+// streams stream 支持的消息类型, 以及对应的订阅方法
+var streams = map[pumping.REQUEST_TYPE]func(s *mt5.Stream, symbols string) error{
+	pumping.REQUEST_TYPE_TICK:      func(s *mt5.Stream, symbols string) error { return s.SubscribeTick(symbols) },
+	pumping.REQUEST_TYPE_ORDER:     func(s *mt5.Stream, _ string) error { return s.SubscribeOrder() },
+	pumping.REQUEST_TYPE_POSITION:  func(s *mt5.Stream, _ string) error { return s.SubscribePosition() },
+	pumping.REQUEST_TYPE_DEAL:      func(s *mt5.Stream, _ string) error { return s.SubscribeDeal() },
+	pumping.REQUEST_TYPE_USER_ADD:  func(s *mt5.Stream, _ string) error { return s.SubscribeUserAdd() },
+	pumping.REQUEST_TYPE_MARGINCAL: func(s *mt5.Stream, _ string) error { return s.SubscribeMarginCall() },
+	pumping.REQUEST_TYPE_STOPOUT:   func(s *mt5.Stream, _ string) error { return s.SubscribeStopOut() },
+}
+
+func streamTypes() []string {
+	list := make([]string, 0, len(streams))
+	for typ := range streams {
+		list = append(list, string(typ))
+	}
+	sort.Strings(list)
+	return list
+}
+
+// runStream 一直输出推送的消息, 直到 Ctrl-C 或者收到 -n 条
+func runStream(a *app, args []string) error {
+	fs := a.flags("stream", "<type> [-symbols A,B] [-n N]")
+	symbols := fs.String("symbols", "", "tick only: comma separated symbols, empty for all")
+	limit := fs.Int("n", 0, "exit after N messages, 0 to run until interrupted")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	typ := pumping.REQUEST_TYPE(pos[0])
+	subscribe, ok := streams[typ]
+	if !ok {
+		return fmt.Errorf("unknown type %q, use one of %v", typ, streamTypes())
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	stream := a.cli.Stream()
+	if stream == nil {
+		return errors.New("pumping is not configured, set pumping.server_addr")
+	}
+
+	done := make(chan struct{})
+	var once sync.Once
+	var count int
+	stream.Handler.OnErrorFunc = func(err error) {
+		fmt.Fprintf(a.stderr, "mt5ctl stream: %v\n", err)
+	}
+	stream.Handler.RegisterTypedHandler(typ, nil, func(_ *pumping.TCPResponse, payload interface{}) error {
+		if ticks, ok := payload.([]pumping.MT5Tick); ok {
+			rows := make([]tickRow, len(ticks))
+			for i, t := range ticks {
+				rows[i] = newTickRow(t.Symbol, t.Ask(), t.Bid(), t.Last(), t.Volume, t.Time)
+			}
+			payload = rows
+		}
+		if err := a.out.stream(payload); err != nil {
+			return err
+		}
+		if count++; *limit > 0 && count >= *limit {
+			once.Do(func() { close(done) })
+		}
+		return nil
+	})
+
+	if err := a.cli.Start(); err != nil {
+		return err
+	}
+	if err := subscribe(stream, *symbols); err != nil {
+		return err
+	}
+	select {
+	case <-a.ctx.Done():
+	case <-done:
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package mt5
+
+import (
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"time"
+)
+
+// Config 整个SDK的配置
+type Config struct {
+	Gateway GatewayConfig   `json:"gateway" mapstructure:"gateway" config:"gateway" yaml:"gateway"`           // REST网关, Market/Accounts/Trading共用
+	Trading *GatewayConfig  `json:"trading,omitempty" mapstructure:"trading" config:"trading" yaml:"trading"` // 交易网关单独配置, nil则和Gateway相同
+	Pumping *pumping.Config `json:"pumping,omitempty" mapstructure:"pumping" config:"pumping" yaml:"pumping"` // pumping推送, nil则不使用Stream
+}
+
+// GatewayConfig REST网关的配置, 字段和 direct.InitParams / order.InitParams 一致
+type GatewayConfig struct {
+	Address   string        `json:"address" mapstructure:"address" config:"address" yaml:"address"`         // http://ip:port这样的地址, 主网关
+	Addresses []string      `json:"addresses" mapstructure:"addresses" config:"addresses" yaml:"addresses"` // 备用网关, 按优先级排列
+	Timeout   time.Duration `json:"timeout" mapstructure:"timeout" config:"timeout" yaml:"timeout"`         // 单次http请求的超时, 0则不限制
+
+	TLS            *utils.TLSConfig            `json:"tls,omitempty" mapstructure:"tls" config:"tls" yaml:"tls"`
+	Transport      *utils.TransportConfig      `json:"transport,omitempty" mapstructure:"transport" config:"transport" yaml:"transport"`
+	Retry          *utils.RetryPolicy          `json:"retry,omitempty" mapstructure:"retry" config:"retry" yaml:"retry"`
+	Sign           *utils.SignConfig           `json:"sign,omitempty" mapstructure:"sign" config:"sign" yaml:"sign"`
+	RateLimit      *utils.RateLimitConfig      `json:"rate_limit,omitempty" mapstructure:"rate_limit" config:"rate_limit" yaml:"rate_limit"`
+	Failover       *utils.FailoverConfig       `json:"failover,omitempty" mapstructure:"failover" config:"failover" yaml:"failover"`
+	Log            *utils.LogConfig            `json:"log,omitempty" mapstructure:"log" config:"log" yaml:"log"`
+	CircuitBreaker *utils.CircuitBreakerConfig `json:"circuit_breaker,omitempty" mapstructure:"circuit_breaker" config:"circuit_breaker" yaml:"circuit_breaker"`
+}
+
+// params direct.InitParams 和 order.InitParams 是同一个类型 utils.ClientParams
+func (c GatewayConfig) params() *utils.ClientParams {
+	return &utils.ClientParams{
+		Address:        c.Address,
+		Addresses:      c.Addresses,
+		Timeout:        c.Timeout,
+		TLS:            c.TLS,
+		Transport:      c.Transport,
+		Retry:          c.Retry,
+		Sign:           c.Sign,
+		RateLimit:      c.RateLimit,
+		Failover:       c.Failover,
+		Log:            c.Log,
+		CircuitBreaker: c.CircuitBreaker,
+	}
+}
@@ -0,0 +1,39 @@
+package direct
+
+import "context"
+
+// MarketAPI 品种和报价
+type MarketAPI interface {
+	ListSymbol() (*ListSymbolResp, error)
+	ListSymbolWithContext(ctx context.Context) (*ListSymbolResp, error)
+	TickReview() (*TickReviewResp, error)
+	TickReviewWithContext(ctx context.Context) (*TickReviewResp, error)
+}
+
+// AccountAPI 开户/出入金/资金, 以及持仓和挂单的查询
+type AccountAPI interface {
+	UserCreate(req UserCreateReq) (*UserCreateResp, error)
+	UserCreateWithContext(ctx context.Context, req UserCreateReq) (*UserCreateResp, error)
+	UserAccountDetail(login uint64) (*UserAccountDetailResp, error)
+	UserAccountDetailWithContext(ctx context.Context, login uint64) (*UserAccountDetailResp, error)
+	BalanceOperation(req BalanceOperationReq) (*BalanceOperationResp, error)
+	BalanceOperationWithContext(ctx context.Context, req BalanceOperationReq) (*BalanceOperationResp, error)
+
+	ListPosition(login uint64) (*ListPositionResp, error)
+	ListPositionWithContext(ctx context.Context, login uint64) (*ListPositionResp, error)
+	ListPendingOrder(login uint64) (*ListPendingOrderResp, error)
+	ListPendingOrderWithContext(ctx context.Context, login uint64) (*ListPendingOrderResp, error)
+	OrderGet(ticket uint64) (*GetOrderResp, error)
+	OrderGetWithContext(ctx context.Context, ticket uint64) (*GetOrderResp, error)
+	PositionGet(ticket uint64) (*GetPositionResp, error)
+	PositionGetWithContext(ctx context.Context, ticket uint64) (*GetPositionResp, error)
+}
+
+// DirectAPI Client 提供的所有查询/账户接口, 业务代码依赖它而不是 *Client, 测试时可以换成 directmock.Fake
+// Use/SetXXX 等配置方法只在组装时使用, 不在接口里
+type DirectAPI interface {
+	MarketAPI
+	AccountAPI
+}
+
+var _ DirectAPI = (*Client)(nil)
@@ -1,28 +1,13 @@
 package direct
 
-import (
-	"github.com/asaka1234/go-mt5-sdk/utils"
-	"github.com/go-resty/resty/v2"
-)
+import "github.com/asaka1234/go-mt5-sdk/utils"
 
+// Client 可以在多个goroutine之间共享, 请求流程(重试/熔断/多网关/限流/签名/中间件)和配置方法都来自 utils.RestClient
 type Client struct {
-	Params *InitParams
-
-	ryClient  *resty.Client
-	debugMode bool
-	logger    utils.Logger
+	*utils.RestClient
 }
 
+// NewClient logger为nil时不输出日志; 实现了 utils.StructuredLogger 的logger会带上login/symbol等字段
 func NewClient(logger utils.Logger, params *InitParams) *Client {
-	return &Client{
-		Params: params,
-
-		ryClient:  resty.New(), //client实例
-		debugMode: false,
-		logger:    logger,
-	}
-}
-
-func (cli *Client) SetDebugModel(debugModel bool) {
-	cli.debugMode = debugModel
+	return &Client{RestClient: utils.NewRestClient(logger, params)}
 }
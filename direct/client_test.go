@@ -0,0 +1,110 @@
+package direct_test
+
+import (
+	"github.com/asaka1234/go-mt5-sdk/direct"
+	"github.com/asaka1234/go-mt5-sdk/order"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// connCounter 记录服务端看到的tcp连接, 以及每个客户端用过的连接
+type connCounter struct {
+	mu       sync.Mutex
+	opened   int
+	open     int
+	maxOpen  int
+	byMethod map[string]map[string]bool //http method -> 客户端的连接地址
+}
+
+func (c *connCounter) connState(_ net.Conn, state http.ConnState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch state {
+	case http.StateNew:
+		c.opened++
+		c.open++
+		c.maxOpen = max(c.maxOpen, c.open)
+	case http.StateClosed, http.StateHijacked:
+		c.open--
+	}
+}
+
+func (c *connCounter) used(method, remote string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.byMethod[method] == nil {
+		c.byMethod[method] = make(map[string]bool)
+	}
+	c.byMethod[method][remote] = true
+}
+
+// 同一个进程里的查询和交易客户端各自维护连接池, 并发请求时连接数不超过 MaxConnsPerHost, 之后的请求复用空闲连接
+func TestClientConnectionReuse(t *testing.T) {
+	counter := &connCounter{byMethod: make(map[string]map[string]bool)}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		//按客户端区分: 查询接口都是GET, 交易接口都是POST
+		counter.used(r.Method, r.RemoteAddr)
+		time.Sleep(2 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"code":0,"success":true,"message":"success","data":[]}`)
+	}))
+	srv.Config.ConnState = counter.connState
+	srv.Start()
+	defer srv.Close()
+
+	const maxConns = 4
+	transport := &utils.TransportConfig{MaxConnsPerHost: maxConns}
+	directCli := direct.NewClient(nil, &direct.InitParams{Address: srv.URL, Transport: transport})
+	orderCli := order.NewClient(nil, &order.InitParams{Address: srv.URL, Transport: transport})
+
+	burst := func(n int) {
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				if _, err := directCli.ListSymbol(); err != nil {
+					t.Error(err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				if _, err := orderCli.OpenPosition(order.OpenPositionRequest{Login: 1001, Symbol: "EURUSD"}); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	burst(100)
+	counter.mu.Lock()
+	opened, maxOpen := counter.opened, counter.maxOpen
+	for method, conns := range counter.byMethod {
+		if len(conns) > maxConns {
+			t.Errorf("%s client used %d connections, MaxConnsPerHost is %d", method, len(conns), maxConns)
+		}
+	}
+	counter.mu.Unlock()
+	if maxOpen > 2*maxConns {
+		t.Fatalf("%d connections open at once, want at most %d", maxOpen, 2*maxConns)
+	}
+
+	//第二批请求全部复用空闲连接
+	burst(100)
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+	if counter.opened != opened {
+		t.Fatalf("second burst opened %d new connections, want 0", counter.opened-opened)
+	}
+	if len(counter.byMethod) != 2 {
+		t.Fatalf("requests by method: %v", counter.byMethod)
+	}
+}
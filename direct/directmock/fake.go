@@ -0,0 +1,194 @@
+package directmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/direct"
+	"sync"
+)
+
+// ErrNotProgrammed 调用了没有设置 XXXFunc 的方法
+var ErrNotProgrammed = errors.New("directmock: method is not programmed")
+
+// Call 一次调用的记录, 带ctx和不带ctx的两个版本记录成同一个Method
+type Call struct {
+	Method string
+	Ctx    context.Context
+	Arg    interface{} //请求参数(login/ticket/req), 没有参数时为nil
+}
+
+// Fake 手写的 direct.DirectAPI 实现, 记录所有调用, 返回值由对应的 XXXFunc 决定, 没有设置时返回 ErrNotProgrammed
+//
+//	fake := &directmock.Fake{
+//		UserAccountDetailFunc: func(ctx context.Context, login uint64) (*direct.UserAccountDetailResp, error) {
+//			return nil, utils.RetcodeNotFound
+//		},
+//	}
+//	svc := NewService(fake) //依赖 direct.DirectAPI
+//	...
+//	calls := fake.CallsTo("UserAccountDetail")
+type Fake struct {
+	ListSymbolFunc        func(ctx context.Context) (*direct.ListSymbolResp, error)
+	TickReviewFunc        func(ctx context.Context) (*direct.TickReviewResp, error)
+	UserCreateFunc        func(ctx context.Context, req direct.UserCreateReq) (*direct.UserCreateResp, error)
+	UserAccountDetailFunc func(ctx context.Context, login uint64) (*direct.UserAccountDetailResp, error)
+	BalanceOperationFunc  func(ctx context.Context, req direct.BalanceOperationReq) (*direct.BalanceOperationResp, error)
+	ListPositionFunc      func(ctx context.Context, login uint64) (*direct.ListPositionResp, error)
+	ListPendingOrderFunc  func(ctx context.Context, login uint64) (*direct.ListPendingOrderResp, error)
+	OrderGetFunc          func(ctx context.Context, ticket uint64) (*direct.GetOrderResp, error)
+	PositionGetFunc       func(ctx context.Context, ticket uint64) (*direct.GetPositionResp, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ direct.DirectAPI = (*Fake)(nil)
+
+// Calls 所有调用, 按调用顺序
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo 某个方法的所有调用, method不带 WithContext 后缀
+func (f *Fake) CallsTo(method string) []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []Call
+	for _, c := range f.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset 清空调用记录, 不影响已经设置的 XXXFunc
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+func (f *Fake) record(ctx context.Context, method string, arg interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Method: method, Ctx: ctx, Arg: arg})
+}
+
+func notProgrammed(method string) error {
+	return fmt.Errorf("%w: %s", ErrNotProgrammed, method)
+}
+
+//------------------------------------------------------------------------
+
+func (f *Fake) ListSymbol() (*direct.ListSymbolResp, error) {
+	return f.ListSymbolWithContext(context.Background())
+}
+
+func (f *Fake) ListSymbolWithContext(ctx context.Context) (*direct.ListSymbolResp, error) {
+	f.record(ctx, "ListSymbol", nil)
+	if f.ListSymbolFunc == nil {
+		return nil, notProgrammed("ListSymbol")
+	}
+	return f.ListSymbolFunc(ctx)
+}
+
+func (f *Fake) TickReview() (*direct.TickReviewResp, error) {
+	return f.TickReviewWithContext(context.Background())
+}
+
+func (f *Fake) TickReviewWithContext(ctx context.Context) (*direct.TickReviewResp, error) {
+	f.record(ctx, "TickReview", nil)
+	if f.TickReviewFunc == nil {
+		return nil, notProgrammed("TickReview")
+	}
+	return f.TickReviewFunc(ctx)
+}
+
+func (f *Fake) UserCreate(req direct.UserCreateReq) (*direct.UserCreateResp, error) {
+	return f.UserCreateWithContext(context.Background(), req)
+}
+
+func (f *Fake) UserCreateWithContext(ctx context.Context, req direct.UserCreateReq) (*direct.UserCreateResp, error) {
+	f.record(ctx, "UserCreate", req)
+	if f.UserCreateFunc == nil {
+		return nil, notProgrammed("UserCreate")
+	}
+	return f.UserCreateFunc(ctx, req)
+}
+
+func (f *Fake) UserAccountDetail(login uint64) (*direct.UserAccountDetailResp, error) {
+	return f.UserAccountDetailWithContext(context.Background(), login)
+}
+
+func (f *Fake) UserAccountDetailWithContext(ctx context.Context, login uint64) (*direct.UserAccountDetailResp, error) {
+	f.record(ctx, "UserAccountDetail", login)
+	if f.UserAccountDetailFunc == nil {
+		return nil, notProgrammed("UserAccountDetail")
+	}
+	return f.UserAccountDetailFunc(ctx, login)
+}
+
+func (f *Fake) BalanceOperation(req direct.BalanceOperationReq) (*direct.BalanceOperationResp, error) {
+	return f.BalanceOperationWithContext(context.Background(), req)
+}
+
+func (f *Fake) BalanceOperationWithContext(ctx context.Context, req direct.BalanceOperationReq) (*direct.BalanceOperationResp, error) {
+	f.record(ctx, "BalanceOperation", req)
+	if f.BalanceOperationFunc == nil {
+		return nil, notProgrammed("BalanceOperation")
+	}
+	return f.BalanceOperationFunc(ctx, req)
+}
+
+func (f *Fake) ListPosition(login uint64) (*direct.ListPositionResp, error) {
+	return f.ListPositionWithContext(context.Background(), login)
+}
+
+func (f *Fake) ListPositionWithContext(ctx context.Context, login uint64) (*direct.ListPositionResp, error) {
+	f.record(ctx, "ListPosition", login)
+	if f.ListPositionFunc == nil {
+		return nil, notProgrammed("ListPosition")
+	}
+	return f.ListPositionFunc(ctx, login)
+}
+
+func (f *Fake) ListPendingOrder(login uint64) (*direct.ListPendingOrderResp, error) {
+	return f.ListPendingOrderWithContext(context.Background(), login)
+}
+
+func (f *Fake) ListPendingOrderWithContext(ctx context.Context, login uint64) (*direct.ListPendingOrderResp, error) {
+	f.record(ctx, "ListPendingOrder", login)
+	if f.ListPendingOrderFunc == nil {
+		return nil, notProgrammed("ListPendingOrder")
+	}
+	return f.ListPendingOrderFunc(ctx, login)
+}
+
+func (f *Fake) OrderGet(ticket uint64) (*direct.GetOrderResp, error) {
+	return f.OrderGetWithContext(context.Background(), ticket)
+}
+
+func (f *Fake) OrderGetWithContext(ctx context.Context, ticket uint64) (*direct.GetOrderResp, error) {
+	f.record(ctx, "OrderGet", ticket)
+	if f.OrderGetFunc == nil {
+		return nil, notProgrammed("OrderGet")
+	}
+	return f.OrderGetFunc(ctx, ticket)
+}
+
+func (f *Fake) PositionGet(ticket uint64) (*direct.GetPositionResp, error) {
+	return f.PositionGetWithContext(context.Background(), ticket)
+}
+
+func (f *Fake) PositionGetWithContext(ctx context.Context, ticket uint64) (*direct.GetPositionResp, error) {
+	f.record(ctx, "PositionGet", ticket)
+	if f.PositionGetFunc == nil {
+		return nil, notProgrammed("PositionGet")
+	}
+	return f.PositionGetFunc(ctx, ticket)
+}
@@ -0,0 +1,130 @@
+package directmock_test
+
+import (
+	"context"
+	"errors"
+	"github.com/asaka1234/go-mt5-sdk/direct"
+	"github.com/asaka1234/go-mt5-sdk/direct/directmock"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type ctxKey struct{}
+
+func TestFakeProgrammed(t *testing.T) {
+	fake := &directmock.Fake{
+		UserAccountDetailFunc: func(ctx context.Context, login uint64) (*direct.UserAccountDetailResp, error) {
+			if login == 404 {
+				return nil, utils.RetcodeNotFound
+			}
+			return &direct.UserAccountDetailResp{Data: direct.MTUserAccount{Login: login}}, nil
+		},
+		ListSymbolFunc: func(ctx context.Context) (*direct.ListSymbolResp, error) {
+			return &direct.ListSymbolResp{Data: []direct.MT5SymbolBase{{Symbol: "EURUSD"}}}, nil
+		},
+	}
+
+	resp, err := fake.UserAccountDetail(1001)
+	if err != nil || resp.Data.Login != 1001 {
+		t.Fatalf("UserAccountDetail = %+v, %v", resp, err)
+	}
+	if _, err := fake.UserAccountDetail(404); !errors.Is(err, utils.RetcodeNotFound) {
+		t.Fatalf("err = %v, want RetcodeNotFound", err)
+	}
+	symbols, err := fake.ListSymbol()
+	if err != nil || len(symbols.Data) != 1 || symbols.Data[0].Symbol != "EURUSD" {
+		t.Fatalf("ListSymbol = %+v, %v", symbols, err)
+	}
+	//没有设置的方法返回 ErrNotProgrammed, 调用同样会被记录
+	if _, err := fake.PositionGet(7); !errors.Is(err, directmock.ErrNotProgrammed) {
+		t.Fatalf("err = %v, want ErrNotProgrammed", err)
+	}
+	if calls := fake.CallsTo("PositionGet"); len(calls) != 1 || calls[0].Arg != uint64(7) {
+		t.Fatalf("PositionGet calls = %+v", calls)
+	}
+}
+
+func TestFakeRecordsCalls(t *testing.T) {
+	fake := &directmock.Fake{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "req-1")
+	req := direct.BalanceOperationReq{Login: 1001, Balance: utils.MustParseMoney("100"), Comment: "order-1"}
+
+	fake.ListPosition(1001)
+	fake.BalanceOperationWithContext(ctx, req)
+	fake.TickReview()
+	fake.ListPositionWithContext(ctx, 1002)
+
+	calls := fake.Calls()
+	want := []struct {
+		method string
+		arg    interface{}
+	}{
+		{"ListPosition", uint64(1001)},
+		{"BalanceOperation", req},
+		{"TickReview", nil},
+		{"ListPosition", uint64(1002)},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("%d calls, want %d: %+v", len(calls), len(want), calls)
+	}
+	for i, w := range want {
+		if calls[i].Method != w.method || !reflect.DeepEqual(calls[i].Arg, w.arg) {
+			t.Errorf("call %d = %s(%v), want %s(%v)", i, calls[i].Method, calls[i].Arg, w.method, w.arg)
+		}
+	}
+	//不带ctx的版本记录的是 context.Background()
+	if calls[0].Ctx != context.Background() || calls[1].Ctx.Value(ctxKey{}) != "req-1" {
+		t.Errorf("ctx not recorded: %v, %v", calls[0].Ctx, calls[1].Ctx)
+	}
+	if positions := fake.CallsTo("ListPosition"); len(positions) != 2 || positions[1].Arg != uint64(1002) {
+		t.Errorf("CallsTo(ListPosition) = %+v", positions)
+	}
+
+	//Calls 返回的是副本
+	calls[0].Method = "changed"
+	if fake.Calls()[0].Method != "ListPosition" {
+		t.Error("Calls() shares its slice with the fake")
+	}
+
+	fake.Reset()
+	if len(fake.Calls()) != 0 {
+		t.Fatal("calls left after Reset")
+	}
+}
+
+func TestFakeConcurrent(t *testing.T) {
+	fake := &directmock.Fake{
+		OrderGetFunc: func(ctx context.Context, ticket uint64) (*direct.GetOrderResp, error) {
+			return &direct.GetOrderResp{}, nil
+		},
+	}
+	const workers, perWorker = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				if _, err := fake.OrderGet(uint64(w*perWorker + i)); err != nil {
+					t.Error(err)
+				}
+				fake.CallsTo("OrderGet")
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	calls := fake.Calls()
+	if len(calls) != workers*perWorker {
+		t.Fatalf("%d calls, want %d", len(calls), workers*perWorker)
+	}
+	seen := make(map[uint64]bool, len(calls))
+	for _, c := range calls {
+		seen[c.Arg.(uint64)] = true
+	}
+	if len(seen) != workers*perWorker {
+		t.Fatalf("%d distinct tickets recorded, want %d", len(seen), workers*perWorker)
+	}
+}
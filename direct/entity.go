@@ -1,17 +1,13 @@
 package direct
 
-type InitParams struct {
-	Address string `json:"address" mapstructure:"address" config:"address" yaml:"address"` // http://ip:port这样的地址
-}
+import "github.com/asaka1234/go-mt5-sdk/utils"
+
+// InitParams 网关的配置, 和 order.InitParams 是同一个类型
+type InitParams = utils.ClientParams
 
 //------------------------------------------------------------------------
 
-type CommonResp struct {
-	Code    int    `json:"code"`    //错误码 0是成功
-	Success bool   `json:"success"` //是否成功
-	Message string `json:"message"` //错误信息
-	//Data    interface{} `json:"data,omitempty"` //数据
-}
+type CommonResp = utils.CommonResp
 
 type ListSymbolResp struct {
 	CommonResp `json:",inline"`
@@ -32,13 +28,13 @@ type MT5SymbolBase struct {
 	CurrencyMargin      string `json:"currency_margin"` //保证金货币
 	CurrencyMarginDigit uint   `json:"currency_margin_digit"`
 	//-----------交易------------------------
-	ContractSize string `json:"contract_size"` //合约量
-	CalcMode     uint   `json:"calc_mode"`     //利润/swap计算
-	TradeMode    uint   `json:"trade_mode"`    //交易模式,比如:long_only https://support.metaquotes.net/en/docs/mt5/api/config_symbol/imtconsymbol/imtconsymbol_enum#entrademode
-	GTCMode      uint   `json:"gtc_mode"`      //直到挂单取消
-	VolumeMin    string `json:"volume_min"`    //最小下单手数
-	VolumeMax    string `json:"volume_max"`    //最大下单手数
-	VolumeStep   string `json:"volume_step"`   //下单步长
+	ContractSize string          `json:"contract_size"` //合约量
+	CalcMode     utils.CalcMode  `json:"calc_mode"`     //利润/swap计算
+	TradeMode    utils.TradeMode `json:"trade_mode"`    //交易模式,比如:long_only https://support.metaquotes.net/en/docs/mt5/api/config_symbol/imtconsymbol/imtconsymbol_enum#entrademode
+	GTCMode      utils.GTCMode   `json:"gtc_mode"`      //直到挂单取消
+	VolumeMin    utils.Volume    `json:"volume_min"`    //最小下单手数
+	VolumeMax    utils.Volume    `json:"volume_max"`    //最大下单手数
+	VolumeStep   utils.Volume    `json:"volume_step"`   //下单步长
 
 	StopsLevel  int `json:"stops_level"`  //sl/tp的价格设置差值
 	FreezeLevel int `json:"freeze_level"` //利润/swap计算
@@ -54,16 +50,31 @@ type MT5SymbolBase struct {
 	MarginRateMainSell string `json:"margin_rate_main_sell"` //percentage针对sell方向
 
 	//-------------利息----------------------
-	SwapMode  uint   `json:"swap_mode"`  //库存费类型
-	SwapLong  string `json:"swap_long"`  //买入库存费
-	SwapShort string `json:"swap_short"` //卖出库存费
-	Swap3Day  uint   `json:"swap_3_day"` //3日库存费
+	SwapMode  utils.SwapMode `json:"swap_mode"`  //库存费类型
+	SwapLong  string         `json:"swap_long"`  //买入库存费
+	SwapShort string         `json:"swap_short"` //卖出库存费
+	Swap3Day  uint           `json:"swap_3_day"` //3日库存费
 
 	//-------------交易时间----------------------
 	SessionTrade []SessionInfo `json:"session_trade"` //每周七天的一个列表
 	SessionQuote []SessionInfo `json:"session_quote"` //每周七天的一个列表
 }
 
+// Point 最小价格变动, 比如 Digit=5 时为 0.00001
+func (s MT5SymbolBase) Point() utils.Price {
+	return utils.PriceFromPoints(1, s.Digit)
+}
+
+// FormatPrice 按品种精度格式化价格
+func (s MT5SymbolBase) FormatPrice(p utils.Price) string {
+	return p.Format(s.Digit)
+}
+
+// Points 价格距离(比如sl和开仓价的距离)换算成点数
+func (s MT5SymbolBase) Points(distance utils.Price) (int64, error) {
+	return utils.Points(distance, s.Digit)
+}
+
 type SessionInfo struct {
 	Wday     uint     `json:"wday"` //Day of the week. The day is specified by a value 0 (Sunday) to 6 (Saturday).
 	Sessions []string `json:"sessions"`
@@ -78,13 +89,28 @@ type TickReviewResp struct {
 
 type MT5Tick struct {
 	Symbol string `json:"symbol"`
-	AskE8  int64  `json:"ask"`  //都是扩大了 10e8倍
-	BidE8  int64  `json:"bid"`  //都是扩大了 10e8倍
-	LastE8 int64  `json:"last"` //都是扩大了 10e8倍
+	AskE8  int64  `json:"ask"`  //扩大了1e8倍, 用 Ask()/Bid()/Last() 转成价格
+	BidE8  int64  `json:"bid"`  //扩大了1e8倍, 用 Ask()/Bid()/Last() 转成价格
+	LastE8 int64  `json:"last"` //扩大了1e8倍, 用 Ask()/Bid()/Last() 转成价格
 	Volume uint64 `json:"volume"`
 	Time   int64  `json:"time"` //unix时间戳(ms毫秒)
 }
 
+func (t MT5Tick) Ask() utils.Price  { return utils.PriceFromE8(t.AskE8) }
+func (t MT5Tick) Bid() utils.Price  { return utils.PriceFromE8(t.BidE8) }
+func (t MT5Tick) Last() utils.Price { return utils.PriceFromE8(t.LastE8) }
+
+// Spread 点差 ask-bid
+func (t MT5Tick) Spread() utils.Price { return utils.Spread(t.Ask(), t.Bid()) }
+
+// Mid 中间价 (ask+bid)/2
+func (t MT5Tick) Mid() utils.Price { return utils.Mid(t.Ask(), t.Bid()) }
+
+// SpreadPoints 点差的点数, digits为品种的 Digit
+func (t MT5Tick) SpreadPoints(digits uint) (int64, error) {
+	return utils.Points(t.Spread(), digits)
+}
+
 //-------------------------------------
 
 type UserCreateReq struct {
@@ -107,9 +133,9 @@ type Mt5User struct {
 //-------------------------------------
 
 type BalanceOperationReq struct {
-	Login   uint64  `json:"login,omitempty"`   //mt5的login
-	Balance float64 `json:"balance,omitempty"` //上账多少,支持浮点数和负数
-	Comment string  `json:"comment,omitempty"` //备注(传这边的order id过去)
+	Login   uint64      `json:"login,omitempty"`   //mt5的login
+	Balance utils.Money `json:"balance,omitzero"`  //上账多少,支持小数和负数
+	Comment string      `json:"comment,omitempty"` //备注(传这边的order id过去)
 }
 
 type BalanceOperationResp struct {
@@ -129,15 +155,15 @@ type UserAccountDetailResp struct {
 }
 
 type MTUserAccount struct {
-	Login          uint64 `json:"login"`   //当前要操作的account的login
-	Balance        string `json:"balance"` //余额
-	Margin         string `json:"margin"`  //已用保证金
-	MarginFree     string `json:"margin_free"`
-	MarginLevel    string `json:"margin_level"`
-	MarginLeverage uint   `json:"margin_leverage"` //杠杆
-	Equity         string `json:"equity"`
-	Storage        string `json:"storage"`
-	Floating       string `json:"floating"`
+	Login          uint64      `json:"login"`   //当前要操作的account的login
+	Balance        utils.Money `json:"balance"` //余额
+	Margin         utils.Money `json:"margin"`  //已用保证金
+	MarginFree     utils.Money `json:"margin_free"`
+	MarginLevel    string      `json:"margin_level"`
+	MarginLeverage uint        `json:"margin_leverage"` //杠杆
+	Equity         utils.Money `json:"equity"`
+	Storage        utils.Money `json:"storage"`
+	Floating       utils.Money `json:"floating"`
 }
 
 //-----------------------------------------------
@@ -147,22 +173,22 @@ type ListPositionResp struct {
 	Data       []*MTPosition `json:"data,omitempty"` //数据
 }
 type MTPosition struct {
-	Login          uint64  `json:"login"`
-	Ticket         uint64  `json:"ticket"` //position_id
-	Symbol         string  `json:"symbol"`
-	Action         uint    `json:"action"`     // 0-buy, 1-sell
-	PriceOpen      string  `json:"price_open"` //开仓价  float64
-	PriceSL        string  `json:"price_sl"`   // float64
-	PriceTP        string  `json:"price_tp"`   // float64
-	RateMargin     float64 `json:"rate_margin"`
-	RateProfit     float64 `json:"rate_profit"`
-	Volume         float64 `json:"volume"` //lots
-	Profit         string  `json:"profit"`
-	Storage        string  `json:"storage"`
-	ActivationMode uint    `json:"activation_mode"` //1-sl, 2-tp, 3-so
-	ActivationTime int64   `json:"activation_time"` //unix时间戳(s)
-	TimeCreate     int64   `json:"time_create"`     //unix时间戳(s)
-	Comment        string  `json:"comment"`         //备注
+	Login          uint64                   `json:"login"`
+	Ticket         uint64                   `json:"ticket"` //position_id
+	Symbol         string                   `json:"symbol"`
+	Action         utils.PositionAction     `json:"action"`     // 0-buy, 1-sell
+	PriceOpen      utils.Price              `json:"price_open"` //开仓价
+	PriceSL        utils.Price              `json:"price_sl"`
+	PriceTP        utils.Price              `json:"price_tp"`
+	RateMargin     float64                  `json:"rate_margin"`
+	RateProfit     float64                  `json:"rate_profit"`
+	Volume         utils.Volume             `json:"volume"` //lots
+	Profit         utils.Money              `json:"profit"`
+	Storage        utils.Money              `json:"storage"`
+	ActivationMode utils.PositionActivation `json:"activation_mode"` //1-sl, 2-tp, 3-so
+	ActivationTime int64                    `json:"activation_time"` //unix时间戳(s)
+	TimeCreate     int64                    `json:"time_create"`     //unix时间戳(s)
+	Comment        string                   `json:"comment"`         //备注
 }
 
 //-----------------------------------------------
@@ -173,20 +199,20 @@ type ListPendingOrderResp struct {
 }
 
 type MTOrder struct {
-	Login          uint64  `json:"login"`
-	Ticket         uint64  `json:"ticket"` //order_id
-	Symbol         string  `json:"symbol"`
-	State          uint    `json:"state"`           //1是挂单  ORDER_STATE_PLACED
-	ActivationMode uint    `json:"activation_mode"` //激活模式  //0-none, 1=ACTIVATION_PENDING, 2=ACTIVATION_STOPLIMIT,3=ACTIVATION_EXPIRATION,4=ACTIVATION_STOPOUT
-	TimeSetup      int64   `json:"time_setup"`      //下单时间
-	Type           uint    `json:"type"`            //0-buy, 1-sell,2-buy limit ,3-sell limit, 4-buy stop, 5-sell stop, 6-buy stop limit, 7-sell stop limit,
-	PriceOrder     string  `json:"price_order"`     //下单价格 (stop/limit的价格)
-	PriceTrigger   string  `json:"price_trigger"`   //触发价格（stop limit 单）
-	PriceSL        string  `json:"price_sl"`
-	PriceTP        string  `json:"price_tp"`
-	Volume         float64 `json:"volume"` //lots
-	RateMargin     float64 `json:"rate_margin"`
-	Comment        string  `json:"comment"` //备注
+	Login          uint64                `json:"login"`
+	Ticket         uint64                `json:"ticket"` //order_id
+	Symbol         string                `json:"symbol"`
+	State          utils.OrderState      `json:"state"`           //1是挂单  ORDER_STATE_PLACED
+	ActivationMode utils.OrderActivation `json:"activation_mode"` //激活模式  //0-none, 1=ACTIVATION_PENDING, 2=ACTIVATION_STOPLIMIT,3=ACTIVATION_EXPIRATION,4=ACTIVATION_STOPOUT
+	TimeSetup      int64                 `json:"time_setup"`      //下单时间
+	Type           utils.OrderType       `json:"type"`            //0-buy, 1-sell,2-buy limit ,3-sell limit, 4-buy stop, 5-sell stop, 6-buy stop limit, 7-sell stop limit,
+	PriceOrder     utils.Price           `json:"price_order"`     //下单价格 (stop/limit的价格)
+	PriceTrigger   utils.Price           `json:"price_trigger"`   //触发价格（stop limit 单）
+	PriceSL        utils.Price           `json:"price_sl"`
+	PriceTP        utils.Price           `json:"price_tp"`
+	Volume         utils.Volume          `json:"volume"` //lots
+	RateMargin     float64               `json:"rate_margin"`
+	Comment        string                `json:"comment"` //备注
 }
 
 //------------------------------------------------------
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"github.com/asaka1234/go-mt5-sdk/direct"
+	"github.com/asaka1234/go-mt5-sdk/utils"
 )
 
 type VLog struct {
@@ -27,7 +28,7 @@ func main() {
 	ADDR := "http://127.0.0.1:8351"
 
 	//构造client
-	cli := direct.NewClient(vlog, &direct.InitParams{ADDR})
+	cli := direct.NewClient(vlog, &direct.InitParams{Address: ADDR})
 	cli.SetDebugModel(true)
 
 	//0. 获取symbols
@@ -62,7 +63,7 @@ func GenUserCreateReqDemo() direct.UserCreateReq {
 func GenBalanceOperationReqDemo() direct.BalanceOperationReq {
 	return direct.BalanceOperationReq{
 		Login:   123450079,
-		Balance: 9000000,
+		Balance: utils.MustParseMoney("9000000"),
 		Comment: "1234567890#1234567891",
 	}
 }
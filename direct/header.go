@@ -1,7 +0,0 @@
-package direct
-
-func getHeaders() map[string]string {
-	return map[string]string{
-		"Content-Type": "application/json",
-	}
-}
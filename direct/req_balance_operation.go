@@ -1,48 +1,33 @@
 package direct
 
 import (
-	"crypto/tls"
-	"fmt"
+	"context"
 	"github.com/asaka1234/go-mt5-sdk/utils"
-	"github.com/json-iterator/go"
+	"net/http"
 )
 
-// 开户
+// 充值/提现
 
 func (cli *Client) BalanceOperation(req BalanceOperationReq) (*BalanceOperationResp, error) {
+	return cli.BalanceOperationWithContext(context.Background(), req)
+}
 
-	rawURL := cli.Params.Address + "/v1/balance/operation"
+func (cli *Client) BalanceOperationWithContext(ctx context.Context, req BalanceOperationReq) (*BalanceOperationResp, error) {
 
 	//返回值会放到这里
 	var result BalanceOperationResp
 
-	resp, err := cli.ryClient.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}).
-		SetCloseConnection(true).
-		R().
-		SetBody(req).
-		SetHeaders(getHeaders()).
-		SetDebug(cli.debugMode).
-		SetResult(&result).
-		SetError(&result).
-		Post(rawURL)
-
-	//print log
-	restLog, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(utils.GetRestyLog(resp))
-	cli.logger.Infof("MT5#BalanceOperation->%+v", string(restLog))
-
+	err := cli.Do(ctx, &utils.Call{
+		Endpoint: "BalanceOperation",
+		Method:   http.MethodPost,
+		Path:     "/v1/balance/operation",
+		Request:  req,
+		Login:    req.Login,
+		Response: &result,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != 200 {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
-	}
-
-	if resp.Error() != nil {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("%v, body:%s", resp.Error(), resp.Body())
-	}
-
-	return &result, err
+	return &result, nil
 }
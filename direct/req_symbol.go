@@ -1,51 +1,31 @@
 package direct
 
 import (
-	"crypto/tls"
-	"fmt"
+	"context"
 	"github.com/asaka1234/go-mt5-sdk/utils"
-	"github.com/json-iterator/go"
+	"net/http"
 )
 
 // 全量获取支持的所有symbol
 
 func (cli *Client) ListSymbol() (*ListSymbolResp, error) {
+	return cli.ListSymbolWithContext(context.Background())
+}
 
-	rawURL := cli.Params.Address + "/v1/symbol/list"
+func (cli *Client) ListSymbolWithContext(ctx context.Context) (*ListSymbolResp, error) {
 
 	//返回值会放到这里
 	var result ListSymbolResp
 
-	resp, err := cli.ryClient.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}).
-		SetCloseConnection(true).
-		R().
-		SetHeaders(getHeaders()).
-		SetDebug(cli.debugMode).
-		SetResult(&result).
-		SetError(&result).
-		Get(rawURL)
-
-	//print log
-	restLog, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(utils.GetRestyLog(resp))
-	cli.logger.Infof("MT5#ListSymbol#List->%+v", string(restLog))
-
+	err := cli.Do(ctx, &utils.Call{
+		Endpoint: "ListSymbol",
+		Method:   http.MethodGet,
+		Path:     "/v1/symbol/list",
+		Response: &result,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != 200 {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
-	}
-
-	if resp.Error() != nil {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("%v, body:%s", resp.Error(), resp.Body())
-	}
-
-	//----------------------------------------------------------------
-
-	//----------------------------------------------------------------
-
-	return &result, err
+	return &result, nil
 }
@@ -1,47 +1,31 @@
 package direct
 
 import (
-	"crypto/tls"
-	"fmt"
+	"context"
 	"github.com/asaka1234/go-mt5-sdk/utils"
-	"github.com/json-iterator/go"
+	"net/http"
 )
 
-// 全量获取支持的所有symbol
+// 获取所有symbol的最新报价
 
 func (cli *Client) TickReview() (*TickReviewResp, error) {
+	return cli.TickReviewWithContext(context.Background())
+}
 
-	rawURL := cli.Params.Address + "/v1/tick/review"
+func (cli *Client) TickReviewWithContext(ctx context.Context) (*TickReviewResp, error) {
 
 	//返回值会放到这里
 	var result TickReviewResp
 
-	resp, err := cli.ryClient.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}).
-		SetCloseConnection(true).
-		R().
-		SetHeaders(getHeaders()).
-		SetDebug(cli.debugMode).
-		SetResult(&result).
-		SetError(&result).
-		Get(rawURL)
-
-	//print log
-	restLog, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(utils.GetRestyLog(resp))
-	cli.logger.Infof("MT5#TickReview#List->%+v", string(restLog))
-
+	err := cli.Do(ctx, &utils.Call{
+		Endpoint: "TickReview",
+		Method:   http.MethodGet,
+		Path:     "/v1/tick/review",
+		Response: &result,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != 200 {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
-	}
-
-	if resp.Error() != nil {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("%v, body:%s", resp.Error(), resp.Body())
-	}
-
-	return &result, err
+	return &result, nil
 }
@@ -1,165 +1,110 @@
 package direct
 
 import (
-	"crypto/tls"
-	"fmt"
+	"context"
 	"github.com/asaka1234/go-mt5-sdk/utils"
-	"github.com/json-iterator/go"
 	"github.com/spf13/cast"
+	"net/http"
 )
 
 // 获取指定login的当前持仓列表
 
 func (cli *Client) ListPosition(login uint64) (*ListPositionResp, error) {
+	return cli.ListPositionWithContext(context.Background(), login)
+}
 
-	rawURL := cli.Params.Address + "/v1/position/list"
+func (cli *Client) ListPositionWithContext(ctx context.Context, login uint64) (*ListPositionResp, error) {
 
 	//返回值会放到这里
 	var result ListPositionResp
 
-	resp, err := cli.ryClient.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}).
-		SetCloseConnection(true).
-		R().
-		SetHeaders(getHeaders()).
-		SetQueryParam("login", cast.ToString(login)).
-		SetDebug(cli.debugMode).
-		SetResult(&result).
-		SetError(&result).
-		Get(rawURL)
-
-	//print log
-	restLog, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(utils.GetRestyLog(resp))
-	cli.logger.Infof("MT5#ListPosition->%+v", string(restLog))
-
+	err := cli.Do(ctx, &utils.Call{
+		Endpoint: "ListPosition",
+		Method:   http.MethodGet,
+		Path:     "/v1/position/list",
+		Query:    map[string]string{"login": cast.ToString(login)},
+		Login:    login,
+		Response: &result,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != 200 {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
-	}
-
-	if resp.Error() != nil {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("%v, body:%s", resp.Error(), resp.Body())
-	}
-
-	return &result, err
+	return &result, nil
 }
 
 // 获取当前的挂单列表
 
 func (cli *Client) ListPendingOrder(login uint64) (*ListPendingOrderResp, error) {
+	return cli.ListPendingOrderWithContext(context.Background(), login)
+}
 
-	rawURL := cli.Params.Address + "/v1/pendingOrder/list"
+func (cli *Client) ListPendingOrderWithContext(ctx context.Context, login uint64) (*ListPendingOrderResp, error) {
 
 	//返回值会放到这里
 	var result ListPendingOrderResp
 
-	resp, err := cli.ryClient.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}).
-		SetCloseConnection(true).
-		R().
-		SetHeaders(getHeaders()).
-		SetQueryParam("login", cast.ToString(login)).
-		SetDebug(cli.debugMode).
-		SetResult(&result).
-		SetError(&result).
-		Get(rawURL)
-
-	//print log
-	restLog, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(utils.GetRestyLog(resp))
-	cli.logger.Infof("MT5#ListPendingOrder->%+v", string(restLog))
-
+	err := cli.Do(ctx, &utils.Call{
+		Endpoint: "ListPendingOrder",
+		Method:   http.MethodGet,
+		Path:     "/v1/pendingOrder/list",
+		Query:    map[string]string{"login": cast.ToString(login)},
+		Login:    login,
+		Response: &result,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != 200 {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
-	}
-
-	if resp.Error() != nil {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("%v, body:%s", resp.Error(), resp.Body())
-	}
-
-	return &result, err
+	return &result, nil
 }
 
+// 根据ticket获取挂单
+
 func (cli *Client) OrderGet(ticket uint64) (*GetOrderResp, error) {
+	return cli.OrderGetWithContext(context.Background(), ticket)
+}
 
-	rawURL := cli.Params.Address + "/v1/order/get"
+func (cli *Client) OrderGetWithContext(ctx context.Context, ticket uint64) (*GetOrderResp, error) {
 
 	//返回值会放到这里
 	var result GetOrderResp
 
-	resp, err := cli.ryClient.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}).
-		SetCloseConnection(true).
-		R().
-		SetHeaders(getHeaders()).
-		SetQueryParam("ticket", cast.ToString(ticket)).
-		SetDebug(cli.debugMode).
-		SetResult(&result).
-		SetError(&result).
-		Get(rawURL)
-
-	//print log
-	restLog, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(utils.GetRestyLog(resp))
-	cli.logger.Infof("MT5#OrderGet->%+v", string(restLog))
-
+	err := cli.Do(ctx, &utils.Call{
+		Endpoint: "OrderGet",
+		Method:   http.MethodGet,
+		Path:     "/v1/order/get",
+		Query:    map[string]string{"ticket": cast.ToString(ticket)},
+		Response: &result,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != 200 {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
-	}
-
-	if resp.Error() != nil {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("%v, body:%s", resp.Error(), resp.Body())
-	}
-
-	return &result, err
+	return &result, nil
 }
 
+// 根据ticket获取持仓
+
 func (cli *Client) PositionGet(ticket uint64) (*GetPositionResp, error) {
+	return cli.PositionGetWithContext(context.Background(), ticket)
+}
 
-	rawURL := cli.Params.Address + "/v1/position/get"
+func (cli *Client) PositionGetWithContext(ctx context.Context, ticket uint64) (*GetPositionResp, error) {
 
 	//返回值会放到这里
 	var result GetPositionResp
 
-	resp, err := cli.ryClient.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}).
-		SetCloseConnection(true).
-		R().
-		SetHeaders(getHeaders()).
-		SetQueryParam("ticket", cast.ToString(ticket)).
-		SetDebug(cli.debugMode).
-		SetResult(&result).
-		SetError(&result).
-		Get(rawURL)
-
-	//print log
-	restLog, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(utils.GetRestyLog(resp))
-	cli.logger.Infof("MT5#PositionGet->%+v", string(restLog))
-
+	err := cli.Do(ctx, &utils.Call{
+		Endpoint: "PositionGet",
+		Method:   http.MethodGet,
+		Path:     "/v1/position/get",
+		Query:    map[string]string{"ticket": cast.ToString(ticket)},
+		Response: &result,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != 200 {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
-	}
-
-	if resp.Error() != nil {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("%v, body:%s", resp.Error(), resp.Body())
-	}
-
-	return &result, err
+	return &result, nil
 }
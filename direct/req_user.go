@@ -1,87 +1,59 @@
 package direct
 
 import (
-	"crypto/tls"
-	"fmt"
+	"context"
 	"github.com/asaka1234/go-mt5-sdk/utils"
-	"github.com/json-iterator/go"
 	"github.com/spf13/cast"
+	"net/http"
 )
 
 // 开户
 
 func (cli *Client) UserCreate(req UserCreateReq) (*UserCreateResp, error) {
+	return cli.UserCreateWithContext(context.Background(), req)
+}
 
-	rawURL := cli.Params.Address + "/v1/user/create"
+func (cli *Client) UserCreateWithContext(ctx context.Context, req UserCreateReq) (*UserCreateResp, error) {
 
 	//返回值会放到这里
 	var result UserCreateResp
 
-	resp, err := cli.ryClient.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}).
-		SetCloseConnection(true).
-		R().
-		SetBody(req).
-		SetHeaders(getHeaders()).
-		SetDebug(cli.debugMode).
-		SetResult(&result).
-		SetError(&result).
-		Post(rawURL)
-
-	//print log
-	restLog, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(utils.GetRestyLog(resp))
-	cli.logger.Infof("MT5#UserCreate#User->%+v", string(restLog))
-
+	err := cli.Do(ctx, &utils.Call{
+		Endpoint: "UserCreate",
+		Method:   http.MethodPost,
+		Path:     "/v1/user/create",
+		Request:  req,
+		Response: &result,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != 200 {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
-	}
-
-	if resp.Error() != nil {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("%v, body:%s", resp.Error(), resp.Body())
-	}
-
-	return &result, err
+	return &result, nil
 }
 
+// 获取账户的资金详情
+
 func (cli *Client) UserAccountDetail(login uint64) (*UserAccountDetailResp, error) {
+	return cli.UserAccountDetailWithContext(context.Background(), login)
+}
 
-	rawURL := cli.Params.Address + "/v1/user/account/detail"
+func (cli *Client) UserAccountDetailWithContext(ctx context.Context, login uint64) (*UserAccountDetailResp, error) {
 
 	//返回值会放到这里
 	var result UserAccountDetailResp
 
-	resp, err := cli.ryClient.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}).
-		SetCloseConnection(true).
-		R().
-		SetQueryParam("login", cast.ToString(login)).
-		SetHeaders(getHeaders()).
-		SetDebug(cli.debugMode).
-		SetResult(&result).
-		SetError(&result).
-		Get(rawURL)
-
-	//print log
-	restLog, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(utils.GetRestyLog(resp))
-	cli.logger.Infof("MT5#UserAccountDetail->%+v", string(restLog))
-
+	err := cli.Do(ctx, &utils.Call{
+		Endpoint: "UserAccountDetail",
+		Method:   http.MethodGet,
+		Path:     "/v1/user/account/detail",
+		Query:    map[string]string{"login": cast.ToString(login)},
+		Login:    login,
+		Response: &result,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != 200 {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
-	}
-
-	if resp.Error() != nil {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("%v, body:%s", resp.Error(), resp.Body())
-	}
-
-	return &result, err
+	return &result, nil
 }
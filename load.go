@@ -0,0 +1,431 @@
+package mt5
+
+import (
+	"flag"
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"go.yaml.in/yaml/v3"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEnvPrefix 环境变量的前缀, 比如 MT5_GATEWAY_ADDRESS / MT5_PUMPING_SERVER_ADDR
+const DefaultEnvPrefix = "MT5"
+
+// LoadConfig 按顺序读取yaml文件(后面的覆盖前面的), 再用 MT5_* 环境变量覆盖, 最后校验
+func LoadConfig(files ...string) (*Config, error) {
+	l := &Loader{Files: files}
+	return l.Load()
+}
+
+// Loader 从yaml文件, 环境变量和命令行参数加载 Config, 优先级: 命令行 > 环境变量 > 文件
+//
+// 字段名使用yaml tag, 嵌套的字段用.连接, 比如 gateway.retry.max_attempts;
+// 环境变量是前缀加上大写的字段名, .换成_, 比如 MT5_GATEWAY_RETRY_MAX_ATTEMPTS;
+// 命令行参数和字段名相同, 比如 -gateway.retry.max_attempts=3.
+//
+// 时长使用 time.ParseDuration 的格式(30s, 1m30s). 环境变量和命令行里列表用,分隔, map用 k=v,k=v
+// (比如 MT5_GATEWAY_LOG_LEVELS=ListSymbol=debug), 值为结构体的map(rate_limit.routes)只能在yaml里配置.
+// 配置了 pumping 下的任意字段才会启用pumping, 没有配置的字段使用 pumping.DefaultConfig 的值
+type Loader struct {
+	Files     []string
+	EnvPrefix string   //为空则使用 DefaultEnvPrefix
+	Environ   []string //KEY=VALUE 格式, nil则使用 os.Environ()
+
+	flags   []setting         //命令行里出现的参数, 按出现顺序
+	sources map[string]string //字段 -> 最后一次设置它的来源, 用于校验错误
+}
+
+// setting 来自环境变量或命令行的一个值
+type setting struct {
+	path   string
+	source string
+	value  string
+}
+
+// leaf 可以用一个字符串设置的字段
+type leaf struct {
+	path string
+	typ  reflect.Type
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// defaultValues 指针字段第一次被设置时的初始值, 没有列出的类型使用零值
+var defaultValues = map[reflect.Type]func() reflect.Value{
+	reflect.TypeOf(pumping.Config{}): func() reflect.Value { return reflect.ValueOf(pumping.DefaultConfig()) },
+}
+
+// RegisterFlags 注册 -config(可以重复, 追加到Files)和每个字段对应的参数, 需要在 fs.Parse 之前调用
+func (l *Loader) RegisterFlags(fs *flag.FlagSet) {
+	fs.Func("config", "yaml config `file`, can be repeated, later files override earlier ones", func(file string) error {
+		l.Files = append(l.Files, file)
+		return nil
+	})
+	for _, lf := range leaves(reflect.TypeOf(Config{}), "") {
+		lf := lf
+		usage := fmt.Sprintf("%s (env %s)", typeName(lf.typ), envName(l.prefix(), lf.path))
+		set := func(raw string) error {
+			//先解析一次, 格式不对时flag包会直接打印用法
+			if err := parseScalar(reflect.New(lf.typ).Elem(), raw); err != nil {
+				return err
+			}
+			l.flags = append(l.flags, setting{path: lf.path, source: "-" + lf.path, value: raw})
+			return nil
+		}
+		if lf.typ.Kind() == reflect.Bool {
+			fs.BoolFunc(lf.path, usage, set)
+		} else {
+			fs.Func(lf.path, usage, set)
+		}
+	}
+}
+
+// Load 依次应用文件, 环境变量和命令行参数, 然后校验; 格式错误和校验错误一起通过 *ConfigError 返回
+func (l *Loader) Load() (*Config, error) {
+	cfg := &Config{}
+	errs := &ConfigError{}
+	l.sources = make(map[string]string)
+
+	for _, file := range l.Files {
+		if err := l.decodeFile(cfg, file, errs); err != nil {
+			return nil, err
+		}
+	}
+	root := reflect.ValueOf(cfg).Elem()
+	for _, s := range append(l.env(), l.flags...) {
+		if err := setPath(root, s.path, s.value); err != nil {
+			errs.add(s.path, s.source, err.Error())
+			continue
+		}
+		l.sources[s.path] = s.source
+	}
+
+	//格式错误的字段保留了原来的值, 不再重复报校验错误
+	if err := cfg.Validate(); err != nil {
+		for _, f := range err.(*ConfigError).Fields {
+			if !errs.has(f.Field) {
+				errs.add(f.Field, l.sources[f.Field], f.Reason)
+			}
+		}
+	}
+	if len(errs.Fields) > 0 {
+		return nil, errs
+	}
+	return cfg, nil
+}
+
+func (l *Loader) prefix() string {
+	if l.EnvPrefix == "" {
+		return DefaultEnvPrefix
+	}
+	return l.EnvPrefix
+}
+
+func (l *Loader) env() []setting {
+	environ := l.Environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+	values := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			values[k] = v
+		}
+	}
+
+	var list []setting
+	for _, lf := range leaves(reflect.TypeOf(Config{}), "") {
+		name := envName(l.prefix(), lf.path)
+		if v, ok := values[name]; ok {
+			list = append(list, setting{path: lf.path, source: name, value: v})
+		}
+	}
+	return list
+}
+
+func envName(prefix, path string) string {
+	return prefix + "_" + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+func (l *Loader) decodeFile(cfg *Config, file string, errs *ConfigError) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("mt5: read config: %w", err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("mt5: parse config %s: %w", file, err)
+	}
+	if len(root.Content) == 0 {
+		return nil //空文件
+	}
+	d := decoder{file: file, errs: errs, sources: l.sources}
+	d.decode(reflect.ValueOf(cfg).Elem(), root.Content[0], "")
+	return nil
+}
+
+// decoder 按yaml tag把yaml节点写到结构体里, 收集所有错误而不是在第一个错误处停下
+type decoder struct {
+	file    string
+	errs    *ConfigError
+	sources map[string]string
+}
+
+func (d *decoder) fail(path string, node *yaml.Node, format string, args ...interface{}) {
+	d.errs.add(path, d.source(node), fmt.Sprintf(format, args...))
+}
+
+func (d *decoder) source(node *yaml.Node) string {
+	return fmt.Sprintf("%s:%d", d.file, node.Line)
+}
+
+func (d *decoder) decode(v reflect.Value, node *yaml.Node, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		v.Set(reflect.Zero(v.Type())) //比如 pumping: null 关闭pumping
+		return
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(newValue(v.Type()))
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			d.fail(path, node, "expected a mapping")
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			name := joinPath(path, key.Value)
+			field, ok := fieldByName(v, key.Value)
+			if !ok {
+				d.fail(name, key, "unknown field")
+				continue
+			}
+			d.decode(field, value, name)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			d.fail(path, node, "expected a mapping")
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			k := reflect.ValueOf(key.Value).Convert(v.Type().Key())
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if old := v.MapIndex(k); old.IsValid() {
+				elem.Set(old)
+			}
+			d.decode(elem, value, joinPath(path, key.Value))
+			v.SetMapIndex(k, elem)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			d.fail(path, node, "expected a list")
+			return
+		}
+		s := reflect.MakeSlice(v.Type(), len(node.Content), len(node.Content))
+		for i, item := range node.Content {
+			d.decode(s.Index(i), item, fmt.Sprintf("%s[%d]", path, i))
+		}
+		v.Set(s)
+	default:
+		if node.Kind != yaml.ScalarNode {
+			d.fail(path, node, "expected a single value")
+			return
+		}
+		if err := parseScalar(v, node.Value); err != nil {
+			d.fail(path, node, "%v", err)
+			return
+		}
+		d.sources[path] = d.source(node)
+	}
+}
+
+// setPath 把字符串写到path对应的字段, 路径上为nil的指针会自动创建
+func setPath(v reflect.Value, path, raw string) error {
+	for _, name := range strings.Split(path, ".") {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(newValue(v.Type()))
+			}
+			v = v.Elem()
+		}
+		field, ok := fieldByName(v, name)
+		if !ok {
+			return fmt.Errorf("unknown field")
+		}
+		v = field
+	}
+	return parseScalar(v, raw)
+}
+
+// parseScalar 解析一个字符串值, 列表用,分隔, map用 k=v,k=v
+func parseScalar(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, use a value like 30s or 1m30s", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", raw)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		items := splitList(raw)
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := parseScalar(s.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for _, item := range splitList(raw) {
+			key, value, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("invalid entry %q, use key=value", item)
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := parseScalar(elem, value); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)).Convert(v.Type().Key()), elem)
+		}
+		v.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// leaves 所有可以用一个字符串设置的字段, 按结构体里的顺序
+func leaves(t reflect.Type, prefix string) []leaf {
+	var list []leaf
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := yamlName(f)
+		if name == "" {
+			continue
+		}
+		path := joinPath(prefix, name)
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch {
+		case ft.Kind() == reflect.Struct:
+			list = append(list, leaves(ft, path)...)
+		case ft.Kind() == reflect.Map && ft.Elem().Kind() == reflect.Struct:
+			//只能在yaml里配置
+		default:
+			list = append(list, leaf{path: path, typ: ft})
+		}
+	}
+	return list
+}
+
+func fieldByName(v reflect.Value, name string) (reflect.Value, bool) {
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if yamlName(t.Field(i)) == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func yamlName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func newValue(ptr reflect.Type) reflect.Value {
+	if f, ok := defaultValues[ptr.Elem()]; ok {
+		return f()
+	}
+	return reflect.New(ptr.Elem())
+}
+
+func typeName(t reflect.Type) string {
+	switch {
+	case t == durationType:
+		return "duration"
+	case t.Kind() == reflect.Slice:
+		return "comma separated list"
+	case t.Kind() == reflect.Map:
+		return "comma separated key=value list"
+	}
+	return t.Kind().String()
+}
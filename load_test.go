@@ -0,0 +1,193 @@
+package mt5_test
+
+import (
+	"errors"
+	"flag"
+	"github.com/asaka1234/go-mt5-sdk"
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeYAML(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "mt5.yaml")
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// fieldErrors 按字段名索引, 方便检查 Source 和 Reason
+func fieldErrors(t *testing.T, err error) map[string]mt5.FieldError {
+	t.Helper()
+	var cfgErr *mt5.ConfigError
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("err = %v, want *ConfigError", err)
+	}
+	m := make(map[string]mt5.FieldError, len(cfgErr.Fields))
+	for _, f := range cfgErr.Fields {
+		m[f.Field] = f
+	}
+	return m
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	file := writeYAML(t, "gateway:\n  address: http://127.0.0.1:8080\n  retry:\n    max_attempts: 2\n    initial_backoff: 100ms\n")
+	cfg, err := (&mt5.Loader{Files: []string{file}, Environ: []string{
+		"MT5_GATEWAY_ADDRESSES=http://10.0.0.2:8080, http://10.0.0.3:8080",
+		"MT5_GATEWAY_TIMEOUT=30s",
+		"MT5_GATEWAY_RETRY_MAX_ATTEMPTS=5",
+		"MT5_GATEWAY_LOG_LEVELS=ListSymbol=debug,OpenPosition=warn",
+		"MT5_GATEWAY_TLS_PINNED_SPKI=",
+		"MT5_TRADING_ADDRESS=http://127.0.0.1:9090",
+		"MT5_PUMPING_SERVER_ADDR=127.0.0.1:9000",
+		"OTHER_GATEWAY_TIMEOUT=1s",
+	}}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"http://10.0.0.2:8080", "http://10.0.0.3:8080"}; !reflect.DeepEqual(cfg.Gateway.Addresses, want) {
+		t.Errorf("addresses = %q, want %q", cfg.Gateway.Addresses, want)
+	}
+	if cfg.Gateway.Timeout != 30*time.Second {
+		t.Errorf("timeout = %v, want 30s", cfg.Gateway.Timeout)
+	}
+	//环境变量只覆盖设置的字段, 文件里的其他字段保留
+	if r := cfg.Gateway.Retry; r.MaxAttempts != 5 || r.InitialBackoff != 100*time.Millisecond {
+		t.Errorf("retry = %+v", r)
+	}
+	if want := map[string]utils.LogLevel{"ListSymbol": utils.LogLevelDebug, "OpenPosition": utils.LogLevelWarn}; !reflect.DeepEqual(cfg.Gateway.Log.Levels, want) {
+		t.Errorf("log levels = %v, want %v", cfg.Gateway.Log.Levels, want)
+	}
+	if cfg.Gateway.TLS == nil || len(cfg.Gateway.TLS.PinnedSPKI) != 0 {
+		t.Errorf("tls = %+v, want an empty pin list", cfg.Gateway.TLS)
+	}
+	if cfg.Trading == nil || cfg.Trading.Address != "http://127.0.0.1:9090" {
+		t.Errorf("trading = %+v", cfg.Trading)
+	}
+	//只配置了 server_addr, 其他字段使用默认值
+	want := pumping.DefaultConfig()
+	want.ServerAddr = "127.0.0.1:9000"
+	if cfg.Pumping == nil || *cfg.Pumping != *want {
+		t.Errorf("pumping = %+v, want %+v", cfg.Pumping, want)
+	}
+}
+
+func TestLoadDurations(t *testing.T) {
+	file := writeYAML(t, "gateway:\n  address: http://127.0.0.1:8080\n  timeout: 30s\n  retry:\n    max_backoff: 1m30s\n"+
+		"pumping:\n  server_addr: 127.0.0.1:9000\n  heartbeat_interval: 500ms\n")
+	cfg, err := (&mt5.Loader{Files: []string{file}, Environ: []string{}}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Gateway.Timeout != 30*time.Second || cfg.Gateway.Retry.MaxBackoff != 90*time.Second || cfg.Pumping.HeartbeatInterval != 500*time.Millisecond {
+		t.Fatalf("durations = %v, %v, %v", cfg.Gateway.Timeout, cfg.Gateway.Retry.MaxBackoff, cfg.Pumping.HeartbeatInterval)
+	}
+
+	//没有单位的数字不是合法的时长
+	file = writeYAML(t, "gateway:\n  address: http://127.0.0.1:8080\n  timeout: 30\n")
+	_, err = (&mt5.Loader{Files: []string{file}, Environ: []string{}}).Load()
+	if f, ok := fieldErrors(t, err)["gateway.timeout"]; !ok || f.Source != file+":3" {
+		t.Fatalf("err = %v, want gateway.timeout at %s:3", err, file)
+	}
+}
+
+// 命令行 > 环境变量 > 文件
+func TestLoadPrecedence(t *testing.T) {
+	file := writeYAML(t, "gateway:\n  address: http://127.0.0.1:8080\n  timeout: 10s\n")
+	env := []string{"MT5_GATEWAY_TIMEOUT=20s"}
+	tests := []struct {
+		name    string
+		environ []string
+		args    []string
+		want    time.Duration
+	}{
+		{"file", []string{}, nil, 10 * time.Second},
+		{"env over file", env, nil, 20 * time.Second},
+		{"flag over env", env, []string{"-gateway.timeout=30s"}, 30 * time.Second},
+		{"last flag wins", env, []string{"-gateway.timeout=30s", "-gateway.timeout=40s"}, 40 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &mt5.Loader{Environ: tt.environ}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			l.RegisterFlags(fs)
+			if err := fs.Parse(append([]string{"-config", file}, tt.args...)); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := l.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Gateway.Timeout != tt.want {
+				t.Fatalf("timeout = %v, want %v", cfg.Gateway.Timeout, tt.want)
+			}
+		})
+	}
+
+	//后面的文件覆盖前面的
+	override := writeYAML(t, "gateway:\n  timeout: 15s\n")
+	cfg, err := (&mt5.Loader{Files: []string{file, override}, Environ: []string{}}).Load()
+	if err != nil || cfg.Gateway.Timeout != 15*time.Second || cfg.Gateway.Address != "http://127.0.0.1:8080" {
+		t.Fatalf("cfg = %+v, %v", cfg, err)
+	}
+}
+
+func TestLoadRejectsUnknownField(t *testing.T) {
+	file := writeYAML(t, "gateway:\n  address: http://127.0.0.1:8080\n  adress: http://127.0.0.1:8081\n")
+	_, err := (&mt5.Loader{Files: []string{file}, Environ: []string{}}).Load()
+	f, ok := fieldErrors(t, err)["gateway.adress"]
+	if !ok || f.Source != file+":3" || f.Reason != "unknown field" {
+		t.Fatalf("err = %v, want unknown field gateway.adress at %s:3", err, file)
+	}
+}
+
+// 一次报告所有有问题的字段, Source 指向设置它的地方
+func TestLoadCollectsErrors(t *testing.T) {
+	file := writeYAML(t, "gateway:\n  address: ftp://127.0.0.1\n  retry:\n    max_attempts: many\n    jitter: 1.5\n"+
+		"pumping:\n  server_addr: localhost\n")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	l := &mt5.Loader{Files: []string{file}, Environ: []string{
+		"MT5_GATEWAY_TIMEOUT=soon",
+		"MT5_GATEWAY_CIRCUIT_BREAKER_FAILURE_RATIO=2",
+	}}
+	l.RegisterFlags(fs)
+	if err := fs.Parse([]string{"-pumping.max_reconnects=-1"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := l.Load()
+
+	want := map[string]string{
+		"gateway.address":                       file + ":2", //校验错误
+		"gateway.retry.max_attempts":            file + ":4", //格式错误
+		"gateway.retry.jitter":                  file + ":5",
+		"pumping.server_addr":                   file + ":7",
+		"gateway.timeout":                       "MT5_GATEWAY_TIMEOUT",
+		"gateway.circuit_breaker.failure_ratio": "MT5_GATEWAY_CIRCUIT_BREAKER_FAILURE_RATIO",
+		"pumping.max_reconnects":                "-pumping.max_reconnects",
+	}
+	got := fieldErrors(t, err)
+	for field, source := range want {
+		if f, ok := got[field]; !ok || f.Source != source {
+			t.Errorf("%s: got %+v, want an error from %s", field, f, source)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("%d errors, want %d: %v", len(got), len(want), err)
+	}
+}
+
+func TestLoadRejectsBadTLS(t *testing.T) {
+	dir := t.TempDir()
+	file := writeYAML(t, "gateway:\n  address: https://127.0.0.1:8443\n  tls:\n    ca_file: "+filepath.Join(dir, "missing.pem")+"\n")
+	_, err := (&mt5.Loader{Files: []string{file}, Environ: []string{}}).Load()
+	if got := fields(err); len(got) != 1 || got[0] != "gateway.tls.ca_file" {
+		t.Fatalf("err = %v, want an error on gateway.tls.ca_file", err)
+	}
+}
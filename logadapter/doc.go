@@ -0,0 +1,4 @@
+// Package logadapter 把常用的日志库适配成 utils.Logger(同时实现了 utils.StructuredLogger)
+//
+//	cli := direct.NewClient(logadapter.NewSlog(slog.Default()), params)
+package logadapter
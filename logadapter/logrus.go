@@ -0,0 +1,58 @@
+package logadapter
+
+import (
+	"context"
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Logrus 把 logrus 适配成 utils.Logger, 支持With和ctx(ctx会设置到logrus.Entry上, ctx里有span时附加trace_id/span_id字段)
+type Logrus struct {
+	e *logrus.Entry
+}
+
+// NewLogrus l为nil时使用 logrus.StandardLogger()
+func NewLogrus(l *logrus.Logger) *Logrus {
+	if l == nil {
+		l = logrus.StandardLogger()
+	}
+	return &Logrus{e: logrus.NewEntry(l)}
+}
+
+func (r *Logrus) Debugf(format string, args ...interface{}) {
+	r.e.Debugf(format, args...)
+}
+
+func (r *Logrus) Infof(format string, args ...interface{}) {
+	r.e.Infof(format, args...)
+}
+
+func (r *Logrus) Warnf(format string, args ...interface{}) {
+	r.e.Warnf(format, args...)
+}
+
+func (r *Logrus) Errorf(format string, args ...interface{}) {
+	r.e.Errorf(format, args...)
+}
+
+// With 落单的key值为 MISSING, 和 utils.With 保持一致
+func (r *Logrus) With(keyvals ...interface{}) utils.StructuredLogger {
+	fields := make(logrus.Fields, len(keyvals)/2+1)
+	for i := 0; i < len(keyvals); i += 2 {
+		var v interface{} = "MISSING"
+		if i+1 < len(keyvals) {
+			v = keyvals[i+1]
+		}
+		fields[fmt.Sprint(keyvals[i])] = v
+	}
+	return &Logrus{e: r.e.WithFields(fields)}
+}
+
+func (r *Logrus) WithContext(ctx context.Context) utils.Logger {
+	l := &Logrus{e: r.e.WithContext(ctx)}
+	if fields := spanFields(ctx); fields != nil {
+		return l.With(fields...)
+	}
+	return l
+}
@@ -0,0 +1,70 @@
+package logadapter_test
+
+import (
+	"context"
+	"github.com/asaka1234/go-mt5-sdk/logadapter"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"testing"
+)
+
+func TestLogrusLevels(t *testing.T) {
+	l, hook := test.NewNullLogger()
+	l.SetLevel(logrus.DebugLevel)
+	logger := logadapter.NewLogrus(l)
+	logger.Debugf("d %d", 1)
+	logger.Infof("i %d", 2)
+	logger.Warnf("w %d", 3)
+	logger.Errorf("e %d", 4)
+
+	want := []struct {
+		level   logrus.Level
+		message string
+	}{{logrus.DebugLevel, "d 1"}, {logrus.InfoLevel, "i 2"}, {logrus.WarnLevel, "w 3"}, {logrus.ErrorLevel, "e 4"}}
+	entries := hook.AllEntries()
+	if len(entries) != len(want) {
+		t.Fatalf("%d entries", len(entries))
+	}
+	for i, w := range want {
+		if e := entries[i]; e.Level != w.level || e.Message != w.message {
+			t.Errorf("entry %d = %v %q, want %v %q", i, e.Level, e.Message, w.level, w.message)
+		}
+	}
+
+	//落单的key值为 MISSING
+	logger.With("login", 1001, "symbol").Infof("odd")
+	if data := hook.LastEntry().Data; data["login"] != 1001 || data["symbol"] != "MISSING" {
+		t.Fatalf("fields = %v", data)
+	}
+}
+
+func TestLogrusWithContext(t *testing.T) {
+	l, hook := test.NewNullLogger()
+	var logger utils.Logger = logadapter.NewLogrus(l)
+	if _, ok := logger.(utils.ContextLogger); !ok {
+		t.Fatal("Logrus does not implement utils.ContextLogger")
+	}
+
+	ctx, sc := spanContext()
+	ctx = utils.ContextWithLogFields(ctx, "request_id", "r1")
+	utils.With(utils.WithContext(ctx, logger), "login", 1001).Warnf("open %s", "EURUSD")
+	utils.WithContext(context.Background(), logger).Infof("no span")
+
+	entries := hook.AllEntries()
+	if len(entries) != 2 {
+		t.Fatalf("%d entries", len(entries))
+	}
+	e := entries[0]
+	if e.Message != "open EURUSD" || e.Data["trace_id"] != sc.TraceID().String() || e.Data["span_id"] != sc.SpanID().String() ||
+		e.Data["request_id"] != "r1" || e.Data["login"] != 1001 {
+		t.Fatalf("entry = %q %v", e.Message, e.Data)
+	}
+	//ctx也设置到了logrus.Entry上
+	if e.Context != ctx {
+		t.Fatal("entry does not carry the ctx")
+	}
+	if data := entries[1].Data; len(data) != 0 {
+		t.Fatalf("entry without span has fields %v", data)
+	}
+}
@@ -0,0 +1,50 @@
+package logadapter
+
+import (
+	"context"
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"log/slog"
+)
+
+// Slog 把 *slog.Logger 适配成 utils.Logger, 支持With和ctx(ctx会传给slog.Handler, ctx里有span时附加trace_id/span_id字段)
+type Slog struct {
+	l   *slog.Logger
+	ctx context.Context
+}
+
+// NewSlog l为nil时使用 slog.Default()
+func NewSlog(l *slog.Logger) *Slog {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &Slog{l: l, ctx: context.Background()}
+}
+
+func (s *Slog) Debugf(format string, args ...interface{}) {
+	s.l.DebugContext(s.ctx, fmt.Sprintf(format, args...))
+}
+
+func (s *Slog) Infof(format string, args ...interface{}) {
+	s.l.InfoContext(s.ctx, fmt.Sprintf(format, args...))
+}
+
+func (s *Slog) Warnf(format string, args ...interface{}) {
+	s.l.WarnContext(s.ctx, fmt.Sprintf(format, args...))
+}
+
+func (s *Slog) Errorf(format string, args ...interface{}) {
+	s.l.ErrorContext(s.ctx, fmt.Sprintf(format, args...))
+}
+
+func (s *Slog) With(keyvals ...interface{}) utils.StructuredLogger {
+	return &Slog{l: s.l.With(keyvals...), ctx: s.ctx}
+}
+
+func (s *Slog) WithContext(ctx context.Context) utils.Logger {
+	l := s.l
+	if fields := spanFields(ctx); fields != nil {
+		l = l.With(fields...)
+	}
+	return &Slog{l: l, ctx: ctx}
+}
@@ -0,0 +1,118 @@
+package logadapter_test
+
+import (
+	"context"
+	"github.com/asaka1234/go-mt5-sdk/logadapter"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// recorder 记录slog的日志和带上的ctx
+type recorder struct {
+	mu      *sync.Mutex
+	attrs   []slog.Attr
+	records *[]record
+}
+
+type record struct {
+	ctx     context.Context
+	level   slog.Level
+	message string
+	fields  map[string]interface{}
+}
+
+func newRecorder() (*recorder, *[]record) {
+	records := new([]record)
+	return &recorder{mu: new(sync.Mutex), records: records}, records
+}
+
+func (h *recorder) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recorder) Handle(ctx context.Context, r slog.Record) error {
+	fields := make(map[string]interface{})
+	for _, a := range h.attrs {
+		fields[a.Key] = a.Value.Any()
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		fields[a.Key] = a.Value.Any()
+		return true
+	})
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.records = append(*h.records, record{ctx: ctx, level: r.Level, message: r.Message, fields: fields})
+	return nil
+}
+
+func (h *recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &recorder{mu: h.mu, attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...), records: h.records}
+}
+
+func (h *recorder) WithGroup(string) slog.Handler { return h }
+
+func spanContext() (context.Context, trace.SpanContext) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x0a, 0xf7},
+		SpanID:     trace.SpanID{0x01},
+		TraceFlags: trace.FlagsSampled,
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc), sc
+}
+
+func TestSlogLevels(t *testing.T) {
+	h, records := newRecorder()
+	logger := logadapter.NewSlog(slog.New(h))
+	logger.Debugf("d %d", 1)
+	logger.Infof("i %d", 2)
+	logger.Warnf("w %d", 3)
+	logger.Errorf("e %d", 4)
+
+	want := []struct {
+		level   slog.Level
+		message string
+	}{{slog.LevelDebug, "d 1"}, {slog.LevelInfo, "i 2"}, {slog.LevelWarn, "w 3"}, {slog.LevelError, "e 4"}}
+	if len(*records) != len(want) {
+		t.Fatalf("%d records", len(*records))
+	}
+	for i, w := range want {
+		if r := (*records)[i]; r.level != w.level || r.message != w.message {
+			t.Errorf("record %d = %v %q, want %v %q", i, r.level, r.message, w.level, w.message)
+		}
+	}
+}
+
+func TestSlogWithContext(t *testing.T) {
+	h, records := newRecorder()
+	var logger utils.Logger = logadapter.NewSlog(slog.New(h))
+	if _, ok := logger.(utils.ContextLogger); !ok {
+		t.Fatal("Slog does not implement utils.ContextLogger")
+	}
+
+	ctx, sc := spanContext()
+	ctx = utils.ContextWithLogFields(ctx, "request_id", "r1")
+	utils.With(utils.WithContext(ctx, logger), "login", 1001).Warnf("open %s", "EURUSD")
+	utils.WithContext(context.Background(), logger).Infof("no span")
+	//With 不会丢掉ctx
+	utils.With(logger, "symbol", "XAUUSD").(utils.ContextLogger).WithContext(ctx).Errorf("after With")
+
+	if len(*records) != 3 {
+		t.Fatalf("%d records", len(*records))
+	}
+	r := (*records)[0]
+	if r.message != "open EURUSD" || r.fields["trace_id"] != sc.TraceID().String() || r.fields["span_id"] != sc.SpanID().String() ||
+		r.fields["request_id"] != "r1" || r.fields["login"] != int64(1001) {
+		t.Fatalf("record = %q %v", r.message, r.fields)
+	}
+	//ctx也传给了slog.Handler
+	if trace.SpanContextFromContext(r.ctx).TraceID() != sc.TraceID() {
+		t.Fatal("handler did not get the ctx")
+	}
+	if r := (*records)[1]; len(r.fields) != 0 {
+		t.Fatalf("record without span has fields %v", r.fields)
+	}
+	if r := (*records)[2]; r.fields["symbol"] != "XAUUSD" || r.fields["trace_id"] != sc.TraceID().String() || r.ctx != ctx {
+		t.Fatalf("record = %q %v", r.message, r.fields)
+	}
+}
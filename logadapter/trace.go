@@ -0,0 +1,15 @@
+package logadapter
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanFields ctx里有span时返回 trace_id/span_id 字段, 否则为nil
+func spanFields(ctx context.Context) []interface{} {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []interface{}{"trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String()}
+}
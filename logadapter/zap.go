@@ -0,0 +1,48 @@
+package logadapter
+
+import (
+	"context"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"go.uber.org/zap"
+)
+
+// Zap 把 *zap.Logger 适配成 utils.Logger, 支持With和ctx(zap没有ctx的概念, ctx里有span时附加trace_id/span_id字段)
+type Zap struct {
+	l *zap.SugaredLogger
+}
+
+// NewZap l为nil时使用 zap.L()
+func NewZap(l *zap.Logger) *Zap {
+	if l == nil {
+		l = zap.L()
+	}
+	return &Zap{l: l.Sugar()}
+}
+
+func (z *Zap) Debugf(format string, args ...interface{}) {
+	z.l.Debugf(format, args...)
+}
+
+func (z *Zap) Infof(format string, args ...interface{}) {
+	z.l.Infof(format, args...)
+}
+
+func (z *Zap) Warnf(format string, args ...interface{}) {
+	z.l.Warnf(format, args...)
+}
+
+func (z *Zap) Errorf(format string, args ...interface{}) {
+	z.l.Errorf(format, args...)
+}
+
+func (z *Zap) With(keyvals ...interface{}) utils.StructuredLogger {
+	return &Zap{l: z.l.With(keyvals...)}
+}
+
+func (z *Zap) WithContext(ctx context.Context) utils.Logger {
+	fields := spanFields(ctx)
+	if fields == nil {
+		return z
+	}
+	return &Zap{l: z.l.With(fields...)}
+}
@@ -0,0 +1,43 @@
+package logadapter_test
+
+import (
+	"context"
+	"github.com/asaka1234/go-mt5-sdk/logadapter"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+)
+
+func TestZapWithContext(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	var logger utils.Logger = logadapter.NewZap(zap.New(core))
+	if _, ok := logger.(utils.ContextLogger); !ok {
+		t.Fatal("Zap does not implement utils.ContextLogger")
+	}
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x0a, 0xf7},
+		SpanID:     trace.SpanID{0x01},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	ctx = utils.ContextWithLogFields(ctx, "request_id", "r1")
+
+	utils.With(utils.WithContext(ctx, logger), "login", 1001).Warnf("open %s", "EURUSD")
+	utils.WithContext(context.Background(), logger).Infof("no span")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("%d entries", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if entries[0].Message != "open EURUSD" || fields["trace_id"] != sc.TraceID().String() || fields["span_id"] != sc.SpanID().String() ||
+		fields["request_id"] != "r1" || fields["login"] != int64(1001) {
+		t.Fatalf("entry = %q %v", entries[0].Message, fields)
+	}
+	if fields := entries[1].ContextMap(); len(fields) != 0 {
+		t.Fatalf("entry without span has fields %v", fields)
+	}
+}
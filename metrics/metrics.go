@@ -0,0 +1,188 @@
+package metrics
+
+import (
+	"context"
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+	"time"
+)
+
+const namespace = "mt5"
+
+// Metrics SDK的prometheus指标
+//
+//   - http: 每个接口的耗时/状态码/mt5错误码/重试次数, 通过 Middleware 安装到 direct.Client / order.Client
+//   - pumping: 连接状态/重连次数/按消息类型统计的消息数和字节数/解码失败/发送队列长度/消息延迟,
+//     通过 Observer 安装到 pumping.TCPClient 和 pumping.SubscriptionManager
+type Metrics struct {
+	httpDuration *prometheus.HistogramVec
+	httpRequests *prometheus.CounterVec
+	httpRetries  *prometheus.CounterVec
+
+	pumpingConnected      prometheus.Gauge
+	pumpingReconnects     prometheus.Counter
+	pumpingFrames         *prometheus.CounterVec
+	pumpingBytes          *prometheus.CounterVec
+	pumpingDecodeFailures prometheus.Counter
+	pumpingSendQueue      prometheus.Gauge
+	pumpingLag            *prometheus.HistogramVec
+}
+
+// New 创建并注册所有指标, reg为nil时使用 prometheus.DefaultRegisterer
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	m := &Metrics{
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of MT5 gateway calls including retries.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "status"}),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "MT5 gateway calls by endpoint, final http status and MT5 retcode (unknown retcodes are reported as \"other\").",
+		}, []string{"endpoint", "status", "retcode"}),
+		httpRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "retries_total",
+			Help:      "Retried attempts of MT5 gateway calls.",
+		}, []string{"endpoint"}),
+
+		pumpingConnected: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "pumping",
+			Name:      "connected",
+			Help:      "1 if the pumping connection is up, 0 otherwise.",
+		}),
+		pumpingReconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "pumping",
+			Name:      "reconnects_total",
+			Help:      "Automatic reconnect attempts of the pumping connection.",
+		}),
+		pumpingFrames: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "pumping",
+			Name:      "frames_total",
+			Help:      "Decoded pumping frames by request type.",
+		}, []string{"type"}),
+		pumpingBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "pumping",
+			Name:      "bytes_total",
+			Help:      "Bytes of decoded pumping frames by request type.",
+		}, []string{"type"}),
+		pumpingDecodeFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "pumping",
+			Name:      "decode_failures_total",
+			Help:      "Pumping frames that could not be decoded.",
+		}),
+		pumpingSendQueue: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "pumping",
+			Name:      "send_queue_depth",
+			Help:      "Messages waiting in the async send queue.",
+		}),
+		pumpingLag: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "pumping",
+			Name:      "lag_seconds",
+			Help:      "Delay between the server timestamp of a frame and its arrival.",
+			Buckets:   []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}, []string{"type"}),
+	}
+
+	for _, c := range []prometheus.Collector{
+		m.httpDuration, m.httpRequests, m.httpRetries,
+		m.pumpingConnected, m.pumpingReconnects, m.pumpingFrames, m.pumpingBytes,
+		m.pumpingDecodeFailures, m.pumpingSendQueue, m.pumpingLag,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Middleware 统计每次http调用, 通过 direct.Client.Use / order.Client.Use 安装
+func (m *Metrics) Middleware() utils.Middleware {
+	return func(next utils.Handler) utils.Handler {
+		return func(ctx context.Context, call *utils.Call) error {
+			start := time.Now()
+			err := next(ctx, call)
+
+			status := strconv.Itoa(call.StatusCode)
+			m.httpDuration.WithLabelValues(call.Endpoint, status).Observe(time.Since(start).Seconds())
+			m.httpRequests.WithLabelValues(call.Endpoint, status, retcodeLabel(err)).Inc()
+			if call.Attempts > 1 {
+				m.httpRetries.WithLabelValues(call.Endpoint).Add(float64(call.Attempts - 1))
+			}
+			return err
+		}
+	}
+}
+
+// retcodeLabel 只有已知的retcode作为label, 其他的(网关返回的任意值)都记为other, 避免label数量无限增长
+// 没有retcode的错误(超时/限流等)为空
+func retcodeLabel(err error) string {
+	code, ok := utils.RetcodeOf(err)
+	switch {
+	case err == nil:
+		return strconv.Itoa(int(utils.RetcodeOK))
+	case !ok:
+		return ""
+	case !code.IsKnown():
+		return "other"
+	}
+	return strconv.Itoa(int(code))
+}
+
+// Observer pumping的监控回调, 同时安装到 TCPClient.SetObserver 和 SubscriptionManager.SetObserver
+func (m *Metrics) Observer() pumping.Observer {
+	return pumpingObserver{m: m}
+}
+
+type pumpingObserver struct {
+	m *Metrics
+}
+
+func (o pumpingObserver) OnConnState(connected bool) {
+	if connected {
+		o.m.pumpingConnected.Set(1)
+	} else {
+		o.m.pumpingConnected.Set(0)
+	}
+}
+
+func (o pumpingObserver) OnReconnect() {
+	o.m.pumpingReconnects.Inc()
+}
+
+func (o pumpingObserver) OnSendQueue(depth int) {
+	o.m.pumpingSendQueue.Set(float64(depth))
+}
+
+func (o pumpingObserver) OnFrame(response *pumping.TCPResponse, bytes int) {
+	o.m.pumpingFrames.WithLabelValues(response.Type).Inc()
+	o.m.pumpingBytes.WithLabelValues(response.Type).Add(float64(bytes))
+
+	//Timestamp 是服务端发出消息的时间(ms)
+	if response.Timestamp > 0 {
+		lag := time.Since(time.UnixMilli(response.Timestamp))
+		o.m.pumpingLag.WithLabelValues(response.Type).Observe(lag.Seconds())
+	}
+}
+
+func (o pumpingObserver) OnDecodeError(bytes int, err error) {
+	o.m.pumpingDecodeFailures.Inc()
+}
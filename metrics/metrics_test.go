@@ -0,0 +1,70 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"github.com/asaka1234/go-mt5-sdk/metrics"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"testing"
+)
+
+// requestsByRetcode mt5_http_requests_total 按retcode label汇总
+func requestsByRetcode(t *testing.T, reg *prometheus.Registry) map[string]float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]float64{}
+	for _, f := range families {
+		if f.GetName() != "mt5_http_requests_total" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "retcode" {
+					got[l.GetValue()] += m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return got
+}
+
+func TestRetcodeLabel(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := metrics.New(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := []error{
+		nil,
+		&utils.APIError{Code: int(utils.RetcodeNoMoney), HTTPStatus: http.StatusOK},
+		&utils.APIError{Code: int(utils.RetcodeNoMoney), HTTPStatus: http.StatusOK},
+		&utils.APIError{Code: 12345, HTTPStatus: http.StatusOK},
+		&utils.APIError{Code: 99999, HTTPStatus: http.StatusOK},
+		&utils.APIError{Code: -7, HTTPStatus: http.StatusBadRequest},
+		errors.New("timeout"),
+	}
+	for _, result := range results {
+		handler := m.Middleware()(func(ctx context.Context, call *utils.Call) error {
+			call.StatusCode = utils.HTTPStatusOf(result)
+			return result
+		})
+		handler(context.Background(), &utils.Call{Endpoint: "OpenPosition", Attempts: 1})
+	}
+
+	got := requestsByRetcode(t, reg)
+	want := map[string]float64{"0": 1, "10019": 2, "other": 3, "": 1}
+	if len(got) != len(want) {
+		t.Fatalf("retcode labels = %v, want %v", got, want)
+	}
+	for label, n := range want {
+		if got[label] != n {
+			t.Fatalf("retcode labels = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package model
+
+import (
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/direct"
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// FromDirectTick REST报价(TickReview)
+func FromDirectTick(t direct.MT5Tick) Tick {
+	return Tick{
+		Symbol: t.Symbol,
+		Ask:    utils.PriceFromE8(t.AskE8),
+		Bid:    utils.PriceFromE8(t.BidE8),
+		Last:   utils.PriceFromE8(t.LastE8),
+		Volume: t.Volume,
+		Time:   unixMilli(t.Time),
+	}
+}
+
+// FromPumpingTick pumping推送的报价
+func FromPumpingTick(t pumping.MT5Tick) Tick {
+	return Tick{
+		Symbol:   t.Symbol,
+		Category: t.Category,
+		Ask:      utils.PriceFromE8(t.AskE8),
+		Bid:      utils.PriceFromE8(t.BidE8),
+		Last:     utils.PriceFromE8(t.LastE8),
+		Volume:   t.Volume,
+		Time:     unixMilli(t.Time),
+	}
+}
+
+func FromDirectPosition(p direct.MTPosition) Position {
+	return Position{
+		Login:          p.Login,
+		Ticket:         p.Ticket,
+		Symbol:         p.Symbol,
+		Action:         p.Action,
+		PriceOpen:      p.PriceOpen,
+		PriceSL:        p.PriceSL,
+		PriceTP:        p.PriceTP,
+		RateMargin:     p.RateMargin,
+		RateProfit:     p.RateProfit,
+		Volume:         p.Volume,
+		Profit:         p.Profit,
+		Storage:        p.Storage,
+		ActivationMode: p.ActivationMode,
+		ActivationTime: unix(p.ActivationTime),
+		TimeCreate:     unix(p.TimeCreate),
+		Comment:        p.Comment,
+	}
+}
+
+// FromPumpingPosition pumping推送的持仓变化, 带上 Operation
+func FromPumpingPosition(p pumping.MTPositionExtra) Position {
+	return Position{
+		Login:          p.Login,
+		Ticket:         p.Ticket,
+		Symbol:         p.Symbol,
+		Action:         p.Action,
+		PriceOpen:      p.PriceOpen,
+		PriceSL:        p.PriceSL,
+		PriceTP:        p.PriceTP,
+		RateMargin:     p.RateMargin,
+		RateProfit:     p.RateProfit,
+		Volume:         p.Volume,
+		Profit:         p.Profit,
+		Storage:        p.Storage,
+		ActivationMode: p.ActivationMode,
+		ActivationTime: unix(p.ActivationTime),
+		TimeCreate:     unix(p.TimeCreate),
+		Comment:        p.Comment,
+		Operation:      Operation(p.Operation),
+	}
+}
+
+func FromDirectOrder(o direct.MTOrder) Order {
+	return Order{
+		Login:          o.Login,
+		Ticket:         o.Ticket,
+		Symbol:         o.Symbol,
+		State:          o.State,
+		ActivationMode: o.ActivationMode,
+		TimeSetup:      unix(o.TimeSetup),
+		Type:           o.Type,
+		PriceOrder:     o.PriceOrder,
+		PriceTrigger:   o.PriceTrigger,
+		PriceSL:        o.PriceSL,
+		PriceTP:        o.PriceTP,
+		Volume:         o.Volume,
+		RateMargin:     o.RateMargin,
+		Comment:        o.Comment,
+	}
+}
+
+// FromPumpingOrder pumping推送的挂单变化, 带上 Operation
+func FromPumpingOrder(o pumping.MTOrderExtra) Order {
+	return Order{
+		Login:          o.Login,
+		Ticket:         o.Ticket,
+		Symbol:         o.Symbol,
+		State:          o.State,
+		ActivationMode: o.ActivationMode,
+		TimeSetup:      unix(o.TimeSetup),
+		Type:           o.Type,
+		PriceOrder:     o.PriceOrder,
+		PriceTrigger:   o.PriceTrigger,
+		PriceSL:        o.PriceSL,
+		PriceTP:        o.PriceTP,
+		Volume:         o.Volume,
+		RateMargin:     o.RateMargin,
+		Comment:        o.Comment,
+		Operation:      Operation(o.Operation),
+	}
+}
+
+// FromPumpingDeal pumping推送的成交, 带上 Operation
+func FromPumpingDeal(d pumping.Mt5DealExtra) Deal {
+	return Deal{
+		DealId:        d.DealId,
+		PositionId:    d.PositionId,
+		Symbol:        d.Symbol,
+		Login:         d.Login,
+		Volume:        d.Volume,
+		Entry:         d.Entry,
+		Action:        d.Action,
+		Reason:        d.Reason,
+		Time:          unix(d.Time),
+		Price:         d.Price,
+		PricePosition: d.PricePosition,
+		PriceSL:       d.PriceSL,
+		PriceTP:       d.PriceTP,
+		Profit:        d.Profit,
+		RateMargin:    d.RateMargin,
+		RateProfit:    d.RateProfit,
+		Storage:       d.Storage,
+		Comment:       d.Comment,
+		Operation:     Operation(d.Operation),
+	}
+}
+
+// FromDirectUser 开户接口返回的login和密码
+func FromDirectUser(u direct.Mt5User) User {
+	return User{Login: u.Login, MasterPass: u.MasterPass, InvestorPass: u.InvestorPass}
+}
+
+// FromPumpingUser pumping推送的新开户
+func FromPumpingUser(u pumping.MT5User) User {
+	return User{Login: u.Login, Uid: u.Uid, NameSpace: u.NameSpace, Group: u.Group}
+}
+
+// FromDirectAccount margin_level 是字符串, 不是合法的数字时返回错误
+func FromDirectAccount(a direct.MTUserAccount) (Account, error) {
+	var level decimal.Decimal
+	if a.MarginLevel != "" {
+		var err error
+		if level, err = decimal.NewFromString(a.MarginLevel); err != nil {
+			return Account{}, fmt.Errorf("invalid margin_level %q of login %d", a.MarginLevel, a.Login)
+		}
+	}
+	return Account{
+		Login:       a.Login,
+		Balance:     a.Balance,
+		Margin:      a.Margin,
+		MarginFree:  a.MarginFree,
+		MarginLevel: level,
+		Leverage:    a.MarginLeverage,
+		Equity:      a.Equity,
+		Storage:     a.Storage,
+		Floating:    a.Floating,
+	}, nil
+}
+
+// FromPumpingMarginCall pumping推送的追加保证金通知
+func FromPumpingMarginCall(m pumping.MT5MarginCall) MarginCall {
+	return MarginCall{
+		Login:       m.Login,
+		Uid:         m.UID,
+		Equity:      m.Equity,
+		MarginLevel: decimal.NewFromFloat(m.MarginLevel),
+	}
+}
+
+// FromPumpingStopOut pumping推送的强平通知
+func FromPumpingStopOut(s pumping.MT5StopOut) StopOut {
+	return StopOut{
+		Login:  s.Login,
+		Uid:    s.UID,
+		Level:  decimal.NewFromFloat(s.SOLevel),
+		Equity: s.SOEquity,
+		Margin: s.SOMargin,
+	}
+}
+
+// unix 秒级时间戳, 0表示没有
+func unix(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// unixMilli 毫秒级时间戳, 0表示没有
+func unixMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
@@ -0,0 +1,201 @@
+package model_test
+
+import (
+	"encoding/json"
+	"github.com/asaka1234/go-mt5-sdk/direct"
+	"github.com/asaka1234/go-mt5-sdk/model"
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readFixture 网关REST接口的原始返回
+func readFixture(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+}
+
+// pumpingPayload 按pumping的格式(msgpack+snappy)编码后走 SubscriptionManager 解出类型化的payload
+func pumpingPayload[T any](t *testing.T, typ pumping.REQUEST_TYPE, payload []map[string]interface{}) []T {
+	t.Helper()
+	data, err := pumping.Encode(pumping.TCPResponse{Status: "ok", Type: string(typ), Payload: payload, Timestamp: 1760774400000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []T
+	sm := pumping.NewSubscriptionManager()
+	sm.RegisterTypedHandler(typ, nil, func(_ *pumping.TCPResponse, v interface{}) error {
+		got = v.([]T)
+		return nil
+	})
+	if err := sm.HandleMessage(data); err != nil {
+		t.Fatalf("HandleMessage(%s): %v", typ, err)
+	}
+	return got
+}
+
+func equal(t *testing.T, field string, got, want interface{ String() string }) {
+	t.Helper()
+	if got.String() != want.String() {
+		t.Errorf("%s = %s, want %s", field, got, want)
+	}
+}
+
+func TestFromDirectAccount(t *testing.T) {
+	var resp direct.UserAccountDetailResp
+	readFixture(t, "user_account_detail.json", &resp)
+
+	acc, err := model.FromDirectAccount(resp.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "MarginLevel", acc.MarginLevel, utils.MustParseMoney("4598.38").Decimal())
+	equal(t, "Balance", acc.Balance, utils.MustParseMoney("10000.5"))
+	equal(t, "Floating", acc.Floating, utils.MustParseMoney("-25.3"))
+	if acc.Login != 5012345 || acc.Leverage != 500 {
+		t.Errorf("account = %+v", acc)
+	}
+
+	//没有持仓时网关返回空字符串
+	resp.Data.MarginLevel = ""
+	if acc, err = model.FromDirectAccount(resp.Data); err != nil || !acc.MarginLevel.IsZero() {
+		t.Errorf("empty margin_level = %s, %v, want 0", acc.MarginLevel, err)
+	}
+	resp.Data.MarginLevel = "n/a"
+	if _, err = model.FromDirectAccount(resp.Data); err == nil || !strings.Contains(err.Error(), `"n/a"`) {
+		t.Errorf("bad margin_level err = %v", err)
+	}
+}
+
+func TestFromDirectPositionAndOrder(t *testing.T) {
+	var positions direct.ListPositionResp
+	readFixture(t, "list_position.json", &positions)
+	p := model.FromDirectPosition(*positions.Data[0])
+	equal(t, "PriceOpen", p.PriceOpen, utils.MustParsePrice("1.08523"))
+	equal(t, "Volume", p.Volume, utils.MustParseVolume("0.2"))
+	if !p.PriceTP.IsSet() || !p.PriceTP.Decimal().IsZero() {
+		t.Errorf("PriceTP = %q, want an explicit 0", p.PriceTP)
+	}
+	if !p.ActivationTime.IsZero() {
+		t.Errorf("ActivationTime = %v, want zero for timestamp 0", p.ActivationTime)
+	}
+	if !p.TimeCreate.Equal(time.Unix(1760774400, 0)) {
+		t.Errorf("TimeCreate = %v", p.TimeCreate)
+	}
+	if p.Operation != model.OperationNone {
+		t.Errorf("Operation = %v, want none for REST", p.Operation)
+	}
+
+	var orders direct.ListPendingOrderResp
+	readFixture(t, "list_pending_order.json", &orders)
+	o := model.FromDirectOrder(*orders.Data[0])
+	equal(t, "PriceOrder", o.PriceOrder, utils.MustParsePrice("2350.3"))
+	equal(t, "PriceTrigger", o.PriceTrigger, utils.MustParsePrice("2352.1"))
+	if o.PriceSL.IsSet() || o.PriceTP.IsSet() {
+		t.Errorf("sl/tp = %q/%q, want unset", o.PriceSL, o.PriceTP)
+	}
+	if o.Type != utils.OrderTypeBuyStopLimit || !o.TimeSetup.Equal(time.Unix(1760774460, 0)) {
+		t.Errorf("order = %+v", o)
+	}
+}
+
+func TestFromDirectTick(t *testing.T) {
+	var resp direct.TickReviewResp
+	readFixture(t, "tick_review.json", &resp)
+
+	eur := model.FromDirectTick(resp.Data[0])
+	equal(t, "Ask", eur.Ask, utils.MustParsePrice("1.0852"))
+	equal(t, "Spread", eur.Spread(), utils.MustParsePrice("0.0002"))
+	if !eur.Time.Equal(time.UnixMilli(1760774400123)) {
+		t.Errorf("Time = %v", eur.Time)
+	}
+	jpy := model.FromDirectTick(resp.Data[1])
+	equal(t, "Bid", jpy.Bid, utils.MustParsePrice("150.5"))
+	if !jpy.Time.IsZero() {
+		t.Errorf("Time = %v, want zero for timestamp 0", jpy.Time)
+	}
+}
+
+func TestFromPumpingOperation(t *testing.T) {
+	positions := pumpingPayload[pumping.MTPositionExtra](t, pumping.REQUEST_TYPE_POSITION, []map[string]interface{}{
+		{"operation": 1, "login": 5012345, "ticket": 880001, "symbol": "EURUSD", "action": 1, "price_open": 1.08523, "volume": 0.2, "profit": -25.3, "time_create": 1760774400, "activation_time": 0},
+		{"operation": 2, "login": 5012345, "ticket": 880002, "symbol": "EURUSD"},
+	})
+	if len(positions) != 2 {
+		t.Fatalf("%d positions", len(positions))
+	}
+	p := model.FromPumpingPosition(positions[0])
+	if p.Operation != model.OperationAdd || p.Action != utils.PositionActionSell || !p.ActivationTime.IsZero() {
+		t.Errorf("position = %+v", p)
+	}
+	equal(t, "PriceOpen", p.PriceOpen, utils.MustParsePrice("1.08523"))
+	equal(t, "Profit", p.Profit, utils.MustParseMoney("-25.3"))
+	if p = model.FromPumpingPosition(positions[1]); p.Operation != model.OperationRemove || p.Ticket != 880002 {
+		t.Errorf("removed position = %+v", p)
+	}
+
+	orders := pumpingPayload[pumping.MTOrderExtra](t, pumping.REQUEST_TYPE_ORDER, []map[string]interface{}{
+		{"operation": 3, "login": 5012345, "ticket": 990001, "symbol": "XAUUSD", "type": 2, "price_order": 2350.3, "time_setup": 0},
+	})
+	o := model.FromPumpingOrder(orders[0])
+	if o.Operation != model.OperationModify || o.Type != utils.OrderTypeBuyLimit || !o.TimeSetup.IsZero() {
+		t.Errorf("order = %+v", o)
+	}
+	equal(t, "PriceOrder", o.PriceOrder, utils.MustParsePrice("2350.3"))
+
+	deals := pumpingPayload[pumping.Mt5DealExtra](t, pumping.REQUEST_TYPE_DEAL, []map[string]interface{}{
+		{"operation": 1, "deal_id": 770001, "position_id": 880001, "login": 5012345, "symbol": "EURUSD", "entry": 1, "price": 1.0851, "profit": 2.4, "time": 1760774460},
+	})
+	d := model.FromPumpingDeal(deals[0])
+	if d.Operation != model.OperationAdd || d.Entry != utils.DealEntry(1) || !d.Time.Equal(time.Unix(1760774460, 0)) {
+		t.Errorf("deal = %+v", d)
+	}
+	equal(t, "Price", d.Price, utils.MustParsePrice("1.0851"))
+
+	ticks := pumpingPayload[pumping.MT5Tick](t, pumping.REQUEST_TYPE_TICK, []map[string]interface{}{
+		{"symbol": "EURUSD", "category": "forex", "ask_e8": 108520000, "bid_e8": 108500000, "time": 0},
+	})
+	tick := model.FromPumpingTick(ticks[0])
+	equal(t, "Bid", tick.Bid, utils.MustParsePrice("1.085"))
+	if tick.Category != "forex" || !tick.Time.IsZero() {
+		t.Errorf("tick = %+v", tick)
+	}
+
+	if got := model.OperationModify.String(); got != "modify" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := model.Operation(9).String(); got != "9" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestFromPumpingRisk(t *testing.T) {
+	calls := pumpingPayload[pumping.MT5MarginCall](t, pumping.REQUEST_TYPE_MARGINCAL, []map[string]interface{}{
+		{"login": 5012345, "uid": 42, "equity": 120.35, "margin_level": 55.2},
+	})
+	mc := model.FromPumpingMarginCall(calls[0])
+	if mc.Login != 5012345 || mc.Uid != 42 {
+		t.Errorf("margin call = %+v", mc)
+	}
+	equal(t, "Equity", mc.Equity, utils.MustParseMoney("120.35"))
+	//float转换按最短表示, 不带出二进制误差
+	equal(t, "MarginLevel", mc.MarginLevel, utils.MustParseMoney("55.2").Decimal())
+
+	stopOuts := pumpingPayload[pumping.MT5StopOut](t, pumping.REQUEST_TYPE_STOPOUT, []map[string]interface{}{
+		{"login": 5012345, "uid": 42, "so_level": 30.1, "so_equity": 65.1, "so_margin": 216.27},
+	})
+	so := model.FromPumpingStopOut(stopOuts[0])
+	equal(t, "Level", so.Level, utils.MustParseMoney("30.1").Decimal())
+	equal(t, "Equity", so.Equity, utils.MustParseMoney("65.1"))
+	equal(t, "Margin", so.Margin, utils.MustParseMoney("216.27"))
+}
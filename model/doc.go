@@ -0,0 +1,20 @@
+// Package model 统一的领域模型: 同一个持仓/挂单/成交/报价/用户/账户, 不管来自REST接口(direct)
+// 还是pumping推送, 都转成这里的一种类型, 业务代码不需要区分推和拉.
+//
+//	positions, _ := cli.Direct().ListPosition(login)
+//	for _, p := range positions.Data {
+//		onPosition(model.FromDirectPosition(*p))
+//	}
+//	cli.Stream().Handler.RegisterTypedHandler(pumping.REQUEST_TYPE_POSITION, nil, func(_ *pumping.TCPResponse, payload interface{}) error {
+//		for _, p := range payload.([]pumping.MTPositionExtra) {
+//			onPosition(model.FromPumpingPosition(p))
+//		}
+//		return nil
+//	})
+//
+// pumping推送的持仓/挂单/成交带有 Operation(add/remove/modify), REST查询的为 OperationNone.
+// 追加保证金和强平通知只有pumping推送, 转成 MarginCall / StopOut.
+//
+// 价格/手数/金额都是 utils.Price / utils.Volume / utils.Money, 报价的E8整数转成 utils.Price,
+// unix时间戳转成 time.Time(0表示没有, 转成零值), 转换都不会损失精度
+package model
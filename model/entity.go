@@ -0,0 +1,152 @@
+package model
+
+import (
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"github.com/shopspring/decimal"
+	"strconv"
+	"time"
+)
+
+// Operation pumping推送的变化类型, REST查询到的数据没有, 为 OperationNone
+type Operation uint
+
+const (
+	OperationNone   Operation = 0
+	OperationAdd    Operation = 1
+	OperationRemove Operation = 2
+	OperationModify Operation = 3
+)
+
+func (o Operation) String() string {
+	switch o {
+	case OperationNone:
+		return "none"
+	case OperationAdd:
+		return "add"
+	case OperationRemove:
+		return "remove"
+	case OperationModify:
+		return "modify"
+	}
+	return strconv.FormatUint(uint64(o), 10)
+}
+
+// Tick 报价
+type Tick struct {
+	Symbol   string      `json:"symbol"`
+	Category string      `json:"category,omitempty"` //分组, 只有pumping推送里有
+	Ask      utils.Price `json:"ask"`
+	Bid      utils.Price `json:"bid"`
+	Last     utils.Price `json:"last"`
+	Volume   uint64      `json:"volume"`
+	Time     time.Time   `json:"time"` //精确到毫秒
+}
+
+// Spread 点差 ask-bid
+func (t Tick) Spread() utils.Price { return utils.Spread(t.Ask, t.Bid) }
+
+// Mid 中间价 (ask+bid)/2
+func (t Tick) Mid() utils.Price { return utils.Mid(t.Ask, t.Bid) }
+
+// Position 持仓
+type Position struct {
+	Login          uint64                   `json:"login"`
+	Ticket         uint64                   `json:"ticket"` //position_id
+	Symbol         string                   `json:"symbol"`
+	Action         utils.PositionAction     `json:"action"` // 0-buy, 1-sell
+	PriceOpen      utils.Price              `json:"price_open"`
+	PriceSL        utils.Price              `json:"price_sl"`
+	PriceTP        utils.Price              `json:"price_tp"`
+	RateMargin     float64                  `json:"rate_margin"`
+	RateProfit     float64                  `json:"rate_profit"`
+	Volume         utils.Volume             `json:"volume"`
+	Profit         utils.Money              `json:"profit"`
+	Storage        utils.Money              `json:"storage"`
+	ActivationMode utils.PositionActivation `json:"activation_mode"` //1-sl, 2-tp, 3-so
+	ActivationTime time.Time                `json:"activation_time"`
+	TimeCreate     time.Time                `json:"time_create"`
+	Comment        string                   `json:"comment"`
+	Operation      Operation                `json:"operation,omitempty"` //pumping推送的变化类型
+}
+
+// Order 挂单
+type Order struct {
+	Login          uint64                `json:"login"`
+	Ticket         uint64                `json:"ticket"` //order_id
+	Symbol         string                `json:"symbol"`
+	State          utils.OrderState      `json:"state"`           //1是挂单 ORDER_STATE_PLACED
+	ActivationMode utils.OrderActivation `json:"activation_mode"` //0-none, 1=ACTIVATION_PENDING, 2=ACTIVATION_STOPLIMIT,3=ACTIVATION_EXPIRATION,4=ACTIVATION_STOPOUT
+	TimeSetup      time.Time             `json:"time_setup"`
+	Type           utils.OrderType       `json:"type"`          //0-buy, 1-sell,2-buy limit ,3-sell limit, 4-buy stop, 5-sell stop, 6-buy stop limit, 7-sell stop limit
+	PriceOrder     utils.Price           `json:"price_order"`   //下单价格 (stop/limit的价格)
+	PriceTrigger   utils.Price           `json:"price_trigger"` //触发价格（stop limit 单）
+	PriceSL        utils.Price           `json:"price_sl"`
+	PriceTP        utils.Price           `json:"price_tp"`
+	Volume         utils.Volume          `json:"volume"`
+	RateMargin     float64               `json:"rate_margin"`
+	Comment        string                `json:"comment"`
+	Operation      Operation             `json:"operation,omitempty"` //pumping推送的变化类型
+}
+
+// Deal 成交, 只有pumping推送
+type Deal struct {
+	DealId        uint64           `json:"deal_id"`
+	PositionId    uint64           `json:"position_id"`
+	Symbol        string           `json:"symbol"`
+	Login         uint64           `json:"login"`
+	Volume        utils.Volume     `json:"volume"`
+	Entry         utils.DealEntry  `json:"entry"` //0-ENTRY_IN 开仓, 1-ENTRY_OUT 平仓
+	Action        utils.DealAction `json:"action"`
+	Reason        utils.DealReason `json:"reason"` //发生的原因
+	Time          time.Time        `json:"time"`
+	Price         utils.Price      `json:"price"`          //执行价格
+	PricePosition utils.Price      `json:"price_position"` //持仓价格, 只有平仓时才有效
+	PriceSL       utils.Price      `json:"price_sl"`
+	PriceTP       utils.Price      `json:"price_tp"`
+	Profit        utils.Money      `json:"profit"`
+	RateMargin    float64          `json:"rate_margin"`
+	RateProfit    float64          `json:"rate_profit"`
+	Storage       utils.Money      `json:"storage"` //swap
+	Comment       string           `json:"comment"`
+	Operation     Operation        `json:"operation,omitempty"`
+}
+
+// User mt5账户. 开户接口只返回login和密码, pumping推送只有uid/分组, 其他字段为零值
+type User struct {
+	Login        uint64 `json:"login"`
+	Uid          uint64 `json:"uid,omitempty"`
+	NameSpace    string `json:"name_space,omitempty"` //Internal | YuBit
+	Group        string `json:"group,omitempty"`
+	MasterPass   string `json:"master_pass,omitempty"`
+	InvestorPass string `json:"investor_pass,omitempty"`
+}
+
+// Account 账户资金
+type Account struct {
+	Login       uint64          `json:"login"`
+	Balance     utils.Money     `json:"balance"` //余额
+	Margin      utils.Money     `json:"margin"`  //已用保证金
+	MarginFree  utils.Money     `json:"margin_free"`
+	MarginLevel decimal.Decimal `json:"margin_level"` //保证金率(百分比)
+	Leverage    uint            `json:"leverage"`     //杠杆
+	Equity      utils.Money     `json:"equity"`
+	Storage     utils.Money     `json:"storage"`
+	Floating    utils.Money     `json:"floating"`
+}
+
+// MarginCall 追加保证金通知, 只有pumping推送
+type MarginCall struct {
+	Login       uint64          `json:"login"`
+	Uid         uint64          `json:"uid"`
+	Equity      utils.Money     `json:"equity"`       //净值
+	MarginLevel decimal.Decimal `json:"margin_level"` //保证金率(百分比)
+}
+
+// StopOut 强平通知, 只有pumping推送
+type StopOut struct {
+	Login  uint64          `json:"login"`
+	Uid    uint64          `json:"uid"`
+	Level  decimal.Decimal `json:"level"`  //强平时的保证金率(百分比)
+	Equity utils.Money     `json:"equity"` //强平时的净值
+	Margin utils.Money     `json:"margin"` //强平时的已用保证金
+}
@@ -0,0 +1,134 @@
+package mt5
+
+import (
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+)
+
+// Option New 的可选配置
+type Option func(o *options)
+
+type options struct {
+	logger             utils.Logger
+	middlewares        []utils.Middleware
+	attemptMiddlewares []utils.Middleware
+	observers          []pumping.Observer
+	dispatchHooks      []pumping.DispatchHook
+}
+
+// WithLogger 所有客户端共用的logger
+func WithLogger(logger utils.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithMiddleware 同时安装到查询和交易客户端, 先安装的在外层
+func WithMiddleware(middlewares ...utils.Middleware) Option {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// WithAttemptMiddleware 同时安装到查询和交易客户端, 每次http请求(包括重试)都会经过, 先安装的在外层
+func WithAttemptMiddleware(middlewares ...utils.Middleware) Option {
+	return func(o *options) {
+		o.attemptMiddlewares = append(o.attemptMiddlewares, middlewares...)
+	}
+}
+
+// WithObserver pumping的监控回调, 可以安装多个
+func WithObserver(observer pumping.Observer) Option {
+	return func(o *options) {
+		o.observers = append(o.observers, observer)
+	}
+}
+
+// WithDispatchHook pumping消息分发的钩子, 可以安装多个, 先安装的在外层
+func WithDispatchHook(hook pumping.DispatchHook) Option {
+	return func(o *options) {
+		o.dispatchHooks = append(o.dispatchHooks, hook)
+	}
+}
+
+// WithMetrics 安装监控, 比如 metrics.Metrics: http中间件 + pumping监控回调
+func WithMetrics(m interface {
+	Middleware() utils.Middleware
+	Observer() pumping.Observer
+}) Option {
+	return func(o *options) {
+		WithMiddleware(m.Middleware())(o)
+		WithObserver(m.Observer())(o)
+	}
+}
+
+// WithTracing 安装链路追踪, 比如 tracing.Tracing: 每次调用和每次http请求的中间件 + pumping消息分发的钩子
+func WithTracing(t interface {
+	Middleware() utils.Middleware
+	AttemptMiddleware() utils.Middleware
+	DispatchHook() pumping.DispatchHook
+}) Option {
+	return func(o *options) {
+		WithMiddleware(t.Middleware())(o)
+		WithAttemptMiddleware(t.AttemptMiddleware())(o)
+		WithDispatchHook(t.DispatchHook())(o)
+	}
+}
+
+func (o options) observer() pumping.Observer {
+	switch len(o.observers) {
+	case 0:
+		return nil
+	case 1:
+		return o.observers[0]
+	}
+	return multiObserver(o.observers)
+}
+
+func (o options) dispatchHook() pumping.DispatchHook {
+	if len(o.dispatchHooks) == 0 {
+		return nil
+	}
+	hooks := o.dispatchHooks
+	return func(response *pumping.TCPResponse, dispatch func() error) error {
+		next := dispatch
+		for i := len(hooks) - 1; i >= 0; i-- {
+			hook, inner := hooks[i], next
+			next = func() error { return hook(response, inner) }
+		}
+		return next()
+	}
+}
+
+// multiObserver 把回调转发给多个Observer
+type multiObserver []pumping.Observer
+
+func (m multiObserver) OnConnState(connected bool) {
+	for _, o := range m {
+		o.OnConnState(connected)
+	}
+}
+
+func (m multiObserver) OnReconnect() {
+	for _, o := range m {
+		o.OnReconnect()
+	}
+}
+
+func (m multiObserver) OnSendQueue(depth int) {
+	for _, o := range m {
+		o.OnSendQueue(depth)
+	}
+}
+
+func (m multiObserver) OnFrame(response *pumping.TCPResponse, bytes int) {
+	for _, o := range m {
+		o.OnFrame(response, bytes)
+	}
+}
+
+func (m multiObserver) OnDecodeError(bytes int, err error) {
+	for _, o := range m {
+		o.OnDecodeError(bytes, err)
+	}
+}
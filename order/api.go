@@ -0,0 +1,27 @@
+package order
+
+import "context"
+
+// TradingAPI Client 提供的所有交易接口, 业务代码依赖它而不是 *Client, 测试时可以换成 ordermock.Fake
+// Use/SetXXX 等配置方法只在组装时使用, 不在接口里
+type TradingAPI interface {
+	OpenPosition(req OpenPositionRequest) (*CommonResp, error)
+	OpenPositionWithContext(ctx context.Context, req OpenPositionRequest) (*CommonResp, error)
+	ModifyPosition(req ModifyPositionRequest) (*CommonResp, error)
+	ModifyPositionWithContext(ctx context.Context, req ModifyPositionRequest) (*CommonResp, error)
+	ClosePosition(req ClosePositionRequest) (*CommonResp, error)
+	ClosePositionWithContext(ctx context.Context, req ClosePositionRequest) (*CommonResp, error)
+	CloseAllPositions(req CloseAllPositionsRequest) (*CommonResp, error)
+	CloseAllPositionsWithContext(ctx context.Context, req CloseAllPositionsRequest) (*CommonResp, error)
+
+	PlacePendingOrder(req PlacePendingOrderRequest) (*CommonResp, error)
+	PlacePendingOrderWithContext(ctx context.Context, req PlacePendingOrderRequest) (*CommonResp, error)
+	ModifyPendingOrder(req ModifyPendingOrderRequest) (*CommonResp, error)
+	ModifyPendingOrderWithContext(ctx context.Context, req ModifyPendingOrderRequest) (*CommonResp, error)
+	RemovePendingOrder(req RemovePendingOrderRequest) (*CommonResp, error)
+	RemovePendingOrderWithContext(ctx context.Context, req RemovePendingOrderRequest) (*CommonResp, error)
+	RemoveAllPendingOrders(req RemoveAllPendingOrdersRequest) (*CommonResp, error)
+	RemoveAllPendingOrdersWithContext(ctx context.Context, req RemoveAllPendingOrdersRequest) (*CommonResp, error)
+}
+
+var _ TradingAPI = (*Client)(nil)
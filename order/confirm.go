@@ -0,0 +1,104 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/direct"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"unicode/utf16"
+)
+
+// maxCommentLen MT5 的comment最多31个字符(wchar[32]), 更长的会被截断, 截断后没法再用来匹配
+const maxCommentLen = 31
+
+// TradeReader 确认交易是否落地时需要用到的查询接口, direct.Client 实现了它
+type TradeReader interface {
+	ListPositionWithContext(ctx context.Context, login uint64) (*direct.ListPositionResp, error)
+	ListPendingOrderWithContext(ctx context.Context, login uint64) (*direct.ListPendingOrderResp, error)
+	PositionGetWithContext(ctx context.Context, ticket uint64) (*direct.GetPositionResp, error)
+	OrderGetWithContext(ctx context.Context, ticket uint64) (*direct.GetOrderResp, error)
+}
+
+// NewTradeConfirmer 基于查询接口确认交易请求是否已经落地, 配合 Client.SetTradeConfirmer 使用
+//
+//   - 开仓/挂单: 通过 login + symbol + comment 查找持仓和挂单, 所以comment需要保证唯一, 且不能超过31个字符
+//   - 全部平仓/取消挂单: ticket还在说明没有落地; ticket查不到时分不清是这次请求平掉的还是ticket本身不对/被其他操作平掉, 按结果不明确处理
+//
+// 其他请求(部分平仓/修改/一键平仓等)无法可靠确认, 会返回包装了 utils.ErrUnconfirmable 的错误, 由调用方自行核对
+func NewTradeConfirmer(reader TradeReader) utils.TradeConfirmer {
+	return func(ctx context.Context, endpoint string, req interface{}) (bool, error) {
+		switch r := req.(type) {
+		case OpenPositionRequest:
+			if err := checkComment(r.Comment); err != nil {
+				return false, fmt.Errorf("cannot confirm open position: %w", err)
+			}
+			return hasPosition(ctx, reader, r.Login, r.Symbol, r.Comment)
+		case PlacePendingOrderRequest:
+			if err := checkComment(r.Comment); err != nil {
+				return false, fmt.Errorf("cannot confirm pending order: %w", err)
+			}
+			placed, err := hasPendingOrder(ctx, reader, r.Login, r.Symbol, r.Comment)
+			if err != nil || placed {
+				return placed, err
+			}
+			//挂单可能已经被触发成了持仓
+			return hasPosition(ctx, reader, r.Login, r.Symbol, r.Comment)
+		case ClosePositionRequest:
+			if r.Lots.IsSet() {
+				return false, fmt.Errorf("cannot confirm partial close: %w", utils.ErrUnconfirmable)
+			}
+			_, err := reader.PositionGetWithContext(ctx, uint64(r.Ticket))
+			return false, notFound(err, "position", uint64(r.Ticket))
+		case RemovePendingOrderRequest:
+			_, err := reader.OrderGetWithContext(ctx, r.Ticket)
+			return false, notFound(err, "order", r.Ticket)
+		}
+		return false, fmt.Errorf("cannot confirm request %T on %s: %w", req, endpoint, utils.ErrUnconfirmable)
+	}
+}
+
+// checkComment comment为空或者会被MT5截断时没法用来匹配
+func checkComment(comment string) error {
+	if comment == "" {
+		return fmt.Errorf("a unique comment is required: %w", utils.ErrUnconfirmable)
+	}
+	if n := len(utf16.Encode([]rune(comment))); n > maxCommentLen {
+		return fmt.Errorf("comment is %d characters, MT5 truncates it to %d: %w", n, maxCommentLen, utils.ErrUnconfirmable)
+	}
+	return nil
+}
+
+func hasPosition(ctx context.Context, reader TradeReader, login uint64, symbol, comment string) (bool, error) {
+	resp, err := reader.ListPositionWithContext(ctx, login)
+	if err != nil {
+		return false, err
+	}
+	for _, pos := range resp.Data {
+		if pos.Symbol == symbol && pos.Comment == comment {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func hasPendingOrder(ctx context.Context, reader TradeReader, login uint64, symbol, comment string) (bool, error) {
+	resp, err := reader.ListPendingOrderWithContext(ctx, login)
+	if err != nil {
+		return false, err
+	}
+	for _, o := range resp.Data {
+		if o.Symbol == symbol && o.Comment == comment {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// notFound ticket还在说明请求没有落地; 查不到时可能是这次请求平掉的, 也可能ticket本身不对, 不能当作成功
+func notFound(err error, kind string, ticket uint64) error {
+	if errors.Is(err, utils.RetcodeNotFound) {
+		return fmt.Errorf("%s %d not found, it was either removed by this request or never existed: %w", kind, ticket, utils.ErrUnconfirmable)
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package order_test
+
+import (
+	"context"
+	"errors"
+	"github.com/asaka1234/go-mt5-sdk/direct"
+	"github.com/asaka1234/go-mt5-sdk/direct/directmock"
+	"github.com/asaka1234/go-mt5-sdk/order"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"strings"
+	"testing"
+)
+
+func TestTradeConfirmer(t *testing.T) {
+	fake := &directmock.Fake{
+		ListPositionFunc: func(ctx context.Context, login uint64) (*direct.ListPositionResp, error) {
+			return &direct.ListPositionResp{Data: []*direct.MTPosition{
+				{Login: login, Ticket: 1, Symbol: "EURUSD", Comment: "open-1"},
+				{Login: login, Ticket: 2, Symbol: "XAUUSD", Comment: "pending-1"}, //已经触发的挂单
+			}}, nil
+		},
+		ListPendingOrderFunc: func(ctx context.Context, login uint64) (*direct.ListPendingOrderResp, error) {
+			return &direct.ListPendingOrderResp{Data: []*direct.MTOrder{{Login: login, Ticket: 3, Symbol: "EURUSD", Comment: "pending-2"}}}, nil
+		},
+		PositionGetFunc: func(ctx context.Context, ticket uint64) (*direct.GetPositionResp, error) {
+			if ticket == 1 {
+				return &direct.GetPositionResp{Data: direct.MTPosition{Ticket: 1}}, nil
+			}
+			return nil, &utils.APIError{Code: int(utils.RetcodeNotFound), Endpoint: "/v1/position/get"}
+		},
+		OrderGetFunc: func(ctx context.Context, ticket uint64) (*direct.GetOrderResp, error) {
+			if ticket == 3 {
+				return &direct.GetOrderResp{Data: direct.MTOrder{Ticket: 3}}, nil
+			}
+			if ticket == 4 {
+				return nil, errors.New("eof")
+			}
+			return nil, &utils.APIError{Code: int(utils.RetcodeNotFound), Endpoint: "/v1/order/get"}
+		},
+	}
+	confirm := order.NewTradeConfirmer(fake)
+
+	tests := []struct {
+		name          string
+		req           interface{}
+		want          bool
+		wantErr       string
+		unconfirmable bool
+	}{
+		{name: "open landed", req: order.OpenPositionRequest{Login: 7, Symbol: "EURUSD", Comment: "open-1"}, want: true},
+		{name: "open not landed", req: order.OpenPositionRequest{Login: 7, Symbol: "EURUSD", Comment: "open-2"}},
+		{name: "open other symbol", req: order.OpenPositionRequest{Login: 7, Symbol: "GBPUSD", Comment: "open-1"}},
+		{name: "open without comment", req: order.OpenPositionRequest{Login: 7, Symbol: "EURUSD"}, wantErr: "unique comment", unconfirmable: true},
+		{name: "open comment truncated by mt5", req: order.OpenPositionRequest{Login: 7, Symbol: "EURUSD", Comment: strings.Repeat("x", 32)}, wantErr: "truncates", unconfirmable: true},
+		{name: "open comment 31 wide chars", req: order.OpenPositionRequest{Login: 7, Symbol: "EURUSD", Comment: strings.Repeat("单", 31)}},
+		{name: "pending placed", req: order.PlacePendingOrderRequest{Login: 7, Symbol: "EURUSD", Comment: "pending-2"}, want: true},
+		{name: "pending triggered", req: order.PlacePendingOrderRequest{Login: 7, Symbol: "XAUUSD", Comment: "pending-1"}, want: true},
+		{name: "pending not placed", req: order.PlacePendingOrderRequest{Login: 7, Symbol: "EURUSD", Comment: "pending-3"}},
+		{name: "close still open", req: order.ClosePositionRequest{Ticket: 1}},
+		{name: "close not found is not success", req: order.ClosePositionRequest{Ticket: 9}, wantErr: "never existed", unconfirmable: true},
+		{name: "partial close", req: order.ClosePositionRequest{Ticket: 1, Lots: utils.VolumeFromFloat(0.1)}, wantErr: "partial close", unconfirmable: true},
+		{name: "remove still pending", req: order.RemovePendingOrderRequest{Ticket: 3}},
+		{name: "remove not found is not success", req: order.RemovePendingOrderRequest{Ticket: 9}, wantErr: "never existed", unconfirmable: true},
+		{name: "remove lookup failed", req: order.RemovePendingOrderRequest{Ticket: 4}, wantErr: "eof"},
+		{name: "modify", req: order.ModifyPositionRequest{Ticket: 1}, wantErr: "cannot confirm", unconfirmable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := confirm(context.Background(), "/v1/test", tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				if errors.Is(err, utils.ErrUnconfirmable) != tt.unconfirmable {
+					t.Fatalf("errors.Is(%v, ErrUnconfirmable) != %v", err, tt.unconfirmable)
+				}
+			} else if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("landed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,24 +1,29 @@
 package order
 
-type MtRequestType int
-type MtOrderTime uint
+import "github.com/asaka1234/go-mt5-sdk/utils"
+
+// 和 utils 里的枚举是同一个类型, 保留原来的名字; MtRequestType 底层仍然是int, MtOrderTime 仍然是uint
+type MtRequestType = utils.OrderType
+type MtOrderTime = utils.OrderTime
 
 // https://support.metaquotes.net/en/docs/mt5/api/reference_trading/trading_order/imtorder/imtorder_enum#enordertype
 const (
-	MtRequestTypeBuy       MtRequestType = 0 //buy mt5也如此
-	MtRequestTypeSell      MtRequestType = 1 //sell mt5也如此
-	MtRequestTypeBuyLimit  MtRequestType = 2
-	MtRequestTypeSellLimit MtRequestType = 3
-	MtRequestTypeBuyStop   MtRequestType = 4
-	MtRequestTypeSellStop  MtRequestType = 5
+	MtRequestTypeBuy           = utils.OrderTypeBuy  //buy mt5也如此
+	MtRequestTypeSell          = utils.OrderTypeSell //sell mt5也如此
+	MtRequestTypeBuyLimit      = utils.OrderTypeBuyLimit
+	MtRequestTypeSellLimit     = utils.OrderTypeSellLimit
+	MtRequestTypeBuyStop       = utils.OrderTypeBuyStop
+	MtRequestTypeSellStop      = utils.OrderTypeSellStop
+	MtRequestTypeBuyStopLimit  = utils.OrderTypeBuyStopLimit
+	MtRequestTypeSellStopLimit = utils.OrderTypeSellStopLimit
 )
 
 // -----------------------------
 // https://support.metaquotes.net/en/docs/mt5/api/reference_trading/trading_order/imtorder/imtorder_enum#enordertime
 // 挂单挂到什么时候?
 const (
-	MtOrderTimeGTC          MtOrderTime = 0 //gtc
-	MtOrderTimeDay          MtOrderTime = 1 //
-	MtOrderTimeSpecified    MtOrderTime = 0 //
-	MtOrderTimeSpecifiedDay MtOrderTime = 1 //
+	MtOrderTimeGTC          = utils.OrderTimeGTC          //gtc
+	MtOrderTimeDay          = utils.OrderTimeDay          //当天有效
+	MtOrderTimeSpecified    = utils.OrderTimeSpecified    //到 expire_time 为止
+	MtOrderTimeSpecifiedDay = utils.OrderTimeSpecifiedDay //到 expire_time 当天结束为止
 )
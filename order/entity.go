@@ -1,8 +1,9 @@
 package order
 
-type InitParams struct {
-	Address string `json:"address" mapstructure:"address" config:"address" yaml:"address"` // http://ip:port这样的地址
-}
+import "github.com/asaka1234/go-mt5-sdk/utils"
+
+// InitParams 网关的配置, 和 direct.InitParams 是同一个类型
+type InitParams = utils.ClientParams
 
 // -----------------------------------
 
@@ -10,14 +11,14 @@ type InitParams struct {
 type OpenPositionRequest struct {
 	//required
 	Login  uint64        `json:"login"` //下单人
-	Lots   string        `json:"lots"`  // lots手数  float64
+	Lots   utils.Volume  `json:"lots"`  // lots手数
 	Symbol string        `json:"symbol"`
 	Type   MtRequestType `json:"type"` // 只支持类型: 0-buy, 1-sell
 
 	//option
-	Comment string `json:"comment,omitempty"`
-	Sl      string `json:"sl,omitempty"` //float64 (为了避免精度损失)
-	Tp      string `json:"tp,omitempty"` //float64 (为了避免精度损失)
+	Comment string      `json:"comment,omitempty"`
+	Sl      utils.Price `json:"sl,omitzero"`
+	Tp      utils.Price `json:"tp,omitzero"`
 }
 
 // 只能修改sl/tp
@@ -25,14 +26,14 @@ type ModifyPositionRequest struct {
 	//required
 	Ticket uint64 `json:"ticket"` //是要修改的 position 的id, 通过它可以拿到: symbol, login, type,
 
-	Sl string `json:"sl,omitempty"` //float64 (为了避免精度损失)
-	Tp string `json:"tp,omitempty"` //float64 (为了避免精度损失)
+	Sl utils.Price `json:"sl,omitzero"`
+	Tp utils.Price `json:"tp,omitzero"`
 }
 
 // 平通平仓单
 type ClosePositionRequest struct {
-	Lots   string `json:"lots,omitempty"` // lots手数  float64
-	Ticket int    `json:"ticket"`         //是要平掉的order/position的id (通过这个可以拿到symbol和login)
+	Lots   utils.Volume `json:"lots,omitzero"` // lots手数, 不传则全部平仓
+	Ticket int          `json:"ticket"`        //是要平掉的order/position的id (通过这个可以拿到symbol和login)
 
 	//option
 	Comment string `json:"comment,omitempty"`
@@ -57,19 +58,19 @@ type PlacePendingOrderRequest struct {
 	//required
 	Login          uint64        `json:"login"` //下单人
 	Symbol         string        `json:"symbol"`
-	Lots           string        `json:"lots"`             // lots手数 float64
+	Lots           utils.Volume  `json:"lots"`             // lots手数
 	Type           MtRequestType `json:"type"`             // 只支持如下6种类型: 2-OP_BUY_LIMIT, 3-OP_SELL_LIMIT, 4-OP_BUY_STOP, 5-OP_SELL_STOP，6-OP_BUY_STOP_LIMIT，7-OP_SELL_STOP_LIMIT
-	Price          string        `json:"price"`            // 挂单的价格(手动指定的)
+	Price          utils.Price   `json:"price"`            // 挂单的价格(手动指定的)
 	ExpireTimeType MtOrderTime   `json:"expire_time_type"` // 不传默认gtc
 
 	//option
-	ExpireTime   int64  `json:"expire_time"`   //到期时间,unix时间戳,传0则是不限制,
-	TriggerPrice string `json:"trigger_price"` //float64 只有6/7生效.  只有 Set the price, at which a Limit order is placed when the Stop Limit order triggers.
+	ExpireTime   int64       `json:"expire_time"`   //到期时间,unix时间戳,传0则是不限制,
+	TriggerPrice utils.Price `json:"trigger_price"` //只有6/7生效.  只有 Set the price, at which a Limit order is placed when the Stop Limit order triggers.
 
 	//option
-	Comment string `json:"comment,omitempty"`
-	Sl      string `json:"sl,omitempty"` //float64 (为了避免精度损失)
-	Tp      string `json:"tp,omitempty"` //float64 (为了避免精度损失)
+	Comment string      `json:"comment,omitempty"`
+	Sl      utils.Price `json:"sl,omitzero"`
+	Tp      utils.Price `json:"tp,omitzero"`
 }
 
 // 修改挂单
@@ -79,10 +80,10 @@ type ModifyPendingOrderRequest struct {
 	Ticket uint64 `json:"ticket"` //是要修改的 order 的id, 通过它可以拿到: symbol, login, type,
 
 	//option
-	Price        string `json:"price"`         //float64     // 新的价格 (不改就还是以前的价格)
-	TriggerPrice string `json:"trigger_price"` //float64  new - 只有6/7生效.  只有 Set the price, at which a Limit order is placed when the Stop Limit order triggers.
-	Sl           string `json:"sl,omitempty"`  //float64 (为了避免精度损失)
-	Tp           string `json:"tp,omitempty"`  //float64 (为了避免精度损失)
+	Price        utils.Price `json:"price"`         // 新的价格 (不改就还是以前的价格)
+	TriggerPrice utils.Price `json:"trigger_price"` // new - 只有6/7生效.  只有 Set the price, at which a Limit order is placed when the Stop Limit order triggers.
+	Sl           utils.Price `json:"sl,omitzero"`
+	Tp           utils.Price `json:"tp,omitzero"`
 
 	ExpireTimeType MtOrderTime `json:"expire_time_type"` // 不传默认gtc
 	ExpireTime     int64       `json:"expire_time"`      //到期时间,unix时间戳,传0则是不限制,
@@ -109,3 +110,7 @@ type CommonResp struct {
 	Message string      `json:"message"`        //错误信息
 	Data    interface{} `json:"data,omitempty"` //数据
 }
+
+func (r *CommonResp) CommonResult() (bool, int, string) {
+	return r.Success, r.Code, r.Message
+}
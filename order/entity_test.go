@@ -0,0 +1,41 @@
+package order
+
+import (
+	"encoding/json"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"testing"
+)
+
+// 显式设置的0(清掉sl/tp)必须发出去, 没有设置的可选字段不传
+func TestRequestWireFormat(t *testing.T) {
+	zero := utils.MustParsePrice("0")
+	tests := []struct {
+		name string
+		req  interface{}
+		want string
+	}{
+		{"open clears sl/tp", OpenPositionRequest{Login: 1, Lots: utils.MustParseVolume("0.1"), Symbol: "EURUSD", Sl: zero, Tp: zero},
+			`{"login":1,"lots":"0.1","symbol":"EURUSD","type":0,"sl":"0","tp":"0"}`},
+		{"open without sl/tp", OpenPositionRequest{Login: 1, Lots: utils.MustParseVolume("0.1"), Symbol: "EURUSD"},
+			`{"login":1,"lots":"0.1","symbol":"EURUSD","type":0}`},
+		{"modify position clears sl", ModifyPositionRequest{Ticket: 2, Sl: zero, Tp: utils.MustParsePrice("1.09")},
+			`{"ticket":2,"sl":"0","tp":"1.09"}`},
+		{"place clears sl/tp", PlacePendingOrderRequest{Login: 1, Symbol: "EURUSD", Lots: utils.MustParseVolume("0.1"), Type: MtRequestTypeBuyLimit, Price: utils.MustParsePrice("1.084"), Sl: zero, Tp: zero},
+			`{"login":1,"symbol":"EURUSD","lots":"0.1","type":2,"price":"1.084","expire_time_type":0,"expire_time":0,"trigger_price":"","sl":"0","tp":"0"}`},
+		{"modify pending clears sl/tp", ModifyPendingOrderRequest{Ticket: 3, Sl: zero, Tp: zero},
+			`{"ticket":3,"price":"","trigger_price":"","sl":"0","tp":"0","expire_time_type":0,"expire_time":0}`},
+		{"modify pending keeps price", ModifyPendingOrderRequest{Ticket: 3, Price: utils.MustParsePrice("1.083")},
+			`{"ticket":3,"price":"1.083","trigger_price":"","expire_time_type":0,"expire_time":0}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Fatalf("json = %s\nwant   %s", data, tt.want)
+			}
+		})
+	}
+}
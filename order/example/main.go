@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"github.com/asaka1234/go-mt5-sdk/order"
+	"github.com/asaka1234/go-mt5-sdk/utils"
 )
 
 type VLog struct {
@@ -27,7 +28,7 @@ func main() {
 	ADDR := "http://127.0.0.1:8352"
 
 	//构造client
-	cli := order.NewClient(vlog, &order.InitParams{ADDR}) //
+	cli := order.NewClient(vlog, &order.InitParams{Address: ADDR}) //
 	cli.SetDebugModel(true)
 
 	//---->开仓-------------
@@ -54,7 +55,7 @@ func main() {
 func GenOpenPositionRequestDemo() order.OpenPositionRequest {
 	return order.OpenPositionRequest{
 		Login:   123450079,
-		Lots:    "0.5",
+		Lots:    utils.MustParseVolume("0.5"),
 		Symbol:  "XAUUSD.s",
 		Type:    0,
 		Comment: "new",
@@ -64,13 +65,13 @@ func GenOpenPositionRequestDemo() order.OpenPositionRequest {
 func GenModifyPositionRequestDemo() order.ModifyPositionRequest {
 	return order.ModifyPositionRequest{
 		Ticket: 467068,
-		Tp:     "2.1",
+		Tp:     utils.MustParsePrice("2.1"),
 	}
 }
 
 func GenClosePositionRequestDemo() order.ClosePositionRequest {
 	return order.ClosePositionRequest{
-		Lots:    "0.1", //部分平仓
+		Lots:    utils.MustParseVolume("0.1"), //部分平仓
 		Ticket:  642331,
 		Comment: "uid-1234",
 	}
@@ -86,10 +87,10 @@ func GenCloseAllPositionRequestDemo() order.CloseAllPositionsRequest {
 func GenPlacePendingOrderRequestDemo() order.PlacePendingOrderRequest {
 	return order.PlacePendingOrderRequest{
 		Login:   123450079,
-		Lots:    "0.5",
+		Lots:    utils.MustParseVolume("0.5"),
 		Symbol:  "XAUUSD.s",
 		Type:    3, // 2-OP_BUY_LIMIT, 3-OP_SELL_LIMIT, 4-OP_BUY_STOP, 5-OP_SELL_STOP，6-OP_BUY_STOP_LIMIT，7-OP_SELL_STOP_LIMIT
-		Price:   "4237.1",
+		Price:   utils.MustParsePrice("4237.1"),
 		Comment: "GenPlacePendingOrderRequestDemo",
 	}
 }
@@ -97,7 +98,7 @@ func GenPlacePendingOrderRequestDemo() order.PlacePendingOrderRequest {
 func GenModifyPendingOrderRequestDemo() order.ModifyPendingOrderRequest {
 	return order.ModifyPendingOrderRequest{
 		Ticket:  654016,
-		Price:   "4225",
+		Price:   utils.MustParsePrice("4225"),
 		Comment: "ModifyPendingOrderRequest",
 	}
 }
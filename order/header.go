@@ -1,7 +0,0 @@
-package order
-
-func getHeaders() map[string]string {
-	return map[string]string{
-		"Content-Type": "application/json",
-	}
-}
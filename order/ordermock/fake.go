@@ -0,0 +1,181 @@
+package ordermock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/order"
+	"sync"
+)
+
+// ErrNotProgrammed 调用了没有设置 XXXFunc 的方法
+var ErrNotProgrammed = errors.New("ordermock: method is not programmed")
+
+// Call 一次调用的记录, 带ctx和不带ctx的两个版本记录成同一个Method
+type Call struct {
+	Method string
+	Ctx    context.Context
+	Arg    interface{} //请求参数(login/ticket/req), 没有参数时为nil
+}
+
+// Fake 手写的 order.TradingAPI 实现, 记录所有调用, 返回值由对应的 XXXFunc 决定, 没有设置时返回 ErrNotProgrammed
+//
+//	fake := &ordermock.Fake{
+//		OpenPositionFunc: func(ctx context.Context, req order.OpenPositionRequest) (*order.CommonResp, error) {
+//			return nil, utils.RetcodeNoMoney
+//		},
+//	}
+//	svc := NewService(fake) //依赖 order.TradingAPI
+//	...
+//	req := fake.CallsTo("OpenPosition")[0].Arg.(order.OpenPositionRequest)
+type Fake struct {
+	OpenPositionFunc           func(ctx context.Context, req order.OpenPositionRequest) (*order.CommonResp, error)
+	ModifyPositionFunc         func(ctx context.Context, req order.ModifyPositionRequest) (*order.CommonResp, error)
+	ClosePositionFunc          func(ctx context.Context, req order.ClosePositionRequest) (*order.CommonResp, error)
+	CloseAllPositionsFunc      func(ctx context.Context, req order.CloseAllPositionsRequest) (*order.CommonResp, error)
+	PlacePendingOrderFunc      func(ctx context.Context, req order.PlacePendingOrderRequest) (*order.CommonResp, error)
+	ModifyPendingOrderFunc     func(ctx context.Context, req order.ModifyPendingOrderRequest) (*order.CommonResp, error)
+	RemovePendingOrderFunc     func(ctx context.Context, req order.RemovePendingOrderRequest) (*order.CommonResp, error)
+	RemoveAllPendingOrdersFunc func(ctx context.Context, req order.RemoveAllPendingOrdersRequest) (*order.CommonResp, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ order.TradingAPI = (*Fake)(nil)
+
+// Calls 所有调用, 按调用顺序
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo 某个方法的所有调用, method不带 WithContext 后缀
+func (f *Fake) CallsTo(method string) []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []Call
+	for _, c := range f.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset 清空调用记录, 不影响已经设置的 XXXFunc
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+func (f *Fake) record(ctx context.Context, method string, arg interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Method: method, Ctx: ctx, Arg: arg})
+}
+
+func notProgrammed(method string) error {
+	return fmt.Errorf("%w: %s", ErrNotProgrammed, method)
+}
+
+//------------------------------------------------------------------------
+
+func (f *Fake) OpenPosition(req order.OpenPositionRequest) (*order.CommonResp, error) {
+	return f.OpenPositionWithContext(context.Background(), req)
+}
+
+func (f *Fake) OpenPositionWithContext(ctx context.Context, req order.OpenPositionRequest) (*order.CommonResp, error) {
+	f.record(ctx, "OpenPosition", req)
+	if f.OpenPositionFunc == nil {
+		return nil, notProgrammed("OpenPosition")
+	}
+	return f.OpenPositionFunc(ctx, req)
+}
+
+func (f *Fake) ModifyPosition(req order.ModifyPositionRequest) (*order.CommonResp, error) {
+	return f.ModifyPositionWithContext(context.Background(), req)
+}
+
+func (f *Fake) ModifyPositionWithContext(ctx context.Context, req order.ModifyPositionRequest) (*order.CommonResp, error) {
+	f.record(ctx, "ModifyPosition", req)
+	if f.ModifyPositionFunc == nil {
+		return nil, notProgrammed("ModifyPosition")
+	}
+	return f.ModifyPositionFunc(ctx, req)
+}
+
+func (f *Fake) ClosePosition(req order.ClosePositionRequest) (*order.CommonResp, error) {
+	return f.ClosePositionWithContext(context.Background(), req)
+}
+
+func (f *Fake) ClosePositionWithContext(ctx context.Context, req order.ClosePositionRequest) (*order.CommonResp, error) {
+	f.record(ctx, "ClosePosition", req)
+	if f.ClosePositionFunc == nil {
+		return nil, notProgrammed("ClosePosition")
+	}
+	return f.ClosePositionFunc(ctx, req)
+}
+
+func (f *Fake) CloseAllPositions(req order.CloseAllPositionsRequest) (*order.CommonResp, error) {
+	return f.CloseAllPositionsWithContext(context.Background(), req)
+}
+
+func (f *Fake) CloseAllPositionsWithContext(ctx context.Context, req order.CloseAllPositionsRequest) (*order.CommonResp, error) {
+	f.record(ctx, "CloseAllPositions", req)
+	if f.CloseAllPositionsFunc == nil {
+		return nil, notProgrammed("CloseAllPositions")
+	}
+	return f.CloseAllPositionsFunc(ctx, req)
+}
+
+func (f *Fake) PlacePendingOrder(req order.PlacePendingOrderRequest) (*order.CommonResp, error) {
+	return f.PlacePendingOrderWithContext(context.Background(), req)
+}
+
+func (f *Fake) PlacePendingOrderWithContext(ctx context.Context, req order.PlacePendingOrderRequest) (*order.CommonResp, error) {
+	f.record(ctx, "PlacePendingOrder", req)
+	if f.PlacePendingOrderFunc == nil {
+		return nil, notProgrammed("PlacePendingOrder")
+	}
+	return f.PlacePendingOrderFunc(ctx, req)
+}
+
+func (f *Fake) ModifyPendingOrder(req order.ModifyPendingOrderRequest) (*order.CommonResp, error) {
+	return f.ModifyPendingOrderWithContext(context.Background(), req)
+}
+
+func (f *Fake) ModifyPendingOrderWithContext(ctx context.Context, req order.ModifyPendingOrderRequest) (*order.CommonResp, error) {
+	f.record(ctx, "ModifyPendingOrder", req)
+	if f.ModifyPendingOrderFunc == nil {
+		return nil, notProgrammed("ModifyPendingOrder")
+	}
+	return f.ModifyPendingOrderFunc(ctx, req)
+}
+
+func (f *Fake) RemovePendingOrder(req order.RemovePendingOrderRequest) (*order.CommonResp, error) {
+	return f.RemovePendingOrderWithContext(context.Background(), req)
+}
+
+func (f *Fake) RemovePendingOrderWithContext(ctx context.Context, req order.RemovePendingOrderRequest) (*order.CommonResp, error) {
+	f.record(ctx, "RemovePendingOrder", req)
+	if f.RemovePendingOrderFunc == nil {
+		return nil, notProgrammed("RemovePendingOrder")
+	}
+	return f.RemovePendingOrderFunc(ctx, req)
+}
+
+func (f *Fake) RemoveAllPendingOrders(req order.RemoveAllPendingOrdersRequest) (*order.CommonResp, error) {
+	return f.RemoveAllPendingOrdersWithContext(context.Background(), req)
+}
+
+func (f *Fake) RemoveAllPendingOrdersWithContext(ctx context.Context, req order.RemoveAllPendingOrdersRequest) (*order.CommonResp, error) {
+	f.record(ctx, "RemoveAllPendingOrders", req)
+	if f.RemoveAllPendingOrdersFunc == nil {
+		return nil, notProgrammed("RemoveAllPendingOrders")
+	}
+	return f.RemoveAllPendingOrdersFunc(ctx, req)
+}
@@ -0,0 +1,127 @@
+package ordermock_test
+
+import (
+	"context"
+	"errors"
+	"github.com/asaka1234/go-mt5-sdk/order"
+	"github.com/asaka1234/go-mt5-sdk/order/ordermock"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type ctxKey struct{}
+
+func TestFakeProgrammed(t *testing.T) {
+	fake := &ordermock.Fake{
+		OpenPositionFunc: func(ctx context.Context, req order.OpenPositionRequest) (*order.CommonResp, error) {
+			if req.Lots.Decimal().GreaterThan(utils.MustParseVolume("10").Decimal()) {
+				return nil, utils.RetcodeNoMoney
+			}
+			return &order.CommonResp{Success: true, Data: req.Symbol}, nil
+		},
+	}
+
+	resp, err := fake.OpenPosition(order.OpenPositionRequest{Login: 1001, Lots: utils.MustParseVolume("0.1"), Symbol: "EURUSD"})
+	if err != nil || !resp.Success || resp.Data != "EURUSD" {
+		t.Fatalf("OpenPosition = %+v, %v", resp, err)
+	}
+	if _, err := fake.OpenPosition(order.OpenPositionRequest{Login: 1001, Lots: utils.MustParseVolume("50"), Symbol: "EURUSD"}); !errors.Is(err, utils.RetcodeNoMoney) {
+		t.Fatalf("err = %v, want RetcodeNoMoney", err)
+	}
+	//没有设置的方法返回 ErrNotProgrammed, 调用同样会被记录
+	if _, err := fake.ClosePosition(order.ClosePositionRequest{Ticket: 7}); !errors.Is(err, ordermock.ErrNotProgrammed) {
+		t.Fatalf("err = %v, want ErrNotProgrammed", err)
+	}
+	if calls := fake.CallsTo("ClosePosition"); len(calls) != 1 || calls[0].Arg.(order.ClosePositionRequest).Ticket != 7 {
+		t.Fatalf("ClosePosition calls = %+v", calls)
+	}
+}
+
+func TestFakeRecordsCalls(t *testing.T) {
+	fake := &ordermock.Fake{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "req-1")
+	open := order.OpenPositionRequest{Login: 1001, Lots: utils.MustParseVolume("0.1"), Symbol: "EURUSD"}
+	closeReq := order.ClosePositionRequest{Ticket: 880001}
+	removeAll := order.RemoveAllPendingOrdersRequest{Login: 1001}
+
+	fake.OpenPositionWithContext(ctx, open)
+	fake.ClosePosition(closeReq)
+	fake.RemoveAllPendingOrders(removeAll)
+	fake.OpenPosition(open)
+
+	calls := fake.Calls()
+	want := []struct {
+		method string
+		arg    interface{}
+	}{
+		{"OpenPosition", open},
+		{"ClosePosition", closeReq},
+		{"RemoveAllPendingOrders", removeAll},
+		{"OpenPosition", open},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("%d calls, want %d: %+v", len(calls), len(want), calls)
+	}
+	for i, w := range want {
+		if calls[i].Method != w.method || !reflect.DeepEqual(calls[i].Arg, w.arg) {
+			t.Errorf("call %d = %s(%+v), want %s(%+v)", i, calls[i].Method, calls[i].Arg, w.method, w.arg)
+		}
+	}
+	//带ctx和不带ctx的版本记录成同一个Method
+	opens := fake.CallsTo("OpenPosition")
+	if len(opens) != 2 || opens[0].Ctx.Value(ctxKey{}) != "req-1" || opens[1].Ctx != context.Background() {
+		t.Errorf("CallsTo(OpenPosition) = %+v", opens)
+	}
+
+	fake.Reset()
+	if len(fake.Calls()) != 0 {
+		t.Fatal("calls left after Reset")
+	}
+	//Reset 不影响已经设置的 XXXFunc
+	fake.ModifyPositionFunc = func(ctx context.Context, req order.ModifyPositionRequest) (*order.CommonResp, error) {
+		return &order.CommonResp{Success: true}, nil
+	}
+	fake.Reset()
+	if _, err := fake.ModifyPosition(order.ModifyPositionRequest{}); err != nil {
+		t.Fatalf("ModifyPosition after Reset: %v", err)
+	}
+}
+
+func TestFakeConcurrent(t *testing.T) {
+	fake := &ordermock.Fake{
+		PlacePendingOrderFunc: func(ctx context.Context, req order.PlacePendingOrderRequest) (*order.CommonResp, error) {
+			return &order.CommonResp{Success: true}, nil
+		},
+	}
+	const workers, perWorker = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				login := uint64(w*perWorker + i)
+				if _, err := fake.PlacePendingOrder(order.PlacePendingOrderRequest{Login: login}); err != nil {
+					t.Error(err)
+				}
+				fake.RemovePendingOrder(order.RemovePendingOrderRequest{})
+				fake.Calls()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	placed := fake.CallsTo("PlacePendingOrder")
+	if len(placed) != workers*perWorker || len(fake.CallsTo("RemovePendingOrder")) != workers*perWorker {
+		t.Fatalf("%d calls recorded, want %d", len(fake.Calls()), 2*workers*perWorker)
+	}
+	seen := make(map[uint64]bool, len(placed))
+	for _, c := range placed {
+		seen[c.Arg.(order.PlacePendingOrderRequest).Login] = true
+	}
+	if len(seen) != workers*perWorker {
+		t.Fatalf("%d distinct logins recorded, want %d", len(seen), workers*perWorker)
+	}
+}
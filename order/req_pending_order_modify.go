@@ -1,47 +1,31 @@
 package order
 
 import (
-	"crypto/tls"
-	"fmt"
+	"context"
 	"github.com/asaka1234/go-mt5-sdk/utils"
-	"github.com/json-iterator/go"
+	"net/http"
 )
 
-// 挂单
+// 修改挂单
 func (cli *Client) ModifyPendingOrder(req ModifyPendingOrderRequest) (*CommonResp, error) {
+	return cli.ModifyPendingOrderWithContext(context.Background(), req)
+}
 
-	rawURL := cli.Params.Address + "/v1/pending/order/modify"
+func (cli *Client) ModifyPendingOrderWithContext(ctx context.Context, req ModifyPendingOrderRequest) (*CommonResp, error) {
 
 	//返回值会放到这里
 	var result CommonResp
 
-	resp, err := cli.ryClient.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}).
-		SetCloseConnection(true).
-		R().
-		SetBody(req).
-		SetHeaders(getHeaders()).
-		SetDebug(cli.debugMode).
-		SetResult(&result).
-		SetError(&result).
-		Post(rawURL)
-
-	//print log
-	restLog, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(utils.GetRestyLog(resp))
-	cli.logger.Infof("MT5#ModifyPendingOrder->%+v", string(restLog))
-
+	err := cli.Do(ctx, &utils.Call{
+		Endpoint: "ModifyPendingOrder",
+		Method:   http.MethodPost,
+		Path:     "/v1/pending/order/modify",
+		Request:  req,
+		Response: &result,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != 200 {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
-	}
-
-	if resp.Error() != nil {
-		//反序列化错误会在此捕捉
-		return nil, fmt.Errorf("%v, body:%s", resp.Error(), resp.Body())
-	}
-
-	return &result, err
+	return &result, nil
 }
@@ -21,14 +21,18 @@ type TCPClient struct {
 	reader         *bufio.Reader
 	writer         *bufio.Writer
 	mu             sync.RWMutex
+	writeMu        sync.Mutex // writer 不是并发安全的, 写/心跳/调用方会同时 Send
 	isConnected    atomic.Bool
-	reconnectCount int
+	reconnectCount atomic.Int32 // 自动重连的次数, Connect 时清零
+	reconnectTimer *time.Timer  // 等待中的重连, Connect/Disconnect 会取消它
 	cancel         context.CancelFunc
-	wg             sync.WaitGroup
+	wg             *sync.WaitGroup // 当前连接的读写/心跳goroutine, 每次连接新建
 
 	// 添加消息队列用于异步发送
 	sendQueue     chan []byte
 	sendQueueSize int
+
+	observer Observer // 监控回调
 }
 
 // NewTCPClient 创建新的TCP客户端
@@ -44,14 +48,30 @@ func NewTCPClient(config *Config, handler MessageHandler) *TCPClient {
 		config:        config,
 		handler:       handler,
 		sendQueueSize: 1000, // 默认发送队列大小
+		observer:      NopObserver{},
 	}
 }
 
-// Connect 连接到服务器
+// SetObserver 设置监控回调, 需要在Connect之前设置
+func (c *TCPClient) SetObserver(observer Observer) {
+	if observer == nil {
+		observer = NopObserver{}
+	}
+	c.observer = observer
+}
+
+// Connect 连接到服务器, 同时重置自动重连的次数
 func (c *TCPClient) Connect() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.stopReconnect()
+	c.reconnectCount.Store(0)
+	return c.connect()
+}
+
+// connect 调用方需要持有 c.mu, 自动重连走这里, 不重置重连次数
+func (c *TCPClient) connect() error {
 	if c.isConnected.Load() {
 		return fmt.Errorf("client is already connected")
 	}
@@ -65,7 +85,6 @@ func (c *TCPClient) Connect() error {
 	c.reader = bufio.NewReaderSize(conn, c.config.BufferSize)
 	c.writer = bufio.NewWriterSize(conn, c.config.BufferSize)
 	c.isConnected.Store(true)
-	c.reconnectCount = 0
 
 	// 创建发送队列
 	c.sendQueue = make(chan []byte, c.sendQueueSize)
@@ -75,11 +94,14 @@ func (c *TCPClient) Connect() error {
 	c.cancel = cancel
 
 	// 启动读写goroutine
-	c.wg.Add(3)
-	go c.readLoop(ctx)
-	go c.writeLoop(ctx)
-	go c.heartbeatLoop(ctx)
-
+	wg := &sync.WaitGroup{}
+	c.wg = wg
+	wg.Add(3)
+	go c.readLoop(ctx, wg, c.reader)
+	go c.writeLoop(ctx, wg, c.sendQueue)
+	go c.heartbeatLoop(ctx, wg)
+
+	c.observer.OnConnState(true)
 	c.handler.OnConnected()
 	return nil
 }
@@ -92,6 +114,8 @@ func (c *TCPClient) Send(data []byte) error {
 
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
 
 	if c.writer == nil {
 		return fmt.Errorf("writer is not initialized")
@@ -114,12 +138,17 @@ func (c *TCPClient) Send(data []byte) error {
 
 // SendAsync 异步发送数据
 func (c *TCPClient) SendAsync(data []byte) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	if !c.isConnected.Load() {
 		return fmt.Errorf("client is not connected")
 	}
 
+	//队列不会被关闭, 断开后 writeLoop 通过ctx退出, 剩下的数据随队列丢弃
 	select {
 	case c.sendQueue <- data:
+		c.observer.OnSendQueue(len(c.sendQueue))
 		return nil
 	default:
 		return fmt.Errorf("send queue is full")
@@ -157,6 +186,8 @@ func (c *TCPClient) SendWithDelimiter(data []byte, delimiter byte) error {
 
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
 
 	if _, err := c.writer.Write(data); err != nil {
 		c.handler.OnError(fmt.Errorf("failed to write data: %w", err))
@@ -242,14 +273,19 @@ func (c *TCPClient) Unsubscribe(requestType REQUEST_TYPE) error {
 }
 
 // writeLoop 写入循环（处理异步发送）
-func (c *TCPClient) writeLoop(ctx context.Context) {
-	defer c.wg.Done()
+func (c *TCPClient) writeLoop(ctx context.Context, wg *sync.WaitGroup, queue chan []byte) {
+	defer wg.Done()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case data := <-c.sendQueue:
+		case data := <-queue:
+			//select 是随机的, 连接已经断开就不再发
+			if ctx.Err() != nil {
+				return
+			}
+			c.observer.OnSendQueue(len(queue))
 			if err := c.Send(data); err != nil {
 				c.handler.OnError(fmt.Errorf("async send failed: %w", err))
 			}
@@ -257,58 +293,62 @@ func (c *TCPClient) writeLoop(ctx context.Context) {
 	}
 }
 
-// 其他方法保持不变...
+// Disconnect 断开连接并等待读写goroutine退出
 func (c *TCPClient) Disconnect() error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
+	// 断线后等待重连时也要取消, 否则Disconnect之后又连上了
+	c.stopReconnect()
 	if !c.isConnected.Load() {
+		c.mu.Unlock()
 		return nil
 	}
 
+	c.isConnected.Store(false)
 	// 取消所有goroutine
 	if c.cancel != nil {
 		c.cancel()
 	}
-
-	// 关闭发送队列
-	if c.sendQueue != nil {
-		close(c.sendQueue)
-	}
-
-	// 关闭连接
+	// 关闭连接, 阻塞在读上的 readLoop 会返回
 	if c.conn != nil {
 		c.conn.Close()
 	}
+	wg := c.wg
+	c.mu.Unlock()
 
-	// 等待所有goroutine退出
-	c.wg.Wait()
+	// 不能持有锁等待: 写/心跳goroutine可能正在 Send 里等读锁
+	if wg != nil {
+		wg.Wait()
+	}
 
-	c.isConnected.Store(false)
+	c.observer.OnConnState(false)
 	c.handler.OnDisconnected()
 	return nil
 }
 
-func (c *TCPClient) readLoop(ctx context.Context) {
-	defer c.wg.Done()
+func (c *TCPClient) readLoop(ctx context.Context, wg *sync.WaitGroup, reader *bufio.Reader) {
+	defer wg.Done()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		default:
-			data, err := utils.DecodeTCPMsgWithLePrefix(c.reader)
+			data, err := utils.DecodeTCPMsgWithLePrefix(reader)
 			//data, err := c.reader.ReadBytes('\n') // 以换行符作为分隔符
 			if err != nil {
+				//Disconnect 主动关闭的连接, 不用再走 handleDisconnect
+				if ctx.Err() != nil {
+					return
+				}
 				if err == io.EOF {
-					c.handleDisconnect()
+					c.handleDisconnect(ctx)
 					return
 				}
 				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
 					continue
 				}
 				c.handler.OnError(fmt.Errorf("read error: %w", err))
-				c.handleDisconnect()
+				c.handleDisconnect(ctx)
 				return
 			}
 
@@ -320,8 +360,8 @@ func (c *TCPClient) readLoop(ctx context.Context) {
 	}
 }
 
-func (c *TCPClient) heartbeatLoop(ctx context.Context) {
-	defer c.wg.Done()
+func (c *TCPClient) heartbeatLoop(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
 
 	if c.config.HeartbeatInterval <= 0 {
 		return
@@ -335,7 +375,7 @@ func (c *TCPClient) heartbeatLoop(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if c.isConnected.Load() {
+			if ctx.Err() == nil && c.isConnected.Load() {
 				// 发送心跳包
 				heartbeat := map[string]string{
 					"type": "heartbeat",
@@ -349,29 +389,61 @@ func (c *TCPClient) heartbeatLoop(ctx context.Context) {
 	}
 }
 
-func (c *TCPClient) handleDisconnect() {
+func (c *TCPClient) handleDisconnect(ctx context.Context) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if !c.isConnected.Load() {
+	//ctx已取消说明这个连接已经被 Disconnect 关掉, 现在的可能是重连后的新连接
+	if ctx.Err() != nil || !c.isConnected.Load() {
 		return
 	}
 
 	c.isConnected.Store(false)
+	// 让这个连接的写/心跳goroutine退出, 否则重连后 Disconnect 会一直等它们
+	if c.cancel != nil {
+		c.cancel()
+	}
 	if c.conn != nil {
 		c.conn.Close()
 	}
 
+	c.observer.OnConnState(false)
 	c.handler.OnDisconnected()
 
-	// 自动重连逻辑
-	if c.config.Reconnect && c.reconnectCount < c.config.MaxReconnects {
-		c.reconnectCount++
-		time.AfterFunc(c.config.ReconnectInterval, func() {
-			if err := c.Connect(); err != nil {
-				c.handler.OnError(fmt.Errorf("reconnect failed: %w", err))
-			}
-		})
+	c.scheduleReconnect()
+}
+
+// scheduleReconnect 调用方需要持有 c.mu; 重连失败会继续重试, 总共重连 MaxReconnects 次后放弃
+func (c *TCPClient) scheduleReconnect() {
+	if !c.config.Reconnect || int(c.reconnectCount.Load()) >= c.config.MaxReconnects {
+		return
+	}
+	c.reconnectCount.Add(1)
+	c.observer.OnReconnect()
+
+	var timer *time.Timer
+	timer = time.AfterFunc(c.config.ReconnectInterval, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		// 已经被 Connect/Disconnect 取消
+		if c.reconnectTimer != timer {
+			return
+		}
+		c.reconnectTimer = nil
+		if err := c.connect(); err != nil {
+			c.handler.OnError(fmt.Errorf("reconnect failed: %w", err))
+			c.scheduleReconnect()
+		}
+	})
+	c.reconnectTimer = timer
+}
+
+// stopReconnect 调用方需要持有 c.mu
+func (c *TCPClient) stopReconnect() {
+	if c.reconnectTimer != nil {
+		c.reconnectTimer.Stop()
+		c.reconnectTimer = nil
 	}
 }
 
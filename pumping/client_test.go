@@ -0,0 +1,245 @@
+package pumping_test
+
+import (
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"github.com/asaka1234/go-mt5-sdk/simulator"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newServer(t *testing.T) *simulator.PumpingServer {
+	t.Helper()
+	srv, err := simulator.NewPumpingServer("")
+	if err != nil {
+		t.Fatalf("NewPumpingServer: %v", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+// disconnectWithin Disconnect 没有在timeout内返回说明死锁了
+func disconnectWithin(t *testing.T, cli *pumping.TCPClient, timeout time.Duration) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- cli.Disconnect() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Disconnect: %v", err)
+		}
+	case <-time.After(timeout):
+		t.Fatal("Disconnect did not return")
+	}
+	if cli.IsConnected() {
+		t.Fatal("client is still connected after Disconnect")
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDisconnectReturns(t *testing.T) {
+	srv := newServer(t)
+	cli := pumping.NewTCPClient(&pumping.Config{ServerAddr: srv.Addr(), Timeout: time.Second, BufferSize: 4096}, nil)
+	if err := cli.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	waitFor(t, "server to accept", func() bool { return srv.Connections() == 1 })
+
+	disconnectWithin(t, cli, 2*time.Second)
+}
+
+func TestDisconnectAfterReconnect(t *testing.T) {
+	srv := newServer(t)
+	connected := make(chan struct{}, 4)
+	handler := &pumping.DefaultMessageHandler{OnConnectedFunc: func() { connected <- struct{}{} }}
+	cli := pumping.NewTCPClient(&pumping.Config{
+		ServerAddr:        srv.Addr(),
+		Timeout:           time.Second,
+		Reconnect:         true,
+		MaxReconnects:     3,
+		ReconnectInterval: 10 * time.Millisecond,
+		HeartbeatInterval: time.Hour, //心跳goroutine一直在跑, 重连后它也必须退出
+		BufferSize:        4096,
+	}, handler)
+	if err := cli.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	<-connected
+	waitFor(t, "server to accept", func() bool { return srv.Connections() == 1 })
+
+	//服务端断开, 客户端自动重连
+	srv.Disconnect()
+	select {
+	case <-connected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("client did not reconnect")
+	}
+
+	disconnectWithin(t, cli, 2*time.Second)
+}
+
+type reconnectObserver struct {
+	pumping.NopObserver
+	reconnects atomic.Int32
+}
+
+func (o *reconnectObserver) OnReconnect() { o.reconnects.Add(1) }
+
+func reconnectConfig(addr string, maxReconnects int, interval time.Duration) *pumping.Config {
+	return &pumping.Config{
+		ServerAddr:        addr,
+		Timeout:           time.Second,
+		Reconnect:         true,
+		MaxReconnects:     maxReconnects,
+		ReconnectInterval: interval,
+		BufferSize:        4096,
+	}
+}
+
+// 服务端一直连不上时, 连续重连 MaxReconnects 次后放弃
+func TestReconnectGivesUp(t *testing.T) {
+	srv := newServer(t)
+	cli := pumping.NewTCPClient(reconnectConfig(srv.Addr(), 3, 10*time.Millisecond), nil)
+	observer := &reconnectObserver{}
+	cli.SetObserver(observer)
+	if err := cli.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer cli.Disconnect()
+	waitFor(t, "server to accept", func() bool { return srv.Connections() == 1 })
+
+	srv.Close()
+	waitFor(t, "reconnects", func() bool { return observer.reconnects.Load() == 3 })
+	time.Sleep(100 * time.Millisecond)
+	if n := observer.reconnects.Load(); n != 3 {
+		t.Fatalf("%d reconnects, want 3", n)
+	}
+	if cli.IsConnected() {
+		t.Fatal("client is connected to a closed server")
+	}
+}
+
+// 重连成功也计数, 自动重连总共 MaxReconnects 次; 调用 Connect 后重新计数
+func TestReconnectCountsSuccessfulReconnects(t *testing.T) {
+	srv := newServer(t)
+	connected := make(chan struct{}, 8)
+	handler := &pumping.DefaultMessageHandler{OnConnectedFunc: func() { connected <- struct{}{} }}
+	cli := pumping.NewTCPClient(reconnectConfig(srv.Addr(), 2, 10*time.Millisecond), handler)
+	if err := cli.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer cli.Disconnect()
+	<-connected
+
+	for i := 0; i < 2; i++ {
+		waitFor(t, "server to accept", func() bool { return srv.Connections() == 1 })
+		srv.Disconnect()
+		select {
+		case <-connected:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("client did not reconnect (%d)", i+1)
+		}
+	}
+
+	waitFor(t, "server to accept", func() bool { return srv.Connections() == 1 })
+	srv.Disconnect()
+	waitFor(t, "client to notice the disconnect", func() bool { return !cli.IsConnected() })
+	time.Sleep(100 * time.Millisecond)
+	if cli.IsConnected() || srv.Connections() != 0 {
+		t.Fatal("client reconnected more than MaxReconnects times")
+	}
+
+	if err := cli.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	<-connected
+	waitFor(t, "server to accept", func() bool { return srv.Connections() == 1 })
+	srv.Disconnect()
+	select {
+	case <-connected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Connect did not reset the reconnect count")
+	}
+}
+
+// 等待重连时调用 Disconnect, 之后不能再连上
+func TestDisconnectCancelsReconnect(t *testing.T) {
+	srv := newServer(t)
+	cli := pumping.NewTCPClient(reconnectConfig(srv.Addr(), 3, 100*time.Millisecond), nil)
+	if err := cli.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	waitFor(t, "server to accept", func() bool { return srv.Connections() == 1 })
+
+	srv.Disconnect()
+	waitFor(t, "client to notice the disconnect", func() bool { return !cli.IsConnected() })
+	disconnectWithin(t, cli, time.Second)
+	time.Sleep(200 * time.Millisecond)
+	if cli.IsConnected() || srv.Connections() != 0 {
+		t.Fatal("client reconnected after Disconnect")
+	}
+}
+
+// 心跳, SendAsync, 服务端断开和 Disconnect 同时发生时不能死锁, 也不能 panic; 用 -race 跑
+func TestDisconnectConcurrent(t *testing.T) {
+	srv := newServer(t)
+	for i := 0; i < 20; i++ {
+		cli := pumping.NewTCPClient(&pumping.Config{
+			ServerAddr:        srv.Addr(),
+			Timeout:           time.Second,
+			Reconnect:         true,
+			MaxReconnects:     3,
+			ReconnectInterval: time.Millisecond,
+			HeartbeatInterval: time.Millisecond,
+			BufferSize:        4096,
+		}, nil)
+		cli.SetSendQueueSize(4)
+		if err := cli.Connect(); err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		waitFor(t, "server to accept", func() bool { return srv.Connections() == 1 })
+
+		stop := make(chan struct{})
+		var senders sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			senders.Add(1)
+			go func() {
+				defer senders.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						cli.SendAsync([]byte("{}\n"))
+					}
+				}
+			}()
+		}
+		time.Sleep(5 * time.Millisecond)
+		go srv.Disconnect()
+		disconnectWithin(t, cli, 2*time.Second)
+		close(stop)
+		senders.Wait()
+
+		//Disconnect 之后不能被等待中的重连再连上
+		time.Sleep(10 * time.Millisecond)
+		if cli.IsConnected() {
+			t.Fatal("client reconnected after Disconnect")
+		}
+		if err := cli.SendAsync([]byte("{}\n")); err == nil {
+			t.Fatal("SendAsync succeeded after Disconnect")
+		}
+		waitFor(t, "server to drop the connection", func() bool { return srv.Connections() == 0 })
+	}
+}
@@ -4,13 +4,13 @@ import "time"
 
 // Config TCP客户端配置
 type Config struct {
-	ServerAddr        string        // 服务器地址，格式：host:port
-	Timeout           time.Duration // 连接超时时间
-	Reconnect         bool          // 是否自动重连
-	MaxReconnects     int           // 最大重连次数
-	ReconnectInterval time.Duration // 重连间隔
-	HeartbeatInterval time.Duration // 心跳间隔
-	BufferSize        int           // 读写缓冲区大小
+	ServerAddr        string        `json:"server_addr" mapstructure:"server_addr" config:"server_addr" yaml:"server_addr"`                             // 服务器地址，格式：host:port
+	Timeout           time.Duration `json:"timeout" mapstructure:"timeout" config:"timeout" yaml:"timeout"`                                             // 连接超时时间
+	Reconnect         bool          `json:"reconnect" mapstructure:"reconnect" config:"reconnect" yaml:"reconnect"`                                     // 是否自动重连
+	MaxReconnects     int           `json:"max_reconnects" mapstructure:"max_reconnects" config:"max_reconnects" yaml:"max_reconnects"`                 // 最大重连次数
+	ReconnectInterval time.Duration `json:"reconnect_interval" mapstructure:"reconnect_interval" config:"reconnect_interval" yaml:"reconnect_interval"` // 重连间隔
+	HeartbeatInterval time.Duration `json:"heartbeat_interval" mapstructure:"heartbeat_interval" config:"heartbeat_interval" yaml:"heartbeat_interval"` // 心跳间隔
+	BufferSize        int           `json:"buffer_size" mapstructure:"buffer_size" config:"buffer_size" yaml:"buffer_size"`                             // 读写缓冲区大小
 }
 
 // DefaultConfig 返回默认配置
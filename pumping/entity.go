@@ -1,5 +1,7 @@
 package pumping
 
+import "github.com/asaka1234/go-mt5-sdk/utils"
+
 // JSON 消息结构
 type TCPRequest struct {
 	Type   string    `json:"type"`   // 请求类型
@@ -25,18 +27,18 @@ type TCPResponse struct {
 //----------------------------------------------------------------------
 
 type MT5MarginCall struct {
-	Login       uint64  `json:"login"  msgpack:"login"`
-	UID         uint64  `json:"uid"  msgpack:"uid"`
-	Equity      float64 `json:"equity"  msgpack:"equity"`             //净值
-	MarginLevel float64 `json:"margin_level"  msgpack:"margin_level"` //保证金率
+	Login       uint64      `json:"login"  msgpack:"login"`
+	UID         uint64      `json:"uid"  msgpack:"uid"`
+	Equity      utils.Money `json:"equity"  msgpack:"equity"`             //净值
+	MarginLevel float64     `json:"margin_level"  msgpack:"margin_level"` //保证金率
 }
 
 type MT5StopOut struct {
-	Login    uint64  `json:"login"  msgpack:"login"`
-	UID      uint64  `json:"uid"  msgpack:"uid"`
-	SOLevel  float64 `json:"so_level"  msgpack:"so_level"`
-	SOEquity float64 `json:"so_equity"  msgpack:"so_equity"`
-	SOMargin float64 `json:"so_margin"  msgpack:"so_margin"`
+	Login    uint64      `json:"login"  msgpack:"login"`
+	UID      uint64      `json:"uid"  msgpack:"uid"`
+	SOLevel  float64     `json:"so_level"  msgpack:"so_level"`
+	SOEquity utils.Money `json:"so_equity"  msgpack:"so_equity"`
+	SOMargin utils.Money `json:"so_margin"  msgpack:"so_margin"`
 }
 
 //----------------------------------------------------------------------
@@ -44,13 +46,28 @@ type MT5StopOut struct {
 type MT5Tick struct {
 	Symbol   string `json:"symbol"  msgpack:"symbol"`
 	Category string `json:"category"  msgpack:"category"` //分组(enum)
-	AskE8    int64  `json:"ask_e8" msgpack:"ask_e8"`      //都是扩大了 10e8倍
-	BidE8    int64  `json:"bid_e8" msgpack:"bid_e8"`      //都是扩大了 10e8倍
-	LastE8   int64  `json:"last_e8" msgpack:"last_e8"`    //都是扩大了 10e8倍
+	AskE8    int64  `json:"ask_e8" msgpack:"ask_e8"`      //扩大了1e8倍, 用 Ask()/Bid()/Last() 转成价格
+	BidE8    int64  `json:"bid_e8" msgpack:"bid_e8"`      //扩大了1e8倍, 用 Ask()/Bid()/Last() 转成价格
+	LastE8   int64  `json:"last_e8" msgpack:"last_e8"`    //扩大了1e8倍, 用 Ask()/Bid()/Last() 转成价格
 	Volume   uint64 `json:"volume" msgpack:"volume"`
 	Time     int64  `json:"time" msgpack:"time"` //unix时间戳(ms毫秒)
 }
 
+func (t MT5Tick) Ask() utils.Price  { return utils.PriceFromE8(t.AskE8) }
+func (t MT5Tick) Bid() utils.Price  { return utils.PriceFromE8(t.BidE8) }
+func (t MT5Tick) Last() utils.Price { return utils.PriceFromE8(t.LastE8) }
+
+// Spread 点差 ask-bid
+func (t MT5Tick) Spread() utils.Price { return utils.Spread(t.Ask(), t.Bid()) }
+
+// Mid 中间价 (ask+bid)/2
+func (t MT5Tick) Mid() utils.Price { return utils.Mid(t.Ask(), t.Bid()) }
+
+// SpreadPoints 点差的点数, digits为品种的 Digit
+func (t MT5Tick) SpreadPoints(digits uint) (int64, error) {
+	return utils.Points(t.Spread(), digits)
+}
+
 //-----------------------------------------------------------------------
 
 type MTOrderExtra struct {
@@ -59,20 +76,20 @@ type MTOrderExtra struct {
 }
 
 type MTOrder struct {
-	Login          uint64  `json:"login"  msgpack:"login"`
-	Ticket         uint64  `json:"ticket"  msgpack:"ticket"` //order_id
-	Symbol         string  `json:"symbol"  msgpack:"symbol"`
-	State          uint    `json:"state"  msgpack:"state"`                     //1是挂单  ORDER_STATE_PLACED, 其他是失败
-	ActivationMode uint    `json:"activation_mode"  msgpack:"activation_mode"` //激活模式  //0-none, 1=ACTIVATION_PENDING, 2=ACTIVATION_STOPLIMIT,3=ACTIVATION_EXPIRATION,4=ACTIVATION_STOPOUT
-	TimeSetup      int64   `json:"time_setup"  msgpack:"time_setup"`           //下单时间
-	Type           uint    `json:"type"  msgpack:"type"`                       //0-buy, 1-sell,2-buy limit ,3-sell limit, 4-buy stop, 5-sell stop, 6-buy stop limit, 7-sell stop limit,
-	PriceOrder     float64 `json:"price_order"  msgpack:"price_order"`         //下单价格 (stop/limit的价格)
-	PriceTrigger   float64 `json:"price_trigger"  msgpack:"price_trigger"`     //触发价格（stop limit 单）
-	PriceSL        float64 `json:"price_sl"  msgpack:"price_sl"`
-	PriceTP        float64 `json:"price_tp"  msgpack:"price_tp"`
-	Volume         float64 `json:"volume"  msgpack:"volume"` //lots
-	RateMargin     float64 `json:"rate_margin"  msgpack:"rate_margin"`
-	Comment        string  `json:"comment"  msgpack:"comment"`
+	Login          uint64                `json:"login"  msgpack:"login"`
+	Ticket         uint64                `json:"ticket"  msgpack:"ticket"` //order_id
+	Symbol         string                `json:"symbol"  msgpack:"symbol"`
+	State          utils.OrderState      `json:"state"  msgpack:"state"`                     //1是挂单  ORDER_STATE_PLACED, 其他是失败
+	ActivationMode utils.OrderActivation `json:"activation_mode"  msgpack:"activation_mode"` //激活模式  //0-none, 1=ACTIVATION_PENDING, 2=ACTIVATION_STOPLIMIT,3=ACTIVATION_EXPIRATION,4=ACTIVATION_STOPOUT
+	TimeSetup      int64                 `json:"time_setup"  msgpack:"time_setup"`           //下单时间
+	Type           utils.OrderType       `json:"type"  msgpack:"type"`                       //0-buy, 1-sell,2-buy limit ,3-sell limit, 4-buy stop, 5-sell stop, 6-buy stop limit, 7-sell stop limit,
+	PriceOrder     utils.Price           `json:"price_order"  msgpack:"price_order"`         //下单价格 (stop/limit的价格)
+	PriceTrigger   utils.Price           `json:"price_trigger"  msgpack:"price_trigger"`     //触发价格（stop limit 单）
+	PriceSL        utils.Price           `json:"price_sl"  msgpack:"price_sl"`
+	PriceTP        utils.Price           `json:"price_tp"  msgpack:"price_tp"`
+	Volume         utils.Volume          `json:"volume"  msgpack:"volume"` //lots
+	RateMargin     float64               `json:"rate_margin"  msgpack:"rate_margin"`
+	Comment        string                `json:"comment"  msgpack:"comment"`
 }
 
 //-----------------------------------------------------------------------
@@ -83,22 +100,22 @@ type MTPositionExtra struct {
 }
 
 type MTPosition struct {
-	Login          uint64  `json:"login"  msgpack:"login"`
-	Ticket         uint64  `json:"ticket"  msgpack:"ticket"` //position_id
-	Symbol         string  `json:"symbol"  msgpack:"symbol"`
-	Action         uint    `json:"action"  msgpack:"action"`         // 0-buy, 1-sell
-	PriceOpen      float64 `json:"price_open"  msgpack:"price_open"` //开仓价
-	PriceSL        float64 `json:"price_sl"  msgpack:"price_sl"`
-	PriceTP        float64 `json:"price_tp"  msgpack:"price_tp"`
-	RateMargin     float64 `json:"rate_margin"  msgpack:"rate_margin"`
-	RateProfit     float64 `json:"rate_profit"  msgpack:"rate_profit"`
-	Volume         float64 `json:"volume"  msgpack:"volume"` //lots
-	Profit         float64 `json:"profit"  msgpack:"profit"`
-	Storage        float64 `json:"storage"  msgpack:"storage"`
-	ActivationMode uint    `json:"activation_mode"  msgpack:"activation_mode"` //1-sl, 2-tp, 3-so
-	ActivationTime int64   `json:"activation_time"  msgpack:"activation_time"` //unix时间戳(s)
-	TimeCreate     int64   `json:"time_create"  msgpack:"time_create"`         //unix时间戳(s)
-	Comment        string  `json:"comment"  msgpack:"comment"`
+	Login          uint64                   `json:"login"  msgpack:"login"`
+	Ticket         uint64                   `json:"ticket"  msgpack:"ticket"` //position_id
+	Symbol         string                   `json:"symbol"  msgpack:"symbol"`
+	Action         utils.PositionAction     `json:"action"  msgpack:"action"`         // 0-buy, 1-sell
+	PriceOpen      utils.Price              `json:"price_open"  msgpack:"price_open"` //开仓价
+	PriceSL        utils.Price              `json:"price_sl"  msgpack:"price_sl"`
+	PriceTP        utils.Price              `json:"price_tp"  msgpack:"price_tp"`
+	RateMargin     float64                  `json:"rate_margin"  msgpack:"rate_margin"`
+	RateProfit     float64                  `json:"rate_profit"  msgpack:"rate_profit"`
+	Volume         utils.Volume             `json:"volume"  msgpack:"volume"` //lots
+	Profit         utils.Money              `json:"profit"  msgpack:"profit"`
+	Storage        utils.Money              `json:"storage"  msgpack:"storage"`
+	ActivationMode utils.PositionActivation `json:"activation_mode"  msgpack:"activation_mode"` //1-sl, 2-tp, 3-so
+	ActivationTime int64                    `json:"activation_time"  msgpack:"activation_time"` //unix时间戳(s)
+	TimeCreate     int64                    `json:"time_create"  msgpack:"time_create"`         //unix时间戳(s)
+	Comment        string                   `json:"comment"  msgpack:"comment"`
 }
 
 //-----------------------------------------------------------------------
@@ -109,24 +126,24 @@ type Mt5DealExtra struct {
 }
 
 type Mt5Deal struct {
-	DealId        uint64  `json:"deal_id"  msgpack:"deal_id"`
-	PositionId    uint64  `json:"position_id"  msgpack:"position_id"`
-	Symbol        string  `json:"symbol"  msgpack:"symbol"`
-	Login         uint64  `json:"login"  msgpack:"login"`
-	Volume        float64 `json:"volume"  msgpack:"volume"`
-	Entry         int     `json:"entry"  msgpack:"entry"`   //0-ENTRY_IN 开仓, 1-ENTRY_OUT 平仓
-	Action        int     `json:"action"  msgpack:"action"` //
-	Reason        uint    `json:"reason"  msgpack:"reason"` //发生的原因
-	Time          int64   `json:"time"  msgpack:"time"`
-	Price         float64 `json:"price"  msgpack:"price"`                   //执行价格
-	PricePosition float64 `json:"price_position"  msgpack:"price_position"` //持仓价格, 只有平仓时才有效
-	PriceSL       float64 `json:"price_sl"  msgpack:"price_sl"`
-	PriceTP       float64 `json:"price_tp"  msgpack:"price_tp"`
-	Profit        float64 `json:"profit"  msgpack:"profit"` //profit
-	RateMargin    float64 `json:"rate_margin"  msgpack:"rate_margin"`
-	RateProfit    float64 `json:"rate_profit"  msgpack:"rate_profit"`
-	Storage       float64 `json:"storage"  msgpack:"storage"` //swap
-	Comment       string  `json:"comment"  msgpack:"comment"`
+	DealId        uint64           `json:"deal_id"  msgpack:"deal_id"`
+	PositionId    uint64           `json:"position_id"  msgpack:"position_id"`
+	Symbol        string           `json:"symbol"  msgpack:"symbol"`
+	Login         uint64           `json:"login"  msgpack:"login"`
+	Volume        utils.Volume     `json:"volume"  msgpack:"volume"`
+	Entry         utils.DealEntry  `json:"entry"  msgpack:"entry"`   //0-ENTRY_IN 开仓, 1-ENTRY_OUT 平仓
+	Action        utils.DealAction `json:"action"  msgpack:"action"` //
+	Reason        utils.DealReason `json:"reason"  msgpack:"reason"` //发生的原因
+	Time          int64            `json:"time"  msgpack:"time"`
+	Price         utils.Price      `json:"price"  msgpack:"price"`                   //执行价格
+	PricePosition utils.Price      `json:"price_position"  msgpack:"price_position"` //持仓价格, 只有平仓时才有效
+	PriceSL       utils.Price      `json:"price_sl"  msgpack:"price_sl"`
+	PriceTP       utils.Price      `json:"price_tp"  msgpack:"price_tp"`
+	Profit        utils.Money      `json:"profit"  msgpack:"profit"` //profit
+	RateMargin    float64          `json:"rate_margin"  msgpack:"rate_margin"`
+	RateProfit    float64          `json:"rate_profit"  msgpack:"rate_profit"`
+	Storage       utils.Money      `json:"storage"  msgpack:"storage"` //swap
+	Comment       string           `json:"comment"  msgpack:"comment"`
 }
 
 //-----------------------------------------------------------------------
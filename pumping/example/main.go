@@ -44,11 +44,11 @@ func main() {
 				for i, item := range tickItems {
 					tickTime := time.Unix(item.Time, 0)
 
-					fmt.Printf("  [%d] %s - Ask: %d, Bid: %d, Time: %s\n",
+					fmt.Printf("  [%d] %s - Ask: %s, Bid: %s, Time: %s\n",
 						i+1,
 						item.Symbol,
-						item.AskE8,
-						item.BidE8,
+						item.Ask(),
+						item.Bid(),
 						tickTime.Format("15:04:05"))
 				}
 				return nil
@@ -83,6 +83,16 @@ func (h *SubscriptionMessageHandler) SetDefaultHandler(handler func(response *TC
 	h.subscriptionManager.SetDefaultHandler(handler)
 }
 
+// SetDispatchHook 设置消息分发的钩子
+func (h *SubscriptionMessageHandler) SetDispatchHook(hook DispatchHook) {
+	h.subscriptionManager.SetDispatchHook(hook)
+}
+
+// SetObserver 设置监控回调
+func (h *SubscriptionMessageHandler) SetObserver(observer Observer) {
+	h.subscriptionManager.SetObserver(observer)
+}
+
 // GetSubscriptionManager 获取订阅管理器
 func (h *SubscriptionMessageHandler) GetSubscriptionManager() *SubscriptionManager {
 	return h.subscriptionManager
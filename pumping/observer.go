@@ -0,0 +1,19 @@
+package pumping
+
+// Observer 监控连接和消息处理的回调, 用于metrics等; 所有方法都需要是并发安全的, 并且不能阻塞
+type Observer interface {
+	OnConnState(connected bool)               // 连接建立/断开
+	OnReconnect()                             // 开始一次自动重连
+	OnSendQueue(depth int)                    // 异步发送队列的长度发生变化
+	OnFrame(response *TCPResponse, bytes int) // 成功解码了一条消息, 可以通过 response.Timestamp 计算延迟
+	OnDecodeError(bytes int, err error)       // 消息解码失败
+}
+
+// NopObserver 什么都不做, 可以嵌入到自己的Observer里, 只实现关心的方法
+type NopObserver struct{}
+
+func (NopObserver) OnConnState(connected bool)               {}
+func (NopObserver) OnReconnect()                             {}
+func (NopObserver) OnSendQueue(depth int)                    {}
+func (NopObserver) OnFrame(response *TCPResponse, bytes int) {}
+func (NopObserver) OnDecodeError(bytes int, err error)       {}
@@ -19,12 +19,18 @@ type TypedResponseHandler struct {
 	Handler     func(response *TCPResponse, payload interface{}) error
 }
 
+// DispatchHook 包装每条消息的分发, dispatch 会调用实际注册的处理器
+// 可以在前后加入链路追踪/监控等逻辑, 返回值作为 HandleMessage 的结果
+type DispatchHook func(response *TCPResponse, dispatch func() error) error
+
 // SubscriptionManager 订阅管理器
 type SubscriptionManager struct {
 	mu             sync.RWMutex
 	handlers       map[REQUEST_TYPE]ResponseHandler
 	typedHandlers  map[REQUEST_TYPE]TypedResponseHandler
 	defaultHandler func(response *TCPResponse) error
+	dispatchHook   DispatchHook
+	observer       Observer
 }
 
 // NewSubscriptionManager 创建订阅管理器
@@ -32,6 +38,7 @@ func NewSubscriptionManager() *SubscriptionManager {
 	return &SubscriptionManager{
 		handlers:      make(map[REQUEST_TYPE]ResponseHandler),
 		typedHandlers: make(map[REQUEST_TYPE]TypedResponseHandler),
+		observer:      NopObserver{},
 	}
 }
 
@@ -72,14 +79,37 @@ func (sm *SubscriptionManager) SetDefaultHandler(handler func(response *TCPRespo
 	sm.defaultHandler = handler
 }
 
+// SetDispatchHook 设置消息分发的钩子, nil则取消
+func (sm *SubscriptionManager) SetDispatchHook(hook DispatchHook) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.dispatchHook = hook
+}
+
+// SetObserver 设置监控回调(收到的消息/解码失败)
+func (sm *SubscriptionManager) SetObserver(observer Observer) {
+	if observer == nil {
+		observer = NopObserver{}
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.observer = observer
+}
+
 // HandleMessage 处理消息
 func (sm *SubscriptionManager) HandleMessage(data []byte) error {
 	// 解析基础响应
 	var response TCPResponse
 	err := Decode[TCPResponse](data, &response)
+
+	sm.mu.RLock()
+	observer := sm.observer
+	sm.mu.RUnlock()
 	if err != nil {
+		observer.OnDecodeError(len(data), err)
 		return err
 	}
+	observer.OnFrame(&response, len(data))
 
 	// 检查状态
 	if response.Status != "ok" {
@@ -89,19 +119,29 @@ func (sm *SubscriptionManager) HandleMessage(data []byte) error {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 
+	if sm.dispatchHook != nil {
+		return sm.dispatchHook(&response, func() error {
+			return sm.dispatch(&response)
+		})
+	}
+	return sm.dispatch(&response)
+}
+
+// dispatch 把消息交给注册的处理器, 调用方需要持有读锁
+func (sm *SubscriptionManager) dispatch(response *TCPResponse) error {
 	// 查找类型化处理器
 	if handler, exists := sm.typedHandlers[REQUEST_TYPE(response.Type)]; exists {
-		return sm.handleTypedResponse(&response, handler)
+		return sm.handleTypedResponse(response, handler)
 	}
 
 	// 查找基础处理器
 	if handler, exists := sm.handlers[REQUEST_TYPE(response.Type)]; exists {
-		return handler.Handler(&response)
+		return handler.Handler(response)
 	}
 
 	// 使用默认处理器
 	if sm.defaultHandler != nil {
-		return sm.defaultHandler(&response)
+		return sm.defaultHandler(response)
 	}
 
 	return fmt.Errorf("no handler registered for request type: %s", response.Type)
@@ -0,0 +1,5 @@
+// Package simulator 不依赖真实MT5服务的测试替身
+//
+//   - Gateway: 实现了所有 /v1 REST接口的网关, 内存里维护账户/持仓/挂单, 错误码和真实网关一致, 配合 httptest 使用
+//   - PumpingServer: 本地的pumping TCP服务端, 可以按脚本推送tick/挂单/持仓/成交/追保/强平消息, 以及断线和坏消息
+package simulator
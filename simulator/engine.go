@@ -0,0 +1,908 @@
+package simulator
+
+import (
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/direct"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"github.com/shopspring/decimal"
+	"sort"
+	"time"
+)
+
+// 编号的起始值
+const (
+	firstLogin      uint64 = 100000
+	firstTicket     uint64 = 500000
+	firstDeal       uint64 = 800000
+	defaultLeverage uint   = 500
+)
+
+// reject 网关拒绝请求时返回的错误码和信息
+type reject struct {
+	code    utils.Retcode
+	message string
+}
+
+func (r *reject) Error() string {
+	return fmt.Sprintf("%s: %s", r.code, r.message)
+}
+
+func rejectf(code utils.Retcode, format string, args ...interface{}) *reject {
+	return &reject{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+type symbol struct {
+	base       direct.MT5SymbolBase
+	contract   decimal.Decimal //合约量
+	volumeMin  utils.Volume
+	volumeMax  utils.Volume
+	volumeStep utils.Volume
+	bid        utils.Price
+	ask        utils.Price
+	time       time.Time
+}
+
+type account struct {
+	user     direct.Mt5User
+	uid      int64
+	internal uint
+	leverage uint
+	balance  utils.Money
+}
+
+// position sl/tp为0表示没有设置
+type position struct {
+	login      uint64
+	ticket     uint64
+	symbol     string
+	action     utils.PositionAction
+	priceOpen  utils.Price
+	sl         utils.Price
+	tp         utils.Price
+	volume     utils.Volume
+	timeCreate time.Time
+	comment    string
+}
+
+type pendingOrder struct {
+	login      uint64
+	ticket     uint64
+	symbol     string
+	typ        utils.OrderType
+	price      utils.Price //挂单价格, stop limit单是触发价
+	trigger    utils.Price //stop limit单触发后limit单的价格
+	sl         utils.Price
+	tp         utils.Price
+	volume     utils.Volume
+	expireType utils.OrderTime
+	expireTime int64
+	timeSetup  time.Time
+	comment    string
+}
+
+// sameSettings 修改挂单时比较价格和过期时间是否有变化
+func (o *pendingOrder) sameSettings(other *pendingOrder) bool {
+	return o.expireType == other.expireType && o.expireTime == other.expireTime &&
+		o.price.Decimal().Equal(other.price.Decimal()) && o.trigger.Decimal().Equal(other.trigger.Decimal()) &&
+		o.sl.Decimal().Equal(other.sl.Decimal()) && o.tp.Decimal().Equal(other.tp.Decimal())
+}
+
+// DefaultSymbols NewGateway 默认加载的品种和报价
+func DefaultSymbols() []Quote {
+	return []Quote{
+		{Symbol: forexSymbol("EURUSD", "EUR", "USD"), Bid: utils.MustParsePrice("1.08500"), Ask: utils.MustParsePrice("1.08520")},
+		{Symbol: forexSymbol("GBPUSD", "GBP", "USD"), Bid: utils.MustParsePrice("1.26500"), Ask: utils.MustParsePrice("1.26520")},
+		{Symbol: direct.MT5SymbolBase{
+			Symbol: "XAUUSD", Digit: 2, Desc: "Gold vs US Dollar", Category: "Metals",
+			CurrencyBase: "XAU", CurrencyBaseDigit: 2, CurrencyProfit: "USD", CurrencyProfitDigit: 2, CurrencyMargin: "USD", CurrencyMarginDigit: 2,
+			ContractSize: "100", CalcMode: utils.CalcModeCFD, TradeMode: utils.TradeModeFull, VolumeMin: utils.MustParseVolume("0.01"), VolumeMax: utils.MustParseVolume("50"), VolumeStep: utils.MustParseVolume("0.01"),
+			StopsLevel: 10, SessionTrade: allWeek(), SessionQuote: allWeek(),
+		}, Bid: utils.MustParsePrice("2350.00"), Ask: utils.MustParsePrice("2350.30")},
+	}
+}
+
+// Quote 品种和它的初始报价
+type Quote struct {
+	Symbol direct.MT5SymbolBase
+	Bid    utils.Price
+	Ask    utils.Price
+}
+
+func forexSymbol(name, base, profit string) direct.MT5SymbolBase {
+	return direct.MT5SymbolBase{
+		Symbol: name, Digit: 5, Desc: base + " vs " + profit, Category: "Forex",
+		CurrencyBase: base, CurrencyBaseDigit: 2, CurrencyProfit: profit, CurrencyProfitDigit: 2, CurrencyMargin: base, CurrencyMarginDigit: 2,
+		ContractSize: "100000", CalcMode: utils.CalcModeForex, TradeMode: utils.TradeModeFull, VolumeMin: utils.MustParseVolume("0.01"), VolumeMax: utils.MustParseVolume("100"), VolumeStep: utils.MustParseVolume("0.01"),
+		StopsLevel: 10, SessionTrade: allWeek(), SessionQuote: allWeek(),
+	}
+}
+
+func allWeek() []direct.SessionInfo {
+	sessions := make([]direct.SessionInfo, 7)
+	for i := range sessions {
+		sessions[i] = direct.SessionInfo{Wday: uint(i), Sessions: []string{"00:00-24:00"}}
+	}
+	return sessions
+}
+
+func newSymbol(base direct.MT5SymbolBase, bid, ask utils.Price) (*symbol, error) {
+	if base.Symbol == "" {
+		return nil, fmt.Errorf("symbol name is empty")
+	}
+	contract, err := decimal.NewFromString(base.ContractSize)
+	if err != nil || !contract.IsPositive() {
+		return nil, fmt.Errorf("symbol %s: invalid contract_size %q", base.Symbol, base.ContractSize)
+	}
+	for _, f := range []struct {
+		name  string
+		value utils.Volume
+	}{
+		{"volume_min", base.VolumeMin},
+		{"volume_max", base.VolumeMax},
+		{"volume_step", base.VolumeStep},
+	} {
+		if !f.value.Decimal().IsPositive() {
+			return nil, fmt.Errorf("symbol %s: invalid %s %q", base.Symbol, f.name, f.value)
+		}
+	}
+	s := &symbol{
+		base:       base,
+		contract:   contract,
+		volumeMin:  base.VolumeMin,
+		volumeMax:  base.VolumeMax,
+		volumeStep: base.VolumeStep,
+	}
+	if err := s.setQuote(bid, ask); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *symbol) setQuote(bid, ask utils.Price) error {
+	if bid.Decimal().IsNegative() || ask.Decimal().LessThan(bid.Decimal()) {
+		return fmt.Errorf("symbol %s: invalid quote bid=%s ask=%s", s.base.Symbol, bid, ask)
+	}
+	s.bid, s.ask = utils.NewPrice(bid.Decimal()), utils.NewPrice(ask.Decimal())
+	return nil
+}
+
+// minDistance sl/tp/挂单价格和参考价的最小距离: StopsLevel个点
+func (s *symbol) minDistance() decimal.Decimal {
+	return decimal.New(int64(s.base.StopsLevel), -int32(s.base.Digit))
+}
+
+func (s *symbol) formatPrice(price utils.Price) string {
+	return price.Format(s.base.Digit)
+}
+
+// price 按品种精度四舍五入, 没有设置时为0
+func (s *symbol) price(price utils.Price) utils.Price {
+	return utils.NewPrice(price.Decimal().Round(int32(s.base.Digit)))
+}
+
+// checkVolume 手数必须在 [min, max] 之间并且是step的整数倍
+func (s *symbol) checkVolume(lots utils.Volume) (utils.Volume, error) {
+	volume := lots.Decimal()
+	if !lots.IsSet() || !volume.IsPositive() {
+		return utils.Volume{}, rejectf(utils.RetcodeInvalidVolume, "invalid lots %q", lots)
+	}
+	if volume.LessThan(s.volumeMin.Decimal()) || volume.GreaterThan(s.volumeMax.Decimal()) {
+		return utils.Volume{}, rejectf(utils.RetcodeInvalidVolume, "lots %s out of range [%s, %s]", lots, s.volumeMin, s.volumeMax)
+	}
+	if !volume.Mod(s.volumeStep.Decimal()).IsZero() {
+		return utils.Volume{}, rejectf(utils.RetcodeInvalidVolume, "lots %s is not a multiple of %s", lots, s.volumeStep)
+	}
+	return utils.NewVolume(volume), nil
+}
+
+// checkTradeMode 开仓/挂单时检查品种的交易模式
+func (s *symbol) checkTradeMode(buy bool) error {
+	if !s.bid.Decimal().IsPositive() || !s.ask.Decimal().IsPositive() {
+		return rejectf(utils.RetcodePriceOff, "no quotes for %s", s.base.Symbol)
+	}
+	switch s.base.TradeMode {
+	case utils.TradeModeDisabled:
+		return rejectf(utils.RetcodeTradeDisabled, "trade is disabled for %s", s.base.Symbol)
+	case utils.TradeModeLongOnly:
+		if !buy {
+			return rejectf(utils.RetcodeLongOnly, "%s is long only", s.base.Symbol)
+		}
+	case utils.TradeModeShortOnly:
+		if buy {
+			return rejectf(utils.RetcodeShortOnly, "%s is short only", s.base.Symbol)
+		}
+	case utils.TradeModeCloseOnly:
+		return rejectf(utils.RetcodeCloseOnly, "%s is close only", s.base.Symbol)
+	}
+	return nil
+}
+
+// checkStops buy的sl必须低于参考价, tp必须高于参考价, sell相反, 距离不能小于 StopsLevel
+func (s *symbol) checkStops(buy bool, ref, sl, tp utils.Price) error {
+	minDistance := s.minDistance()
+	if sl.Decimal().IsPositive() {
+		distance := ref.Decimal().Sub(sl.Decimal())
+		if !buy {
+			distance = distance.Neg()
+		}
+		if distance.LessThan(minDistance) {
+			return rejectf(utils.RetcodeInvalidStops, "invalid sl %s for price %s", s.formatPrice(sl), s.formatPrice(ref))
+		}
+	}
+	if tp.Decimal().IsPositive() {
+		distance := tp.Decimal().Sub(ref.Decimal())
+		if !buy {
+			distance = distance.Neg()
+		}
+		if distance.LessThan(minDistance) {
+			return rejectf(utils.RetcodeInvalidStops, "invalid tp %s for price %s", s.formatPrice(tp), s.formatPrice(ref))
+		}
+	}
+	return nil
+}
+
+// parsePrice 没有设置则为0
+func parsePrice(field string, value utils.Price) (utils.Price, error) {
+	if value.Decimal().IsNegative() {
+		code := utils.RetcodeInvalidPrice
+		if field == "sl" || field == "tp" {
+			code = utils.RetcodeInvalidStops
+		}
+		return utils.Price{}, rejectf(code, "invalid %s %q", field, value)
+	}
+	return utils.NewPrice(value.Decimal()), nil
+}
+
+// money 金额保留2位小数
+func money(d decimal.Decimal) utils.Money {
+	return utils.NewMoney(d.Round(2))
+}
+
+func formatMoney(m utils.Money) string {
+	return m.Decimal().StringFixed(2)
+}
+
+//------------------------------------------------------------------------
+// 以下方法都需要在持有 g.mu 的情况下调用
+
+func (g *Gateway) symbol(name string) (*symbol, error) {
+	s, ok := g.symbols[name]
+	if !ok {
+		return nil, rejectf(utils.RetcodeInvalidRequest, "unknown symbol %q", name)
+	}
+	return s, nil
+}
+
+func (g *Gateway) account(login uint64) (*account, error) {
+	a, ok := g.accounts[login]
+	if !ok {
+		return nil, rejectf(utils.RetcodeNotFound, "login %d not found", login)
+	}
+	return a, nil
+}
+
+func (g *Gateway) position(ticket uint64) (*position, error) {
+	p, ok := g.positions[ticket]
+	if !ok {
+		return nil, rejectf(utils.RetcodeNotFound, "position %d not found", ticket)
+	}
+	return p, nil
+}
+
+func (g *Gateway) pendingOrder(ticket uint64) (*pendingOrder, error) {
+	o, ok := g.orders[ticket]
+	if !ok {
+		return nil, rejectf(utils.RetcodeNotFound, "order %d not found", ticket)
+	}
+	return o, nil
+}
+
+func (g *Gateway) newTicket() uint64 {
+	g.nextTicket++
+	return g.nextTicket
+}
+
+func (g *Gateway) newDeal() uint64 {
+	g.nextDeal++
+	return g.nextDeal
+}
+
+// profit 按当前报价计算浮动盈亏, buy按bid平仓, sell按ask平仓
+func (g *Gateway) profit(p *position, volume utils.Volume) decimal.Decimal {
+	s := g.symbols[p.symbol]
+	diff := s.bid.Decimal().Sub(p.priceOpen.Decimal())
+	if !p.action.IsBuy() {
+		diff = p.priceOpen.Decimal().Sub(s.ask.Decimal())
+	}
+	return diff.Mul(volume.Decimal()).Mul(s.contract)
+}
+
+// margin 简化的保证金计算: 手数 * 合约量 * 价格 / 杠杆
+func (g *Gateway) margin(a *account, symbolName string, volume utils.Volume, price utils.Price) decimal.Decimal {
+	return volume.Decimal().Mul(g.symbols[symbolName].contract).Mul(price.Decimal()).Div(decimal.NewFromInt(int64(a.leverage)))
+}
+
+// funds 账户当前的 已用保证金/浮动盈亏
+func (g *Gateway) funds(a *account) (margin, floating decimal.Decimal) {
+	for _, p := range g.positions {
+		if p.login != a.user.Login {
+			continue
+		}
+		margin = margin.Add(g.margin(a, p.symbol, p.volume, p.priceOpen))
+		floating = floating.Add(g.profit(p, p.volume))
+	}
+	return margin, floating
+}
+
+// freeMargin 可用保证金: 余额 + 浮动盈亏 - 已用保证金
+func (g *Gateway) freeMargin(a *account) decimal.Decimal {
+	margin, floating := g.funds(a)
+	return a.balance.Decimal().Add(floating).Sub(margin)
+}
+
+func (g *Gateway) accountDetail(a *account) direct.MTUserAccount {
+	margin, floating := g.funds(a)
+	equity := a.balance.Decimal().Add(floating)
+
+	level := decimal.Zero
+	if margin.IsPositive() {
+		level = equity.Div(margin).Mul(decimal.NewFromInt(100))
+	}
+	return direct.MTUserAccount{
+		Login:          a.user.Login,
+		Balance:        money(a.balance.Decimal()),
+		Margin:         money(margin),
+		MarginFree:     money(equity.Sub(margin)),
+		MarginLevel:    level.StringFixed(2),
+		MarginLeverage: a.leverage,
+		Equity:         money(equity),
+		Storage:        money(decimal.Zero),
+		Floating:       money(floating),
+	}
+}
+
+func (g *Gateway) positionInfo(p *position) *direct.MTPosition {
+	s := g.symbols[p.symbol]
+	return &direct.MTPosition{
+		Login:      p.login,
+		Ticket:     p.ticket,
+		Symbol:     p.symbol,
+		Action:     p.action,
+		PriceOpen:  s.price(p.priceOpen),
+		PriceSL:    s.price(p.sl),
+		PriceTP:    s.price(p.tp),
+		RateMargin: 1,
+		RateProfit: 1,
+		Volume:     p.volume,
+		Profit:     money(g.profit(p, p.volume)),
+		Storage:    money(decimal.Zero),
+		TimeCreate: p.timeCreate.Unix(),
+		Comment:    p.comment,
+	}
+}
+
+func (g *Gateway) orderInfo(o *pendingOrder) *direct.MTOrder {
+	s := g.symbols[o.symbol]
+	return &direct.MTOrder{
+		Login:        o.login,
+		Ticket:       o.ticket,
+		Symbol:       o.symbol,
+		State:        1,
+		TimeSetup:    o.timeSetup.Unix(),
+		Type:         o.typ,
+		PriceOrder:   s.price(o.price),
+		PriceTrigger: s.price(o.trigger),
+		PriceSL:      s.price(o.sl),
+		PriceTP:      s.price(o.tp),
+		Volume:       o.volume,
+		RateMargin:   1,
+		Comment:      o.comment,
+	}
+}
+
+// loginPositions 按ticket排序, 保证返回顺序稳定
+func (g *Gateway) loginPositions(login uint64) []*position {
+	var list []*position
+	for _, p := range g.positions {
+		if p.login == login {
+			list = append(list, p)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ticket < list[j].ticket })
+	return list
+}
+
+func (g *Gateway) loginOrders(login uint64) []*pendingOrder {
+	var list []*pendingOrder
+	for _, o := range g.orders {
+		if o.login == login {
+			list = append(list, o)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ticket < list[j].ticket })
+	return list
+}
+
+//------------------------------------------------------------------------
+
+// createUser 开户, 初始余额为0
+func (g *Gateway) createUser(req direct.UserCreateReq) direct.Mt5User {
+	leverage := req.Leverage
+	if leverage == 0 {
+		leverage = defaultLeverage
+	}
+	g.nextLogin++
+	a := &account{
+		user: direct.Mt5User{
+			Login:        g.nextLogin,
+			MasterPass:   fmt.Sprintf("M%dpass", g.nextLogin),
+			InvestorPass: fmt.Sprintf("I%dpass", g.nextLogin),
+		},
+		uid:      req.Uid,
+		internal: req.Internal,
+		leverage: leverage,
+	}
+	g.accounts[a.user.Login] = a
+	return a.user
+}
+
+// balanceOperation 出入金, 出金不能超过可用保证金
+func (g *Gateway) balanceOperation(req direct.BalanceOperationReq) (uint64, error) {
+	amount := req.Balance.Decimal()
+	if amount.IsZero() {
+		return 0, rejectf(utils.RetcodeInvalidParams, "invalid balance %q", req.Balance)
+	}
+	a, err := g.account(req.Login)
+	if err != nil {
+		return 0, err
+	}
+	if amount.IsNegative() {
+		if free := g.freeMargin(a); free.Add(amount).IsNegative() {
+			return 0, rejectf(utils.RetcodeNoMoney, "not enough money: free margin %s", free.StringFixed(2))
+		}
+	}
+	a.balance = utils.NewMoney(a.balance.Decimal().Add(amount))
+	return g.newDeal(), nil
+}
+
+// openPosition 市价开仓, buy按ask成交, sell按bid成交
+func (g *Gateway) openPosition(login uint64, symbolName string, typ utils.OrderType, lots utils.Volume, sl, tp utils.Price, comment string) (*position, uint64, error) {
+	a, err := g.account(login)
+	if err != nil {
+		return nil, 0, err
+	}
+	s, err := g.symbol(symbolName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !typ.IsMarket() {
+		return nil, 0, rejectf(utils.RetcodeInvalidRequest, "invalid position type %d", typ)
+	}
+	buy := typ.IsBuy()
+	if err := s.checkTradeMode(buy); err != nil {
+		return nil, 0, err
+	}
+	volume, err := s.checkVolume(lots)
+	if err != nil {
+		return nil, 0, err
+	}
+	slPrice, err := parsePrice("sl", sl)
+	if err != nil {
+		return nil, 0, err
+	}
+	tpPrice, err := parsePrice("tp", tp)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	price, ref := s.ask, s.bid
+	if !buy {
+		price, ref = s.bid, s.ask
+	}
+	if err := s.checkStops(buy, ref, slPrice, tpPrice); err != nil {
+		return nil, 0, err
+	}
+	p, deal, err := g.fill(a, s, typ, volume, price, comment)
+	if err != nil {
+		return nil, 0, err
+	}
+	p.sl, p.tp = slPrice, tpPrice
+	return p, deal, nil
+}
+
+// fill 检查保证金并生成持仓, 持仓id和开仓的订单号相同
+func (g *Gateway) fill(a *account, s *symbol, typ utils.OrderType, volume utils.Volume, price utils.Price, comment string) (*position, uint64, error) {
+	free := g.freeMargin(a)
+	if required := g.margin(a, s.base.Symbol, volume, price); required.GreaterThan(free) {
+		return nil, 0, rejectf(utils.RetcodeNoMoney, "not enough money: required margin %s, free margin %s", required.StringFixed(2), free.StringFixed(2))
+	}
+
+	action := utils.PositionActionBuy
+	if !typ.IsBuy() {
+		action = utils.PositionActionSell
+	}
+	p := &position{
+		login:      a.user.Login,
+		ticket:     g.newTicket(),
+		symbol:     s.base.Symbol,
+		action:     action,
+		priceOpen:  price,
+		volume:     volume,
+		timeCreate: g.now(),
+		comment:    comment,
+	}
+	g.positions[p.ticket] = p
+	return p, g.newDeal(), nil
+}
+
+func (g *Gateway) modifyPosition(ticket uint64, sl, tp utils.Price) (*position, error) {
+	p, err := g.position(ticket)
+	if err != nil {
+		return nil, err
+	}
+	slPrice, err := parsePrice("sl", sl)
+	if err != nil {
+		return nil, err
+	}
+	tpPrice, err := parsePrice("tp", tp)
+	if err != nil {
+		return nil, err
+	}
+	if slPrice.Decimal().Equal(p.sl.Decimal()) && tpPrice.Decimal().Equal(p.tp.Decimal()) {
+		return nil, rejectf(utils.RetcodeNoChanges, "sl/tp of position %d are not changed", ticket)
+	}
+
+	s := g.symbols[p.symbol]
+	buy := p.action.IsBuy()
+	ref := s.bid
+	if !buy {
+		ref = s.ask
+	}
+	if err := s.checkStops(buy, ref, slPrice, tpPrice); err != nil {
+		return nil, err
+	}
+	p.sl, p.tp = slPrice, tpPrice
+	return p, nil
+}
+
+// closePosition lots没有设置则全部平仓, 盈亏计入余额
+func (g *Gateway) closePosition(ticket uint64, lots utils.Volume) (closed utils.Volume, price utils.Price, profit utils.Money, deal uint64, err error) {
+	p, err := g.position(ticket)
+	if err != nil {
+		return closed, price, profit, 0, err
+	}
+	s := g.symbols[p.symbol]
+
+	closed = p.volume
+	if lots.IsSet() {
+		if closed, err = s.checkVolume(lots); err != nil {
+			return closed, price, profit, 0, err
+		}
+		if closed.Decimal().GreaterThan(p.volume.Decimal()) {
+			return closed, price, profit, 0, rejectf(utils.RetcodeInvalidCloseVolume, "lots %s exceeds position volume %s", closed, p.volume)
+		}
+	}
+
+	price = s.bid
+	if p.action.IsSell() {
+		price = s.ask
+	}
+	//盈亏按2位小数计入余额
+	profit = money(g.profit(p, closed))
+	a := g.accounts[p.login]
+	a.balance = utils.NewMoney(a.balance.Decimal().Add(profit.Decimal()))
+
+	if remaining := p.volume.Decimal().Sub(closed.Decimal()); remaining.IsPositive() {
+		p.volume = utils.NewVolume(remaining)
+	} else {
+		delete(g.positions, ticket)
+	}
+	return closed, price, profit, g.newDeal(), nil
+}
+
+func (g *Gateway) closeAllPositions(login uint64) ([]uint64, error) {
+	if _, err := g.account(login); err != nil {
+		return nil, err
+	}
+	var tickets []uint64
+	for _, p := range g.loginPositions(login) {
+		if _, _, _, _, err := g.closePosition(p.ticket, utils.Volume{}); err != nil {
+			return tickets, err
+		}
+		tickets = append(tickets, p.ticket)
+	}
+	return tickets, nil
+}
+
+// checkPendingPrices 挂单价格检查
+//
+//   - buy limit < ask, sell limit > bid
+//   - buy stop > ask, sell stop < bid
+//   - stop limit: price按stop单检查, trigger(limit价格)buy必须低于price, sell必须高于price
+func (g *Gateway) checkPendingPrices(s *symbol, typ utils.OrderType, price, trigger, sl, tp utils.Price) error {
+	if !price.Decimal().IsPositive() {
+		return rejectf(utils.RetcodeInvalidPrice, "price is required")
+	}
+	minDistance := s.minDistance()
+	p, bid, ask := price.Decimal(), s.bid.Decimal(), s.ask.Decimal()
+
+	var valid bool
+	switch typ {
+	case utils.OrderTypeBuyLimit:
+		valid = ask.Sub(p).GreaterThanOrEqual(minDistance)
+	case utils.OrderTypeSellLimit:
+		valid = p.Sub(bid).GreaterThanOrEqual(minDistance)
+	case utils.OrderTypeBuyStop, utils.OrderTypeBuyStopLimit:
+		valid = p.Sub(ask).GreaterThanOrEqual(minDistance)
+	case utils.OrderTypeSellStop, utils.OrderTypeSellStopLimit:
+		valid = bid.Sub(p).GreaterThanOrEqual(minDistance)
+	}
+	if !valid {
+		return rejectf(utils.RetcodeInvalidPrice, "invalid price %s for order type %d, bid %s ask %s",
+			s.formatPrice(price), typ, s.formatPrice(s.bid), s.formatPrice(s.ask))
+	}
+
+	//成交价, sl/tp以它为参考
+	fill := price
+	switch typ {
+	case utils.OrderTypeBuyStopLimit:
+		if !trigger.Decimal().IsPositive() || !trigger.Decimal().LessThan(p) {
+			return rejectf(utils.RetcodeInvalidPrice, "invalid trigger price %s for buy stop limit at %s", s.formatPrice(trigger), s.formatPrice(price))
+		}
+		fill = trigger
+	case utils.OrderTypeSellStopLimit:
+		if !trigger.Decimal().IsPositive() || !trigger.Decimal().GreaterThan(p) {
+			return rejectf(utils.RetcodeInvalidPrice, "invalid trigger price %s for sell stop limit at %s", s.formatPrice(trigger), s.formatPrice(price))
+		}
+		fill = trigger
+	}
+	return s.checkStops(typ.IsBuy(), fill, sl, tp)
+}
+
+func (g *Gateway) checkExpiration(expireType utils.OrderTime, expireTime int64) error {
+	switch expireType {
+	case utils.OrderTimeGTC, utils.OrderTimeDay:
+		return nil
+	case utils.OrderTimeSpecified, utils.OrderTimeSpecifiedDay:
+		if expireTime <= g.now().Unix() {
+			return rejectf(utils.RetcodeInvalidExpiration, "expire time %d is in the past", expireTime)
+		}
+		return nil
+	}
+	return rejectf(utils.RetcodeInvalidExpiration, "invalid expire time type %d", expireType)
+}
+
+type pendingParams struct {
+	login      uint64
+	symbol     string
+	typ        utils.OrderType
+	lots       utils.Volume
+	price      utils.Price
+	trigger    utils.Price
+	sl         utils.Price
+	tp         utils.Price
+	expireType utils.OrderTime
+	expireTime int64
+	comment    string
+}
+
+func (g *Gateway) placePendingOrder(req pendingParams) (*pendingOrder, error) {
+	if _, err := g.account(req.login); err != nil {
+		return nil, err
+	}
+	s, err := g.symbol(req.symbol)
+	if err != nil {
+		return nil, err
+	}
+	if !req.typ.IsPending() {
+		return nil, rejectf(utils.RetcodeInvalidRequest, "invalid pending order type %d", req.typ)
+	}
+	if err := s.checkTradeMode(req.typ.IsBuy()); err != nil {
+		return nil, err
+	}
+	volume, err := s.checkVolume(req.lots)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &pendingOrder{
+		login:      req.login,
+		symbol:     s.base.Symbol,
+		typ:        req.typ,
+		volume:     volume,
+		expireType: req.expireType,
+		expireTime: req.expireTime,
+		timeSetup:  g.now(),
+		comment:    req.comment,
+	}
+	if err := g.applyPendingPrices(o, s, req.price, req.trigger, req.sl, req.tp); err != nil {
+		return nil, err
+	}
+	if err := g.checkExpiration(o.expireType, o.expireTime); err != nil {
+		return nil, err
+	}
+	o.ticket = g.newTicket()
+	g.orders[o.ticket] = o
+	return o, nil
+}
+
+// applyPendingPrices 解析并检查价格, 通过后才写入o
+func (g *Gateway) applyPendingPrices(o *pendingOrder, s *symbol, price, trigger, sl, tp utils.Price) error {
+	var err error
+	var p, t, slPrice, tpPrice utils.Price
+	if p, err = parsePrice("price", price); err != nil {
+		return err
+	}
+	if o.typ.IsStopLimit() {
+		if t, err = parsePrice("trigger_price", trigger); err != nil {
+			return err
+		}
+	}
+	if slPrice, err = parsePrice("sl", sl); err != nil {
+		return err
+	}
+	if tpPrice, err = parsePrice("tp", tp); err != nil {
+		return err
+	}
+	if err := g.checkPendingPrices(s, o.typ, p, t, slPrice, tpPrice); err != nil {
+		return err
+	}
+	o.price, o.trigger, o.sl, o.tp = p, t, slPrice, tpPrice
+	return nil
+}
+
+// modifyPendingOrder 没传的价格保持不变
+func (g *Gateway) modifyPendingOrder(ticket uint64, price, trigger, sl, tp utils.Price, expireType utils.OrderTime, expireTime int64) (*pendingOrder, error) {
+	o, err := g.pendingOrder(ticket)
+	if err != nil {
+		return nil, err
+	}
+	s := g.symbols[o.symbol]
+
+	keep := func(v, current utils.Price) utils.Price {
+		if !v.IsSet() {
+			return current
+		}
+		return v
+	}
+	updated := *o
+	updated.expireType, updated.expireTime = expireType, expireTime
+	if err := g.applyPendingPrices(&updated, s, keep(price, o.price), keep(trigger, o.trigger), keep(sl, o.sl), keep(tp, o.tp)); err != nil {
+		return nil, err
+	}
+	if err := g.checkExpiration(updated.expireType, updated.expireTime); err != nil {
+		return nil, err
+	}
+	if updated.sameSettings(o) {
+		return nil, rejectf(utils.RetcodeNoChanges, "order %d is not changed", ticket)
+	}
+	*o = updated
+	return o, nil
+}
+
+func (g *Gateway) removePendingOrder(ticket uint64) error {
+	if _, err := g.pendingOrder(ticket); err != nil {
+		return err
+	}
+	delete(g.orders, ticket)
+	return nil
+}
+
+// removeAllPendingOrders symbol为空则删掉所有挂单
+func (g *Gateway) removeAllPendingOrders(login uint64, symbolName string) ([]uint64, error) {
+	if _, err := g.account(login); err != nil {
+		return nil, err
+	}
+	var tickets []uint64
+	for _, o := range g.loginOrders(login) {
+		if symbolName != "" && o.symbol != symbolName {
+			continue
+		}
+		delete(g.orders, o.ticket)
+		tickets = append(tickets, o.ticket)
+	}
+	return tickets, nil
+}
+
+//------------------------------------------------------------------------
+
+// onQuote 报价变化后: 删除过期挂单, 触发挂单, 检查持仓的sl/tp
+func (g *Gateway) onQuote(s *symbol) {
+	now := g.now()
+
+	for _, o := range g.symbolOrders(s.base.Symbol) {
+		if o.expireType != utils.OrderTimeGTC && g.expired(o, now) {
+			delete(g.orders, o.ticket)
+			continue
+		}
+
+		bid, ask, target := s.bid.Decimal(), s.ask.Decimal(), o.price.Decimal()
+		var triggered bool
+		switch o.typ {
+		case utils.OrderTypeBuyLimit:
+			triggered = ask.LessThanOrEqual(target)
+		case utils.OrderTypeSellLimit:
+			triggered = bid.GreaterThanOrEqual(target)
+		case utils.OrderTypeBuyStop, utils.OrderTypeBuyStopLimit:
+			triggered = ask.GreaterThanOrEqual(target)
+		case utils.OrderTypeSellStop, utils.OrderTypeSellStopLimit:
+			triggered = bid.LessThanOrEqual(target)
+		}
+		if !triggered {
+			continue
+		}
+
+		//stop limit触发后变成limit单
+		switch o.typ {
+		case utils.OrderTypeBuyStopLimit:
+			o.typ, o.price, o.trigger = utils.OrderTypeBuyLimit, o.trigger, utils.NewPrice(decimal.Zero)
+			continue
+		case utils.OrderTypeSellStopLimit:
+			o.typ, o.price, o.trigger = utils.OrderTypeSellLimit, o.trigger, utils.NewPrice(decimal.Zero)
+			continue
+		}
+
+		//limit按挂单价成交, stop按市价成交; 保证金不足时挂单被取消
+		price := o.price
+		switch o.typ {
+		case utils.OrderTypeBuyStop:
+			price = s.ask
+		case utils.OrderTypeSellStop:
+			price = s.bid
+		}
+		delete(g.orders, o.ticket)
+		if p, _, err := g.fill(g.accounts[o.login], s, o.typ, o.volume, price, o.comment); err == nil {
+			p.sl, p.tp = o.sl, o.tp
+		}
+	}
+
+	for _, p := range g.symbolPositions(s.base.Symbol) {
+		bid, ask, sl, tp := s.bid.Decimal(), s.ask.Decimal(), p.sl.Decimal(), p.tp.Decimal()
+		var hit bool
+		if p.action.IsBuy() {
+			hit = (sl.IsPositive() && bid.LessThanOrEqual(sl)) || (tp.IsPositive() && bid.GreaterThanOrEqual(tp))
+		} else {
+			hit = (sl.IsPositive() && ask.GreaterThanOrEqual(sl)) || (tp.IsPositive() && ask.LessThanOrEqual(tp))
+		}
+		if hit {
+			g.closePosition(p.ticket, utils.Volume{})
+		}
+	}
+}
+
+// expired day单在当天结束时过期, specified单在指定时间过期, specified day单在指定日期结束时过期
+func (g *Gateway) expired(o *pendingOrder, now time.Time) bool {
+	switch o.expireType {
+	case utils.OrderTimeDay:
+		y, m, d := o.timeSetup.Date()
+		return !now.Before(time.Date(y, m, d+1, 0, 0, 0, 0, o.timeSetup.Location()))
+	case utils.OrderTimeSpecified:
+		return now.Unix() >= o.expireTime
+	case utils.OrderTimeSpecifiedDay:
+		y, m, d := time.Unix(o.expireTime, 0).In(now.Location()).Date()
+		return !now.Before(time.Date(y, m, d+1, 0, 0, 0, 0, now.Location()))
+	}
+	return false
+}
+
+func (g *Gateway) symbolOrders(symbolName string) []*pendingOrder {
+	var list []*pendingOrder
+	for _, o := range g.orders {
+		if o.symbol == symbolName {
+			list = append(list, o)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ticket < list[j].ticket })
+	return list
+}
+
+func (g *Gateway) symbolPositions(symbolName string) []*position {
+	var list []*position
+	for _, p := range g.positions {
+		if p.symbol == symbolName {
+			list = append(list, p)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ticket < list[j].ticket })
+	return list
+}
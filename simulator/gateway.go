@@ -0,0 +1,512 @@
+package simulator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/direct"
+	"github.com/asaka1234/go-mt5-sdk/order"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Gateway 进程内的MT5网关模拟器, 实现了SDK用到的所有 /v1 接口, 数据都在内存里
+//
+//	gw := simulator.NewGateway()
+//	srv := httptest.NewServer(gw)
+//	defer srv.Close()
+//	cli := order.NewClient(nil, &order.InitParams{Address: srv.URL})
+//
+// 需要校验签名时用 utils.SignVerifier.Middleware(gw) 包一层
+type Gateway struct {
+	mux *http.ServeMux
+
+	mu         sync.Mutex
+	now        func() time.Time
+	symbols    map[string]*symbol
+	accounts   map[uint64]*account
+	positions  map[uint64]*position
+	orders     map[uint64]*pendingOrder
+	nextLogin  uint64
+	nextTicket uint64
+	nextDeal   uint64
+}
+
+// TradeResult 交易接口返回的data
+type TradeResult struct {
+	Ticket uint64       `json:"ticket"`            //持仓/挂单的id
+	DealId uint64       `json:"deal_id,omitempty"` //成交id, 挂单相关的接口没有
+	Price  utils.Price  `json:"price,omitzero"`    //成交价
+	Volume utils.Volume `json:"volume,omitzero"`   //成交手数
+	Profit utils.Money  `json:"profit,omitzero"`   //平仓盈亏
+}
+
+// BatchResult 一键平仓/一键撤单返回的data
+type BatchResult struct {
+	Tickets []uint64 `json:"tickets"` //被平掉的持仓/被删掉的挂单
+}
+
+// NewGateway 创建模拟网关, 默认加载 DefaultSymbols
+func NewGateway() *Gateway {
+	g := &Gateway{
+		mux:        http.NewServeMux(),
+		now:        time.Now,
+		symbols:    make(map[string]*symbol),
+		accounts:   make(map[uint64]*account),
+		positions:  make(map[uint64]*position),
+		orders:     make(map[uint64]*pendingOrder),
+		nextLogin:  firstLogin - 1,
+		nextTicket: firstTicket - 1,
+		nextDeal:   firstDeal - 1,
+	}
+	for _, q := range DefaultSymbols() {
+		if err := g.AddSymbol(q.Symbol, q.Bid, q.Ask); err != nil {
+			panic(err)
+		}
+	}
+
+	g.mux.HandleFunc("GET /v1/symbol/list", g.handleSymbolList)
+	g.mux.HandleFunc("GET /v1/tick/review", g.handleTickReview)
+	g.mux.HandleFunc("POST /v1/user/create", g.handleUserCreate)
+	g.mux.HandleFunc("GET /v1/user/account/detail", g.handleUserAccountDetail)
+	g.mux.HandleFunc("POST /v1/balance/operation", g.handleBalanceOperation)
+	g.mux.HandleFunc("GET /v1/position/list", g.handlePositionList)
+	g.mux.HandleFunc("GET /v1/position/get", g.handlePositionGet)
+	g.mux.HandleFunc("GET /v1/pendingOrder/list", g.handlePendingOrderList)
+	g.mux.HandleFunc("GET /v1/order/get", g.handleOrderGet)
+	g.mux.HandleFunc("POST /v1/position/open", g.handlePositionOpen)
+	g.mux.HandleFunc("POST /v1/position/modify", g.handlePositionModify)
+	g.mux.HandleFunc("POST /v1/position/close", g.handlePositionClose)
+	g.mux.HandleFunc("POST /v1/position/all/close", g.handlePositionAllClose)
+	g.mux.HandleFunc("POST /v1/pending/order/place", g.handlePendingOrderPlace)
+	g.mux.HandleFunc("POST /v1/pending/order/modify", g.handlePendingOrderModify)
+	g.mux.HandleFunc("POST /v1/pending/order/remove", g.handlePendingOrderRemove)
+	g.mux.HandleFunc("POST /v1/pending/order/all/remove", g.handlePendingOrderAllRemove)
+	return g
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// SetClock 替换时钟, 用于测试挂单过期等和时间相关的逻辑
+func (g *Gateway) SetClock(now func() time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.now = now
+}
+
+// AddSymbol 添加或替换品种, ContractSize/VolumeMin/VolumeMax/VolumeStep 必须是正数
+func (g *Gateway) AddSymbol(base direct.MT5SymbolBase, bid, ask utils.Price) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	s, err := newSymbol(base, bid, ask)
+	if err != nil {
+		return err
+	}
+	s.time = g.now()
+	g.symbols[base.Symbol] = s
+	return nil
+}
+
+// SetQuote 更新报价, 会触发挂单成交/过期, 以及持仓的sl/tp
+func (g *Gateway) SetQuote(symbolName string, bid, ask utils.Price) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	s, ok := g.symbols[symbolName]
+	if !ok {
+		return fmt.Errorf("unknown symbol %q", symbolName)
+	}
+	if err := s.setQuote(bid, ask); err != nil {
+		return err
+	}
+	s.time = g.now()
+	g.onQuote(s)
+	return nil
+}
+
+// CreateAccount 直接开户并入金, 省掉测试里 UserCreate + BalanceOperation 两步, leverage为0则使用默认杠杆500
+func (g *Gateway) CreateAccount(balance utils.Money, leverage uint) uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	user := g.createUser(direct.UserCreateReq{Leverage: leverage})
+	g.accounts[user.Login].balance = utils.NewMoney(balance.Decimal())
+	return user.Login
+}
+
+//------------------------------------------------------------------------
+
+func (g *Gateway) handleSymbolList(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	list := make([]direct.MT5SymbolBase, 0, len(g.symbols))
+	for _, s := range g.symbols {
+		list = append(list, s.base)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Symbol < list[j].Symbol })
+	writeData(w, list)
+}
+
+// handleTickReview 价格扩大1e8倍, last取bid
+func (g *Gateway) handleTickReview(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ticks := make([]direct.MT5Tick, 0, len(g.symbols))
+	for _, s := range g.symbols {
+		ticks = append(ticks, direct.MT5Tick{
+			Symbol: s.base.Symbol,
+			AskE8:  toE8(s.ask),
+			BidE8:  toE8(s.bid),
+			LastE8: toE8(s.bid),
+			Time:   s.time.UnixMilli(),
+		})
+	}
+	sort.Slice(ticks, func(i, j int) bool { return ticks[i].Symbol < ticks[j].Symbol })
+	writeData(w, ticks)
+}
+
+func (g *Gateway) handleUserCreate(w http.ResponseWriter, r *http.Request) {
+	var req direct.UserCreateReq
+	if !readBody(w, r, &req) {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	writeData(w, g.createUser(req))
+}
+
+func (g *Gateway) handleUserAccountDetail(w http.ResponseWriter, r *http.Request) {
+	login, ok := queryUint(w, r, "login")
+	if !ok {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	a, err := g.account(login)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, g.accountDetail(a))
+}
+
+func (g *Gateway) handleBalanceOperation(w http.ResponseWriter, r *http.Request) {
+	var req direct.BalanceOperationReq
+	if !readBody(w, r, &req) {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	deal, err := g.balanceOperation(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, direct.MtRecharge{DealId: deal})
+}
+
+func (g *Gateway) handlePositionList(w http.ResponseWriter, r *http.Request) {
+	login, ok := queryUint(w, r, "login")
+	if !ok {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, err := g.account(login); err != nil {
+		writeError(w, err)
+		return
+	}
+	list := make([]*direct.MTPosition, 0)
+	for _, p := range g.loginPositions(login) {
+		list = append(list, g.positionInfo(p))
+	}
+	writeData(w, list)
+}
+
+func (g *Gateway) handlePositionGet(w http.ResponseWriter, r *http.Request) {
+	ticket, ok := queryUint(w, r, "ticket")
+	if !ok {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, err := g.position(ticket)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, g.positionInfo(p))
+}
+
+func (g *Gateway) handlePendingOrderList(w http.ResponseWriter, r *http.Request) {
+	login, ok := queryUint(w, r, "login")
+	if !ok {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, err := g.account(login); err != nil {
+		writeError(w, err)
+		return
+	}
+	list := make([]*direct.MTOrder, 0)
+	for _, o := range g.loginOrders(login) {
+		list = append(list, g.orderInfo(o))
+	}
+	writeData(w, list)
+}
+
+func (g *Gateway) handleOrderGet(w http.ResponseWriter, r *http.Request) {
+	ticket, ok := queryUint(w, r, "ticket")
+	if !ok {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	o, err := g.pendingOrder(ticket)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, g.orderInfo(o))
+}
+
+func (g *Gateway) handlePositionOpen(w http.ResponseWriter, r *http.Request) {
+	var req order.OpenPositionRequest
+	if !readBody(w, r, &req) {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, deal, err := g.openPosition(req.Login, req.Symbol, req.Type, req.Lots, req.Sl, req.Tp, req.Comment)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, TradeResult{
+		Ticket: p.ticket,
+		DealId: deal,
+		Price:  g.symbols[p.symbol].price(p.priceOpen),
+		Volume: p.volume,
+	})
+}
+
+func (g *Gateway) handlePositionModify(w http.ResponseWriter, r *http.Request) {
+	var req order.ModifyPositionRequest
+	if !readBody(w, r, &req) {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, err := g.modifyPosition(req.Ticket, req.Sl, req.Tp)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, TradeResult{Ticket: p.ticket})
+}
+
+func (g *Gateway) handlePositionClose(w http.ResponseWriter, r *http.Request) {
+	var req order.ClosePositionRequest
+	if !readBody(w, r, &req) {
+		return
+	}
+	if req.Ticket <= 0 {
+		writeError(w, rejectf(utils.RetcodeInvalidParams, "invalid ticket %d", req.Ticket))
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ticket := uint64(req.Ticket)
+	var s *symbol
+	if p, ok := g.positions[ticket]; ok {
+		s = g.symbols[p.symbol]
+	}
+	volume, price, profit, deal, err := g.closePosition(ticket, req.Lots)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, TradeResult{
+		Ticket: ticket,
+		DealId: deal,
+		Price:  s.price(price),
+		Volume: volume,
+		Profit: profit,
+	})
+}
+
+func (g *Gateway) handlePositionAllClose(w http.ResponseWriter, r *http.Request) {
+	var req order.CloseAllPositionsRequest
+	if !readBody(w, r, &req) {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	tickets, err := g.closeAllPositions(req.Login)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, BatchResult{Tickets: nonNil(tickets)})
+}
+
+func (g *Gateway) handlePendingOrderPlace(w http.ResponseWriter, r *http.Request) {
+	var req order.PlacePendingOrderRequest
+	if !readBody(w, r, &req) {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	o, err := g.placePendingOrder(pendingParams{
+		login:      req.Login,
+		symbol:     req.Symbol,
+		typ:        req.Type,
+		lots:       req.Lots,
+		price:      req.Price,
+		trigger:    req.TriggerPrice,
+		sl:         req.Sl,
+		tp:         req.Tp,
+		expireType: req.ExpireTimeType,
+		expireTime: req.ExpireTime,
+		comment:    req.Comment,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, TradeResult{Ticket: o.ticket, Price: g.symbols[o.symbol].price(o.price), Volume: o.volume})
+}
+
+func (g *Gateway) handlePendingOrderModify(w http.ResponseWriter, r *http.Request) {
+	var req order.ModifyPendingOrderRequest
+	if !readBody(w, r, &req) {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	o, err := g.modifyPendingOrder(req.Ticket, req.Price, req.TriggerPrice, req.Sl, req.Tp, req.ExpireTimeType, req.ExpireTime)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, TradeResult{Ticket: o.ticket, Price: g.symbols[o.symbol].price(o.price)})
+}
+
+func (g *Gateway) handlePendingOrderRemove(w http.ResponseWriter, r *http.Request) {
+	var req order.RemovePendingOrderRequest
+	if !readBody(w, r, &req) {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.removePendingOrder(req.Ticket); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, TradeResult{Ticket: req.Ticket})
+}
+
+func (g *Gateway) handlePendingOrderAllRemove(w http.ResponseWriter, r *http.Request) {
+	var req order.RemoveAllPendingOrdersRequest
+	if !readBody(w, r, &req) {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	tickets, err := g.removeAllPendingOrders(req.Login, req.Symbol)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, BatchResult{Tickets: nonNil(tickets)})
+}
+
+//------------------------------------------------------------------------
+
+// readBody 请求体不是合法的json时返回400
+func readBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, order.CommonResp{
+			Code:    int(utils.RetcodeInvalidParams),
+			Message: fmt.Sprintf("invalid request body: %v", err),
+		})
+		return false
+	}
+	return true
+}
+
+func queryUint(w http.ResponseWriter, r *http.Request, key string) (uint64, bool) {
+	v, err := strconv.ParseUint(r.URL.Query().Get(key), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, order.CommonResp{
+			Code:    int(utils.RetcodeInvalidParams),
+			Message: fmt.Sprintf("invalid %s %q", key, r.URL.Query().Get(key)),
+		})
+		return 0, false
+	}
+	return v, true
+}
+
+func writeData(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, http.StatusOK, order.CommonResp{
+		Code:    int(utils.RetcodeOK),
+		Success: true,
+		Message: "success",
+		Data:    data,
+	})
+}
+
+// writeError 业务错误和真实网关一样返回http 200, 错误码放在code里
+func writeError(w http.ResponseWriter, err error) {
+	var rej *reject
+	if !errors.As(err, &rej) {
+		rej = &reject{code: utils.RetcodeError, message: err.Error()}
+	}
+	writeJSON(w, http.StatusOK, order.CommonResp{
+		Code:    int(rej.code),
+		Message: rej.message,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func toE8(price utils.Price) int64 {
+	e8, _ := price.Round(8).E8() //已经取整到8位小数, 报价也不会超出int64
+	return e8
+}
+
+func nonNil(tickets []uint64) []uint64 {
+	if tickets == nil {
+		return []uint64{}
+	}
+	return tickets
+}
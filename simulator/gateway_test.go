@@ -0,0 +1,241 @@
+package simulator_test
+
+import (
+	"errors"
+	"github.com/asaka1234/go-mt5-sdk/direct"
+	"github.com/asaka1234/go-mt5-sdk/order"
+	"github.com/asaka1234/go-mt5-sdk/simulator"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type clients struct {
+	gateway *simulator.Gateway
+	direct  *direct.Client
+	order   *order.Client
+}
+
+// newClients wrap为nil时直接使用网关, params为nil时不重试
+func newClients(t *testing.T, wrap func(http.Handler) http.Handler, params *utils.ClientParams) *clients {
+	t.Helper()
+	g := simulator.NewGateway()
+	var handler http.Handler = g
+	if wrap != nil {
+		handler = wrap(g)
+	}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	if params == nil {
+		params = &utils.ClientParams{Retry: &utils.RetryPolicy{MaxAttempts: 1}}
+	}
+	params.Address = srv.URL
+	c := &clients{gateway: g, direct: direct.NewClient(nil, params), order: order.NewClient(nil, params)}
+	t.Cleanup(func() {
+		c.direct.Close()
+		c.order.Close()
+	})
+	return c
+}
+
+func (c *clients) account(t *testing.T, login uint64) direct.MTUserAccount {
+	t.Helper()
+	resp, err := c.direct.UserAccountDetail(login)
+	if err != nil {
+		t.Fatalf("UserAccountDetail: %v", err)
+	}
+	return resp.Data
+}
+
+func (c *clients) positions(t *testing.T, login uint64) []*direct.MTPosition {
+	t.Helper()
+	resp, err := c.direct.ListPosition(login)
+	if err != nil {
+		t.Fatalf("ListPosition: %v", err)
+	}
+	return resp.Data
+}
+
+func wantString(t *testing.T, what string, got interface{ String() string }, want string) {
+	t.Helper()
+	if got.String() != want {
+		t.Fatalf("%s = %s, want %s", what, got, want)
+	}
+}
+
+func TestGatewayBalanceOperation(t *testing.T) {
+	c := newClients(t, nil, nil)
+	user, err := c.direct.UserCreate(direct.UserCreateReq{Leverage: 100})
+	if err != nil {
+		t.Fatalf("UserCreate: %v", err)
+	}
+	login := user.Data.Login
+
+	//0.1 + 0.2 用float会变成 0.30000000000000004
+	for _, amount := range []string{"0.1", "0.2", "1000"} {
+		if _, err := c.direct.BalanceOperation(direct.BalanceOperationReq{Login: login, Balance: utils.MustParseMoney(amount)}); err != nil {
+			t.Fatalf("BalanceOperation(%s): %v", amount, err)
+		}
+	}
+	wantString(t, "balance", c.account(t, login).Balance, "1000.3")
+
+	_, err = c.direct.BalanceOperation(direct.BalanceOperationReq{Login: login, Balance: utils.MustParseMoney("-1000.31")})
+	if !errors.Is(err, utils.RetcodeNoMoney) {
+		t.Fatalf("withdraw more than free margin: err = %v, want RetcodeNoMoney", err)
+	}
+	if _, err := c.direct.BalanceOperation(direct.BalanceOperationReq{Login: login, Balance: utils.MustParseMoney("-1000.3")}); err != nil {
+		t.Fatalf("withdraw all: %v", err)
+	}
+	wantString(t, "balance", c.account(t, login).Balance, "0")
+}
+
+func TestGatewayPositionLifecycle(t *testing.T) {
+	c := newClients(t, nil, nil)
+	login := c.gateway.CreateAccount(utils.MustParseMoney("10000"), 100)
+
+	_, err := c.order.OpenPosition(order.OpenPositionRequest{Login: login, Symbol: "EURUSD", Type: order.MtRequestTypeBuy, Lots: utils.MustParseVolume("0.015")})
+	if !errors.Is(err, utils.RetcodeInvalidVolume) {
+		t.Fatalf("lots not a multiple of step: err = %v, want RetcodeInvalidVolume", err)
+	}
+	_, err = c.order.OpenPosition(order.OpenPositionRequest{Login: login, Symbol: "EURUSD", Type: order.MtRequestTypeBuy, Lots: utils.MustParseVolume("0.1"), Sl: utils.MustParsePrice("1.08499")})
+	if !errors.Is(err, utils.RetcodeInvalidStops) {
+		t.Fatalf("sl inside stops level: err = %v, want RetcodeInvalidStops", err)
+	}
+
+	if _, err := c.order.OpenPosition(order.OpenPositionRequest{Login: login, Symbol: "EURUSD", Type: order.MtRequestTypeBuy, Lots: utils.MustParseVolume("0.1"), Sl: utils.MustParsePrice("1.08000")}); err != nil {
+		t.Fatalf("OpenPosition: %v", err)
+	}
+	list := c.positions(t, login)
+	if len(list) != 1 {
+		t.Fatalf("%d positions, want 1", len(list))
+	}
+	p := list[0]
+	wantString(t, "price_open", p.PriceOpen, "1.0852")
+	wantString(t, "sl", p.PriceSL, "1.08")
+	//0.1手 * 100000 * 1.0852 / 100
+	wantString(t, "margin", c.account(t, login).Margin, "108.52")
+
+	//传0清掉sl
+	if _, err := c.order.ModifyPosition(order.ModifyPositionRequest{Ticket: p.Ticket, Sl: utils.MustParsePrice("0"), Tp: utils.MustParsePrice("1.09000")}); err != nil {
+		t.Fatalf("ModifyPosition: %v", err)
+	}
+	p = c.positions(t, login)[0]
+	wantString(t, "sl", p.PriceSL, "0")
+	wantString(t, "tp", p.PriceTP, "1.09")
+
+	if err := c.gateway.SetQuote("EURUSD", utils.MustParsePrice("1.08620"), utils.MustParsePrice("1.08640")); err != nil {
+		t.Fatalf("SetQuote: %v", err)
+	}
+	wantString(t, "floating", c.account(t, login).Floating, "10")
+
+	if _, err := c.order.ClosePosition(order.ClosePositionRequest{Ticket: int(p.Ticket), Lots: utils.MustParseVolume("0.03")}); err != nil {
+		t.Fatalf("partial close: %v", err)
+	}
+	//float会剩下 0.06999999999999999
+	wantString(t, "remaining volume", c.positions(t, login)[0].Volume, "0.07")
+	wantString(t, "balance", c.account(t, login).Balance, "10003")
+
+	_, err = c.order.ClosePosition(order.ClosePositionRequest{Ticket: int(p.Ticket), Lots: utils.MustParseVolume("0.08")})
+	if !errors.Is(err, utils.RetcodeInvalidCloseVolume) {
+		t.Fatalf("close more than position volume: err = %v, want RetcodeInvalidCloseVolume", err)
+	}
+	if _, err := c.order.ClosePosition(order.ClosePositionRequest{Ticket: int(p.Ticket)}); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if n := len(c.positions(t, login)); n != 0 {
+		t.Fatalf("%d positions after close, want 0", n)
+	}
+	detail := c.account(t, login)
+	wantString(t, "balance", detail.Balance, "10010")
+	wantString(t, "margin", detail.Margin, "0")
+}
+
+func TestGatewayPendingOrder(t *testing.T) {
+	c := newClients(t, nil, nil)
+	login := c.gateway.CreateAccount(utils.MustParseMoney("10000"), 100)
+
+	_, err := c.order.PlacePendingOrder(order.PlacePendingOrderRequest{Login: login, Symbol: "EURUSD", Type: order.MtRequestTypeBuyLimit, Lots: utils.MustParseVolume("0.1"), Price: utils.MustParsePrice("1.08600")})
+	if !errors.Is(err, utils.RetcodeInvalidPrice) {
+		t.Fatalf("buy limit above ask: err = %v, want RetcodeInvalidPrice", err)
+	}
+	req := order.PlacePendingOrderRequest{Login: login, Symbol: "EURUSD", Type: order.MtRequestTypeBuyLimit, Lots: utils.MustParseVolume("0.1"), Price: utils.MustParsePrice("1.08400"), Tp: utils.MustParsePrice("1.09000"), ExpireTimeType: order.MtOrderTimeGTC}
+	if _, err := c.order.PlacePendingOrder(req); err != nil {
+		t.Fatalf("PlacePendingOrder: %v", err)
+	}
+	orders, err := c.direct.ListPendingOrder(login)
+	if err != nil || len(orders.Data) != 1 {
+		t.Fatalf("ListPendingOrder = %v, %v", orders, err)
+	}
+	ticket := orders.Data[0].Ticket
+
+	_, err = c.order.ModifyPendingOrder(order.ModifyPendingOrderRequest{Ticket: ticket, Price: utils.MustParsePrice("1.084"), ExpireTimeType: order.MtOrderTimeGTC})
+	if !errors.Is(err, utils.RetcodeNoChanges) {
+		t.Fatalf("same price with a different scale: err = %v, want RetcodeNoChanges", err)
+	}
+	if _, err := c.order.ModifyPendingOrder(order.ModifyPendingOrderRequest{Ticket: ticket, Price: utils.MustParsePrice("1.08300"), ExpireTimeType: order.MtOrderTimeGTC}); err != nil {
+		t.Fatalf("ModifyPendingOrder: %v", err)
+	}
+
+	//ask碰到挂单价, 按挂单价成交, tp带到持仓上
+	if err := c.gateway.SetQuote("EURUSD", utils.MustParsePrice("1.08280"), utils.MustParsePrice("1.08300")); err != nil {
+		t.Fatalf("SetQuote: %v", err)
+	}
+	if orders, _ := c.direct.ListPendingOrder(login); len(orders.Data) != 0 {
+		t.Fatalf("%d pending orders after trigger, want 0", len(orders.Data))
+	}
+	list := c.positions(t, login)
+	if len(list) != 1 {
+		t.Fatalf("%d positions after trigger, want 1", len(list))
+	}
+	wantString(t, "price_open", list[0].PriceOpen, "1.083")
+	wantString(t, "tp", list[0].PriceTP, "1.09")
+	wantString(t, "volume", list[0].Volume, "0.1")
+
+	//碰到tp自动平仓: (1.09 - 1.083) * 0.1 * 100000
+	if err := c.gateway.SetQuote("EURUSD", utils.MustParsePrice("1.09000"), utils.MustParsePrice("1.09020")); err != nil {
+		t.Fatalf("SetQuote: %v", err)
+	}
+	if n := len(c.positions(t, login)); n != 0 {
+		t.Fatalf("%d positions after tp, want 0", n)
+	}
+	wantString(t, "balance", c.account(t, login).Balance, "10070")
+}
+
+// 第一次开仓请求网关已经成交, 但响应超时; 客户端确认到持仓后不能再重发
+func TestGatewayConfirmBeforeResubmit(t *testing.T) {
+	var opens atomic.Int32
+	done := make(chan struct{})
+	wrap := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v1/position/open" && opens.Add(1) == 1 {
+				next.ServeHTTP(httptest.NewRecorder(), r)
+				<-done
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	c := newClients(t, wrap, &utils.ClientParams{
+		Timeout: 100 * time.Millisecond,
+		Retry:   &utils.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, ConfirmWindow: 300 * time.Millisecond, ConfirmInterval: 20 * time.Millisecond},
+	})
+	//Cleanup 后进先出, 先放行挂住的请求再关闭服务
+	t.Cleanup(func() { close(done) })
+	c.order.SetTradeConfirmer(order.NewTradeConfirmer(c.direct))
+	login := c.gateway.CreateAccount(utils.MustParseMoney("10000"), 100)
+
+	_, err := c.order.OpenPosition(order.OpenPositionRequest{Login: login, Symbol: "EURUSD", Type: order.MtRequestTypeBuy, Lots: utils.MustParseVolume("0.1"), Comment: "confirm-1"})
+	if !errors.Is(err, utils.ErrTradeLanded) {
+		t.Fatalf("err = %v, want ErrTradeLanded", err)
+	}
+	if n := opens.Load(); n != 1 {
+		t.Fatalf("gateway saw %d open requests, want 1", n)
+	}
+	if n := len(c.positions(t, login)); n != 1 {
+		t.Fatalf("%d positions, want 1", n)
+	}
+}
@@ -0,0 +1,380 @@
+package simulator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PumpingServer 本地的pumping服务端, 协议和真实服务一致:
+// 客户端发送以换行分隔的json订阅请求, 服务端推送 4字节大端长度前缀 + snappy压缩的msgpack TCPResponse
+//
+//	srv, _ := simulator.NewPumpingServer("")
+//	defer srv.Close()
+//	cli := pumping.NewTCPClient(&pumping.Config{ServerAddr: srv.Addr(), ...}, handler)
+//	cli.Connect()
+//	cli.SubscribeTick("EURUSD")
+//	srv.WaitSubscribed(ctx, pumping.REQUEST_TYPE_TICK)
+//	srv.Tick(pumping.MT5Tick{Symbol: "EURUSD", BidE8: 108500000, AskE8: 108520000})
+//
+// 消息只会推送给订阅了对应类型的连接, tick按订阅的symbol过滤
+type PumpingServer struct {
+	ln  net.Listener
+	now func() time.Time
+	wg  sync.WaitGroup
+
+	mu       sync.Mutex
+	conns    map[*pumpingConn]struct{}
+	requests []pumping.TCPRequest
+	changed  chan struct{} //有新的连接/订阅时close, 用于 WaitSubscribed
+	closed   bool
+}
+
+type pumpingConn struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+
+	subs map[pumping.REQUEST_TYPE]map[string]bool //订阅类型 -> tick的symbol过滤, nil表示全部; 由 PumpingServer.mu 保护
+}
+
+// NewPumpingServer 在addr上监听, addr为空则使用 127.0.0.1 的随机端口
+func NewPumpingServer(addr string) (*PumpingServer, error) {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &PumpingServer{
+		ln:      ln,
+		now:     time.Now,
+		conns:   make(map[*pumpingConn]struct{}),
+		changed: make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.acceptLoop()
+	return s, nil
+}
+
+// Addr 监听的地址, 用作 pumping.Config.ServerAddr
+func (s *PumpingServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close 停止监听并断开所有连接
+func (s *PumpingServer) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	err := s.ln.Close()
+	s.Disconnect()
+	s.wg.Wait()
+	return err
+}
+
+// Disconnect 断开当前所有连接, 但继续监听, 用来测试客户端的重连
+func (s *PumpingServer) Disconnect() {
+	s.mu.Lock()
+	conns := s.snapshot()
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		c.conn.Close()
+	}
+}
+
+// Connections 当前的连接数
+func (s *PumpingServer) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// Requests 收到的所有请求(包括心跳), 按到达顺序
+func (s *PumpingServer) Requests() []pumping.TCPRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]pumping.TCPRequest(nil), s.requests...)
+}
+
+// WaitSubscribed 等待至少一个连接订阅了typ
+func (s *PumpingServer) WaitSubscribed(ctx context.Context, typ pumping.REQUEST_TYPE) error {
+	for {
+		s.mu.Lock()
+		subscribed := false
+		for c := range s.conns {
+			if _, ok := c.subs[typ]; ok {
+				subscribed = true
+				break
+			}
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		if subscribed {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for %s subscription: %w", typ, ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+//------------------------------------------------------------------------
+
+// Tick 推送报价, 只发给订阅了对应symbol的连接
+func (s *PumpingServer) Tick(ticks ...pumping.MT5Tick) error {
+	s.mu.Lock()
+	conns := s.snapshot()
+	filters := make([]map[string]bool, len(conns))
+	subscribed := make([]bool, len(conns))
+	for i, c := range conns {
+		filters[i], subscribed[i] = c.subs[pumping.REQUEST_TYPE_TICK]
+	}
+	s.mu.Unlock()
+
+	for i, c := range conns {
+		if !subscribed[i] {
+			continue
+		}
+		payload := ticks
+		if filters[i] != nil {
+			payload = nil
+			for _, t := range ticks {
+				if filters[i][t.Symbol] {
+					payload = append(payload, t)
+				}
+			}
+			if len(payload) == 0 {
+				continue
+			}
+		}
+		frame, err := s.frame(pumping.REQUEST_TYPE_TICK, "ok", payload)
+		if err != nil {
+			return err
+		}
+		s.write(c, frame)
+	}
+	return nil
+}
+
+// Order 推送挂单变化, Operation: 1-add, 2-remove, 3-modify
+func (s *PumpingServer) Order(orders ...pumping.MTOrderExtra) error {
+	return s.Emit(pumping.REQUEST_TYPE_ORDER, orders)
+}
+
+// Position 推送持仓变化
+func (s *PumpingServer) Position(positions ...pumping.MTPositionExtra) error {
+	return s.Emit(pumping.REQUEST_TYPE_POSITION, positions)
+}
+
+// Deal 推送成交
+func (s *PumpingServer) Deal(deals ...pumping.Mt5DealExtra) error {
+	return s.Emit(pumping.REQUEST_TYPE_DEAL, deals)
+}
+
+// UserAdd 推送新开户
+func (s *PumpingServer) UserAdd(users ...pumping.MT5User) error {
+	return s.Emit(pumping.REQUEST_TYPE_USER_ADD, users)
+}
+
+// MarginCall 推送追保通知
+func (s *PumpingServer) MarginCall(calls ...pumping.MT5MarginCall) error {
+	return s.Emit(pumping.REQUEST_TYPE_MARGINCAL, calls)
+}
+
+// StopOut 推送强平通知
+func (s *PumpingServer) StopOut(stopOuts ...pumping.MT5StopOut) error {
+	return s.Emit(pumping.REQUEST_TYPE_STOPOUT, stopOuts)
+}
+
+// Emit 推送任意payload给订阅了typ的连接
+func (s *PumpingServer) Emit(typ pumping.REQUEST_TYPE, payload interface{}) error {
+	frame, err := s.frame(typ, "ok", payload)
+	if err != nil {
+		return err
+	}
+	s.broadcast(typ, frame)
+	return nil
+}
+
+// EmitError 推送status不是ok的消息, 客户端会当作服务端错误处理
+func (s *PumpingServer) EmitError(typ pumping.REQUEST_TYPE, status string) error {
+	frame, err := s.frame(typ, status, nil)
+	if err != nil {
+		return err
+	}
+	s.broadcast(typ, frame)
+	return nil
+}
+
+// EmitMalformed 给所有连接推送一个长度前缀正确但内容无法解码的消息
+func (s *PumpingServer) EmitMalformed(data []byte) {
+	frame, _ := utils.EncodeTCPMsgWithLePrefix(data)
+	s.EmitRaw(frame)
+}
+
+// EmitRaw 原样写给所有连接, 不加长度前缀, 可以用来构造截断的消息
+func (s *PumpingServer) EmitRaw(raw []byte) {
+	s.mu.Lock()
+	conns := s.snapshot()
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		s.write(c, raw)
+	}
+}
+
+//------------------------------------------------------------------------
+
+func (s *PumpingServer) frame(typ pumping.REQUEST_TYPE, status string, payload interface{}) ([]byte, error) {
+	data, err := pumping.Encode(pumping.TCPResponse{
+		Status:    status,
+		Type:      string(typ),
+		Payload:   payload,
+		Timestamp: s.now().UnixMilli(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode %s frame: %w", typ, err)
+	}
+	return utils.EncodeTCPMsgWithLePrefix(data)
+}
+
+func (s *PumpingServer) broadcast(typ pumping.REQUEST_TYPE, frame []byte) {
+	s.mu.Lock()
+	var conns []*pumpingConn
+	for c := range s.conns {
+		if _, ok := c.subs[typ]; ok {
+			conns = append(conns, c)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		s.write(c, frame)
+	}
+}
+
+// write 写失败说明连接已经断了, 关掉后由readLoop清理
+func (s *PumpingServer) write(c *pumpingConn, frame []byte) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if _, err := c.conn.Write(frame); err != nil {
+		c.conn.Close()
+	}
+}
+
+// snapshot 调用方需要持有 s.mu
+func (s *PumpingServer) snapshot() []*pumpingConn {
+	conns := make([]*pumpingConn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// notify 调用方需要持有 s.mu
+func (s *PumpingServer) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *PumpingServer) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		c := &pumpingConn{conn: conn, subs: make(map[pumping.REQUEST_TYPE]map[string]bool)}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[c] = struct{}{}
+		s.notify()
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.readLoop(c)
+	}
+}
+
+// readLoop 读取以换行分隔的json请求
+func (s *PumpingServer) readLoop(c *pumpingConn) {
+	defer s.wg.Done()
+	defer func() {
+		c.conn.Close()
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.notify()
+		s.mu.Unlock()
+	}()
+
+	scanner := bufio.NewScanner(c.conn)
+	for scanner.Scan() {
+		var req pumping.TCPRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+		if unknown := s.subscribe(c, req); unknown {
+			if frame, err := s.frame(pumping.REQUEST_TYPE(req.Type), "unknown request type: "+req.Type, nil); err == nil {
+				s.write(c, frame)
+			}
+		}
+	}
+}
+
+// subscribe 记录请求并更新订阅, 返回是否是不认识的请求类型
+func (s *PumpingServer) subscribe(c *pumpingConn, req pumping.TCPRequest) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, req)
+
+	typ := pumping.REQUEST_TYPE(req.Type)
+	switch typ {
+	case "heartbeat":
+		return false
+	case pumping.REQUEST_TYPE_TICK:
+		var filter map[string]bool
+		if req.Params.Symbols != "" {
+			filter = make(map[string]bool)
+			for _, sym := range strings.Split(req.Params.Symbols, ",") {
+				if sym = strings.TrimSpace(sym); sym != "" {
+					filter[sym] = true
+				}
+			}
+		}
+		c.subs[typ] = filter
+	case pumping.REQUEST_TYPE_DEAL, pumping.REQUEST_TYPE_POSITION, pumping.REQUEST_TYPE_ORDER, pumping.REQUEST_TYPE_USER_ADD,
+		pumping.REQUEST_TYPE_MARGINCAL, pumping.REQUEST_TYPE_STOPOUT:
+		c.subs[typ] = nil
+	default:
+		return true
+	}
+	s.notify()
+	return false
+}
@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"container/list"
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/order"
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"go.opentelemetry.io/otel/trace"
+	"sync"
+	"time"
+)
+
+// maxTradeEntries 最多保留这么多条记录, 超过时淘汰最早的(即使还没有过期)
+const maxTradeEntries = 10000
+
+type tradeEntry struct {
+	key string
+	sc  trace.SpanContext //交易请求的span
+	at  time.Time         //交易请求结束的时间
+}
+
+// tradeRegistry 记录最近的交易请求, 用来和pumping的成交消息关联
+//
+//   - 开仓/挂单: login + comment (和 order.NewTradeConfirmer 一样, comment需要唯一)
+//   - 平仓: position id
+type tradeRegistry struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List //按记录时间排列, 最新的在前面
+}
+
+func newTradeRegistry(ttl time.Duration) *tradeRegistry {
+	return &tradeRegistry{
+		ttl:        ttl,
+		maxEntries: maxTradeEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (r *tradeRegistry) remember(req interface{}, sc trace.SpanContext, now time.Time) {
+	keys := tradeKeys(req)
+	if len(keys) == 0 || !sc.IsValid() {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range keys {
+		if e, ok := r.entries[key]; ok {
+			r.order.Remove(e)
+		}
+		r.entries[key] = r.order.PushFront(&tradeEntry{key: key, sc: sc, at: now})
+	}
+	r.sweep(now)
+}
+
+// sweep 从最早的记录开始清理过期的, 数量超过上限时继续淘汰, 调用方需要持有锁
+func (r *tradeRegistry) sweep(now time.Time) {
+	for back := r.order.Back(); back != nil; back = r.order.Back() {
+		e := back.Value.(*tradeEntry)
+		if now.Sub(e.at) <= r.ttl && r.order.Len() <= r.maxEntries {
+			return
+		}
+		r.order.Remove(back)
+		delete(r.entries, e.key)
+	}
+}
+
+// lookup 部分成交会有多条成交消息, 所以查到后记录不会被删掉, 过期后才失效
+func (r *tradeRegistry) lookup(key string, now time.Time) (tradeEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.entries[key]
+	if !ok {
+		return tradeEntry{}, false
+	}
+	entry := e.Value.(*tradeEntry)
+	if now.Sub(entry.at) > r.ttl {
+		return tradeEntry{}, false
+	}
+	return *entry, true
+}
+
+func tradeKeys(req interface{}) []string {
+	switch r := req.(type) {
+	case order.OpenPositionRequest:
+		if r.Comment != "" {
+			return []string{commentKey(r.Login, r.Comment)}
+		}
+	case order.PlacePendingOrderRequest:
+		if r.Comment != "" {
+			return []string{commentKey(r.Login, r.Comment)}
+		}
+	case order.ClosePositionRequest:
+		if r.Ticket != 0 {
+			return []string{positionKey(uint64(r.Ticket))}
+		}
+	}
+	return nil
+}
+
+func dealKeys(deal pumping.Mt5Deal) []string {
+	var keys []string
+	if deal.Comment != "" {
+		keys = append(keys, commentKey(deal.Login, deal.Comment))
+	}
+	if deal.PositionId != 0 {
+		keys = append(keys, positionKey(deal.PositionId))
+	}
+	return keys
+}
+
+func commentKey(login uint64, comment string) string {
+	return fmt.Sprintf("comment:%d:%s", login, comment)
+}
+
+func positionKey(ticket uint64) string {
+	return fmt.Sprintf("position:%d", ticket)
+}
@@ -0,0 +1,204 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	jsoniter "github.com/json-iterator/go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+const instrumentationName = "github.com/asaka1234/go-mt5-sdk/tracing"
+
+// span上的属性
+const (
+	AttrEndpoint     = attribute.Key("mt5.endpoint")         //接口名, 比如 OpenPosition
+	AttrLogin        = attribute.Key("mt5.login")            //请求涉及的login
+	AttrSymbol       = attribute.Key("mt5.symbol")           //请求涉及的symbol
+	AttrRetcode      = attribute.Key("mt5.retcode")          //网关返回的错误码
+	AttrPumpingType  = attribute.Key("mt5.pumping.type")     //pumping消息类型, 比如 deal/tick
+	AttrPumpingLagMs = attribute.Key("mt5.pumping.lag_ms")   //消息从服务端发出到开始处理的延迟(ms)
+	AttrDealID       = attribute.Key("mt5.deal_id")          //成交id, 在deal消息的link上
+	AttrTradeToDeal  = attribute.Key("mt5.trade_to_deal_ms") //交易请求结束到收到成交消息的耗时(ms), 在deal消息的link上
+	AttrAttempts     = attribute.Key("mt5.attempts")         //一次调用的http请求次数(包含重试)
+
+	attrHTTPMethod  = attribute.Key("http.request.method")
+	attrHTTPStatus  = attribute.Key("http.response.status_code")
+	attrResendCount = attribute.Key("http.request.resend_count")
+	attrURLPath     = attribute.Key("url.path")
+	attrServerAddr  = attribute.Key("server.address")
+)
+
+// Tracing REST调用和pumping消息的链路追踪
+// 同一个实例同时安装到 order.Client 和 pumping 上, 才能把交易请求和对应的成交消息关联起来
+type Tracing struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	trades     *tradeRegistry
+}
+
+// New tp为nil时使用 otel.GetTracerProvider()
+func New(tp trace.TracerProvider) *Tracing {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &Tracing{
+		tracer:     tp.Tracer(instrumentationName),
+		propagator: propagation.TraceContext{},
+		trades:     newTradeRegistry(10 * time.Minute),
+	}
+}
+
+// Middleware 每次接口调用创建一个span, 重试的每次http请求是它下面的子span(见 AttemptMiddleware)
+// 通过 direct.Client.Use / order.Client.Use 安装
+func (t *Tracing) Middleware() utils.Middleware {
+	return func(next utils.Handler) utils.Handler {
+		return func(ctx context.Context, call *utils.Call) error {
+			attrs := []attribute.KeyValue{
+				AttrEndpoint.String(call.Endpoint),
+				attrHTTPMethod.String(call.Method),
+				attrURLPath.String(call.Path),
+			}
+			if call.Login != 0 {
+				attrs = append(attrs, AttrLogin.Int64(int64(call.Login)))
+			}
+			if call.Symbol != "" {
+				attrs = append(attrs, AttrSymbol.String(call.Symbol))
+			}
+
+			ctx, span := t.tracer.Start(ctx, "MT5 "+call.Endpoint,
+				trace.WithSpanKind(trace.SpanKindInternal),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			//没有安装 AttemptMiddleware 时网关看到的是这个span
+			t.propagator.Inject(ctx, propagation.MapCarrier(call.Header))
+
+			err := next(ctx, call)
+
+			span.SetAttributes(AttrAttempts.Int(call.Attempts))
+			if call.StatusCode != 0 {
+				span.SetAttributes(attrHTTPStatus.Int(call.StatusCode))
+			}
+			if code, ok := utils.RetcodeOf(err); ok {
+				span.SetAttributes(AttrRetcode.Int(int(code)))
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			//请求可能已经落地的交易, 记下来等成交消息来了再关联
+			if err == nil || errors.Is(err, utils.ErrTradeLanded) || errors.Is(err, utils.ErrAmbiguousResult) {
+				t.trades.remember(call.Request, span.SpanContext(), time.Now())
+			}
+			return err
+		}
+	}
+}
+
+// AttemptMiddleware 每次http请求(包括重试)创建一个 Middleware 的子span, 并通过W3C traceparent header传给网关
+// 通过 direct.Client.UseAttempt / order.Client.UseAttempt 安装
+func (t *Tracing) AttemptMiddleware() utils.Middleware {
+	return func(next utils.Handler) utils.Handler {
+		return func(ctx context.Context, call *utils.Call) error {
+			ctx, span := t.tracer.Start(ctx, "MT5 "+call.Endpoint+" attempt",
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attrHTTPMethod.String(call.Method),
+					attrURLPath.String(call.Path),
+					attrServerAddr.String(call.Address),
+					attrResendCount.Int(call.Attempts-1),
+				),
+			)
+			defer span.End()
+
+			t.propagator.Inject(ctx, propagation.MapCarrier(call.Header))
+
+			err := next(ctx, call)
+
+			if call.StatusCode != 0 {
+				span.SetAttributes(attrHTTPStatus.Int(call.StatusCode))
+			}
+			if code, ok := utils.RetcodeOf(err); ok {
+				span.SetAttributes(AttrRetcode.Int(int(code)))
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
+
+// DispatchHook 每条pumping消息创建一个span, 成交(deal)消息会link到对应交易请求的span
+// 通过 SubscriptionManager.SetDispatchHook 安装
+func (t *Tracing) DispatchHook() pumping.DispatchHook {
+	return func(response *pumping.TCPResponse, dispatch func() error) error {
+		now := time.Now()
+
+		var links []trace.Link
+		if pumping.REQUEST_TYPE(response.Type) == pumping.REQUEST_TYPE_DEAL {
+			links = t.dealLinks(response, now)
+		}
+
+		_, span := t.tracer.Start(context.Background(), "MT5 pumping "+response.Type,
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithLinks(links...),
+			trace.WithAttributes(AttrPumpingType.String(response.Type)),
+		)
+		defer span.End()
+
+		//Timestamp 是服务端发出消息的时间(ms)
+		if response.Timestamp > 0 {
+			span.SetAttributes(AttrPumpingLagMs.Int64(now.UnixMilli() - response.Timestamp))
+		}
+
+		err := dispatch()
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+}
+
+// dealLinks 找到成交消息对应的交易请求
+func (t *Tracing) dealLinks(response *pumping.TCPResponse, now time.Time) []trace.Link {
+	payloadJSON, err := jsoniter.Marshal(response.Payload)
+	if err != nil {
+		return nil
+	}
+	var deals []pumping.Mt5DealExtra
+	if err := jsoniter.Unmarshal(payloadJSON, &deals); err != nil {
+		return nil
+	}
+
+	var links []trace.Link
+	seen := make(map[trace.SpanID]bool)
+	for _, deal := range deals {
+		for _, key := range dealKeys(deal.Mt5Deal) {
+			entry, ok := t.trades.lookup(key, now)
+			if !ok || seen[entry.sc.SpanID()] {
+				continue
+			}
+			seen[entry.sc.SpanID()] = true
+			links = append(links, trace.Link{
+				SpanContext: entry.sc,
+				Attributes: []attribute.KeyValue{
+					AttrDealID.Int64(int64(deal.DealId)),
+					AttrTradeToDeal.Int64(now.Sub(entry.at).Milliseconds()),
+				},
+			})
+		}
+	}
+	return links
+}
@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/order"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 每次重试是调用span下面单独的子span, 网关收到的traceparent是对应那次尝试的span
+func TestAttemptSpans(t *testing.T) {
+	var mu sync.Mutex
+	var parents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		parents = append(parents, r.Header.Get("traceparent"))
+		n := len(parents)
+		mu.Unlock()
+		if n == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":0,"success":true}`))
+	}))
+	defer srv.Close()
+
+	tp, exporter := NewInMemoryProvider()
+	tr := New(tp)
+	cli := utils.NewRestClient(nil, &utils.ClientParams{Address: srv.URL, Retry: &utils.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}})
+	cli.Use(tr.Middleware())
+	cli.UseAttempt(tr.AttemptMiddleware())
+
+	call := &utils.Call{Endpoint: "ListSymbol", Method: http.MethodGet, Path: "/v1/symbol/list", Response: &utils.CommonResp{}}
+	if err := cli.Do(context.Background(), call); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("%d spans, want 1 call span and 2 attempt spans", len(spans))
+	}
+	//子span先结束
+	first, second, parent := spans[0], spans[1], spans[2]
+	if parent.Name != "MT5 ListSymbol" || parent.SpanKind != trace.SpanKindInternal {
+		t.Fatalf("call span = %s (%s)", parent.Name, parent.SpanKind)
+	}
+	for i, attempt := range []struct {
+		status  int
+		errored bool
+	}{{http.StatusBadGateway, true}, {http.StatusOK, false}} {
+		span := spans[i]
+		if span.Name != "MT5 ListSymbol attempt" || span.SpanKind != trace.SpanKindClient || span.Parent.SpanID() != parent.SpanContext.SpanID() {
+			t.Fatalf("attempt %d: %s (%s) parent %s", i+1, span.Name, span.SpanKind, span.Parent.SpanID())
+		}
+		attrs := map[string]string{}
+		for _, kv := range span.Attributes {
+			attrs[string(kv.Key)] = kv.Value.Emit()
+		}
+		if attrs["http.response.status_code"] != fmt.Sprint(attempt.status) || attrs["http.request.resend_count"] != fmt.Sprint(i) || attrs["server.address"] != srv.URL {
+			t.Fatalf("attempt %d attributes = %v", i+1, attrs)
+		}
+		if (len(span.Events) > 0) != attempt.errored {
+			t.Fatalf("attempt %d events = %v", i+1, span.Events)
+		}
+		if !strings.Contains(parents[i], span.SpanContext.SpanID().String()) {
+			t.Fatalf("request %d traceparent %q, want span %s", i+1, parents[i], span.SpanContext.SpanID())
+		}
+	}
+	if first.SpanContext.SpanID() == second.SpanContext.SpanID() {
+		t.Fatal("attempts share a span")
+	}
+}
+
+func TestTradeRegistrySweep(t *testing.T) {
+	r := newTradeRegistry(time.Minute)
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}})
+	start := time.Now()
+
+	r.remember(order.ClosePositionRequest{Ticket: 1}, sc, start)
+	r.remember(order.ClosePositionRequest{Ticket: 2}, sc, start.Add(30*time.Second))
+	if _, ok := r.lookup(positionKey(1), start.Add(59*time.Second)); !ok {
+		t.Fatal("entry expired too early")
+	}
+
+	//过期的记录不用等到数量上限就会被清理
+	r.remember(order.ClosePositionRequest{Ticket: 3}, sc, start.Add(61*time.Second))
+	if _, ok := r.entries[positionKey(1)]; ok || len(r.entries) != 2 || r.order.Len() != 2 {
+		t.Fatalf("entries = %d, expired entry was not swept", len(r.entries))
+	}
+
+	//同一个key重新记录, 时间以最后一次为准
+	r.remember(order.ClosePositionRequest{Ticket: 2}, sc, start.Add(80*time.Second))
+	if _, ok := r.lookup(positionKey(2), start.Add(130*time.Second)); !ok {
+		t.Fatal("re-remembered entry used the old timestamp")
+	}
+}
+
+func TestTradeRegistryCap(t *testing.T) {
+	r := newTradeRegistry(time.Hour)
+	r.maxEntries = 3
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}})
+	now := time.Now()
+
+	for ticket := 1; ticket <= 10; ticket++ {
+		r.remember(order.ClosePositionRequest{Ticket: ticket}, sc, now.Add(time.Duration(ticket)*time.Second))
+		if len(r.entries) > 3 || r.order.Len() != len(r.entries) {
+			t.Fatalf("%d entries (list %d), cap is 3", len(r.entries), r.order.Len())
+		}
+	}
+	//都没有过期, 淘汰的是最早的
+	for ticket := uint64(1); ticket <= 10; ticket++ {
+		_, ok := r.lookup(positionKey(ticket), now.Add(time.Minute))
+		if ok != (ticket > 7) {
+			t.Fatalf("ticket %d kept = %v", ticket, ok)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIError 网关返回失败(success=false 或 http状态码非200)时返回的错误
+// 可以用 errors.As 拿到详细信息, 或者 errors.Is(err, utils.RetcodeNoMoney) 判断具体的错误码
+type APIError struct {
+	Code       int    `json:"code"`        //网关返回的错误码(一般是mt5的retcode), 0表示没有
+	Message    string `json:"message"`     //网关返回的错误信息
+	Endpoint   string `json:"endpoint"`    //请求的接口, 比如 /v1/position/open
+	HTTPStatus int    `json:"http_status"` //http状态码
+	Body       []byte `json:"-"`           //原始返回内容
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.HTTPStatus)
+	}
+	if e.Code != 0 {
+		return fmt.Sprintf("mt5 %s: code=%d(%s) status=%d: %s", e.Endpoint, e.Code, Retcode(e.Code).String(), e.HTTPStatus, msg)
+	}
+	return fmt.Sprintf("mt5 %s: status=%d: %s", e.Endpoint, e.HTTPStatus, msg)
+}
+
+// Unwrap 返回对应的Retcode, 使 errors.Is(err, RetcodeXxx) 可用
+func (e *APIError) Unwrap() error {
+	if e.Code == 0 {
+		return nil
+	}
+	return Retcode(e.Code)
+}
+
+// Retcode 返回错误码对应的Retcode
+func (e *APIError) Retcode() Retcode {
+	return Retcode(e.Code)
+}
+
+// AsAPIError 从err链上取出APIError
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// RetcodeOf 从err链上取出mt5的retcode
+func RetcodeOf(err error) (Retcode, bool) {
+	var code Retcode
+	if errors.As(err, &code) {
+		return code, true
+	}
+	return 0, false
+}
+
+// HTTPStatusOf 请求结果对应的http状态码, 成功为200, 没有收到返回(网络错误等)为0
+func HTTPStatusOf(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.HTTPStatus
+	}
+	return 0
+}
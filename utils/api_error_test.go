@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	noMoney := &APIError{Code: int(RetcodeNoMoney), Message: "not enough money", Endpoint: "/v1/position/open", HTTPStatus: http.StatusOK}
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{"direct", noMoney, RetcodeNoMoney, true},
+		{"wrapped", fmt.Errorf("open: %w", noMoney), RetcodeNoMoney, true},
+		{"other retcode", noMoney, RetcodeInvalidVolume, false},
+		{"ambiguous wraps retcode", &AmbiguousError{Endpoint: "/v1/position/open", Err: noMoney}, RetcodeNoMoney, true},
+		{"no code", &APIError{Endpoint: "/v1/symbol/list", HTTPStatus: http.StatusBadGateway}, RetcodeOK, false},
+		{"retcode itself", RetcodeNotFound, RetcodeNotFound, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Fatalf("errors.Is(%v, %v) = %v, want %v", tt.err, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIErrorAccessors(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &APIError{Code: int(RetcodeInvalidStops), Endpoint: "/v1/position/modify", HTTPStatus: http.StatusOK})
+
+	apiErr, ok := AsAPIError(err)
+	if !ok || apiErr.Retcode() != RetcodeInvalidStops {
+		t.Fatalf("AsAPIError = %v, %v", apiErr, ok)
+	}
+	if code, ok := RetcodeOf(err); !ok || code != RetcodeInvalidStops {
+		t.Fatalf("RetcodeOf = %v, %v", code, ok)
+	}
+	if _, ok := RetcodeOf(errors.New("eof")); ok {
+		t.Fatal("RetcodeOf found a retcode in a plain error")
+	}
+
+	for _, tt := range []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusOK},
+		{err, http.StatusOK},
+		{&APIError{HTTPStatus: http.StatusServiceUnavailable}, http.StatusServiceUnavailable},
+		{context.DeadlineExceeded, 0},
+	} {
+		if got := HTTPStatusOf(tt.err); got != tt.want {
+			t.Errorf("HTTPStatusOf(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *APIError
+		want string
+	}{
+		{&APIError{Code: 10019, Message: "no money", Endpoint: "/v1/position/open", HTTPStatus: 200}, "mt5 /v1/position/open: code=10019(MT_RET_REQUEST_NO_MONEY) status=200: no money"},
+		{&APIError{Code: 99999, Message: "x", Endpoint: "/v1/a", HTTPStatus: 200}, "mt5 /v1/a: code=99999(MT_RET_99999) status=200: x"},
+		{&APIError{Endpoint: "/v1/a", HTTPStatus: 502}, "mt5 /v1/a: status=502: Bad Gateway"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+// 网关返回的失败(success=false 或 非200)都要变成可以 errors.Is 的 APIError
+func TestRestClientAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/position/open":
+			w.Write([]byte(`{"code":10019,"success":false,"message":"not enough money"}`))
+		case "/v1/symbol/list":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":3,"success":false,"message":"bad params"}`))
+		}
+	}))
+	defer srv.Close()
+	cli := NewRestClient(nil, &ClientParams{Address: srv.URL, Retry: &RetryPolicy{MaxAttempts: 1}})
+
+	var resp CommonResp
+	err := cli.Do(context.Background(), &Call{Endpoint: "OpenPosition", Method: http.MethodPost, Path: "/v1/position/open", Request: map[string]int{"login": 1}, Response: &resp})
+	if !errors.Is(err, RetcodeNoMoney) {
+		t.Fatalf("business failure: err = %v, want RetcodeNoMoney", err)
+	}
+
+	err = cli.Do(context.Background(), &Call{Endpoint: "ListSymbol", Method: http.MethodGet, Path: "/v1/symbol/list", Response: &resp})
+	apiErr, ok := AsAPIError(err)
+	if !ok || apiErr.HTTPStatus != http.StatusBadRequest || !errors.Is(err, RetcodeInvalidParams) {
+		t.Fatalf("http failure: err = %v", err)
+	}
+}
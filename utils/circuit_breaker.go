@@ -0,0 +1,280 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitState 熔断器状态
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota //正常放行
+	CircuitOpen                         //熔断中, 请求直接失败
+	CircuitHalfOpen                     //半开, 放少量请求探测网关是否恢复
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerConfig 熔断配置, 零值字段使用默认值
+type CircuitBreakerConfig struct {
+	Window           time.Duration `json:"window" mapstructure:"window" config:"window" yaml:"window"`                                                     //统计窗口, 默认10s
+	MinRequests      int           `json:"min_requests" mapstructure:"min_requests" config:"min_requests" yaml:"min_requests"`                             //窗口内请求数达到该值才会判断是否熔断, 默认10
+	FailureRatio     float64       `json:"failure_ratio" mapstructure:"failure_ratio" config:"failure_ratio" yaml:"failure_ratio"`                         //失败率达到该值熔断, 默认0.5
+	SlowCallDuration time.Duration `json:"slow_call_duration" mapstructure:"slow_call_duration" config:"slow_call_duration" yaml:"slow_call_duration"`     //耗时超过该值算慢请求, 0则不按延迟熔断
+	SlowCallRatio    float64       `json:"slow_call_ratio" mapstructure:"slow_call_ratio" config:"slow_call_ratio" yaml:"slow_call_ratio"`                 //慢请求比例达到该值熔断, 默认0.5
+	OpenTimeout      time.Duration `json:"open_timeout" mapstructure:"open_timeout" config:"open_timeout" yaml:"open_timeout"`                             //熔断多久之后进入半开状态, 默认30s
+	HalfOpenMaxCalls int           `json:"half_open_max_calls" mapstructure:"half_open_max_calls" config:"half_open_max_calls" yaml:"half_open_max_calls"` //半开状态放行的探测请求数, 全部成功则恢复, 默认1
+}
+
+// ErrCircuitOpen 熔断中, 请求没有发出去
+var ErrCircuitOpen = errors.New("mt5: circuit breaker is open")
+
+// CircuitOpenError 熔断时返回的错误, errors.Is(err, ErrCircuitOpen) 为true
+type CircuitOpenError struct {
+	Name       string        //熔断器名字
+	RetryAfter time.Duration //大约多久之后进入半开状态
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("mt5 %s: circuit breaker is open, retry after %v", e.Name, e.RetryAfter)
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// circuitBuckets 统计窗口切分成多少个桶
+const circuitBuckets = 10
+
+type circuitBucket struct {
+	start    time.Time
+	total    int
+	failures int
+	slow     int
+}
+
+// CircuitBreaker 熔断器, 网关出问题时快速失败, 避免所有goroutine都卡在超时上
+// 只有网络错误/超时/5xx算失败, 业务错误(余额不足等)说明网关是正常的, 算成功
+type CircuitBreaker struct {
+	name          string
+	cfg           CircuitBreakerConfig
+	logger        Logger
+	onStateChange func(name string, from, to CircuitState)
+
+	mu              sync.Mutex
+	state           CircuitState
+	openedAt        time.Time
+	buckets         [circuitBuckets]circuitBucket
+	halfOpenCalls   int //半开状态已经放行的请求数
+	halfOpenSuccess int //半开状态成功的请求数
+}
+
+// NewCircuitBreaker cfg为nil时返回nil(不熔断), logger可以为nil
+func NewCircuitBreaker(name string, cfg *CircuitBreakerConfig, logger Logger) *CircuitBreaker {
+	if cfg == nil {
+		return nil
+	}
+	c := *cfg
+	c.Window = durationOr(c.Window, 10*time.Second)
+	c.MinRequests = intOr(c.MinRequests, 10)
+	c.OpenTimeout = durationOr(c.OpenTimeout, 30*time.Second)
+	c.HalfOpenMaxCalls = intOr(c.HalfOpenMaxCalls, 1)
+	if c.FailureRatio <= 0 {
+		c.FailureRatio = 0.5
+	}
+	if c.SlowCallRatio <= 0 {
+		c.SlowCallRatio = 0.5
+	}
+	return &CircuitBreaker{
+		name:   name,
+		cfg:    c,
+		logger: logger,
+	}
+}
+
+// SetOnStateChange 状态变化时的回调(在锁外调用)
+func (cb *CircuitBreaker) SetOnStateChange(fn func(name string, from, to CircuitState)) {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.onStateChange = fn
+}
+
+// State 当前状态, nil表示没有开启熔断, 返回closed
+func (cb *CircuitBreaker) State() CircuitState {
+	if cb == nil {
+		return CircuitClosed
+	}
+	cb.mu.Lock()
+	state, changed := cb.currentState(time.Now())
+	cb.mu.Unlock()
+
+	cb.notify(changed, CircuitOpen, state)
+	return state
+}
+
+// Allow 请求之前调用, 熔断中返回 *CircuitOpenError; 返回nil时请求结束后必须调用 Done
+func (cb *CircuitBreaker) Allow() error {
+	if cb == nil {
+		return nil
+	}
+
+	cb.mu.Lock()
+	now := time.Now()
+	state, changed := cb.currentState(now)
+	var err error
+	switch state {
+	case CircuitOpen:
+		err = &CircuitOpenError{Name: cb.name, RetryAfter: cb.cfg.OpenTimeout - now.Sub(cb.openedAt)}
+	case CircuitHalfOpen:
+		if cb.halfOpenCalls >= cb.cfg.HalfOpenMaxCalls {
+			err = &CircuitOpenError{Name: cb.name}
+		} else {
+			cb.halfOpenCalls++
+		}
+	}
+	cb.mu.Unlock()
+
+	cb.notify(changed, CircuitOpen, state)
+	return err
+}
+
+// Done 记录一次请求的结果, 调用方自己取消的请求(context.Canceled)不计入统计
+func (cb *CircuitBreaker) Done(err error, latency time.Duration) {
+	if cb == nil {
+		return
+	}
+
+	cb.mu.Lock()
+	from := cb.state
+	ignored := errors.Is(err, context.Canceled)
+	failed := !ignored && isGatewayFailure(err)
+	slow := !ignored && cb.cfg.SlowCallDuration > 0 && latency >= cb.cfg.SlowCallDuration
+
+	switch cb.state {
+	case CircuitHalfOpen:
+		if cb.halfOpenCalls > 0 {
+			cb.halfOpenCalls--
+		}
+		switch {
+		case ignored:
+		case failed || slow:
+			cb.toOpen(time.Now())
+		default:
+			cb.halfOpenSuccess++
+			if cb.halfOpenSuccess >= cb.cfg.HalfOpenMaxCalls {
+				cb.toClosed()
+			}
+		}
+	case CircuitClosed:
+		if !ignored {
+			now := time.Now()
+			b := cb.bucket(now)
+			b.total++
+			if failed {
+				b.failures++
+			}
+			if slow {
+				b.slow++
+			}
+			if cb.shouldTrip(now) {
+				cb.toOpen(now)
+			}
+		}
+	}
+	to := cb.state
+	cb.mu.Unlock()
+
+	cb.notify(from != to, from, to)
+}
+
+// isGatewayFailure 只有网关不可用类的错误才计入失败
+func isGatewayFailure(err error) bool {
+	switch ClassifyError(err) {
+	case ErrorClassNotExecuted, ErrorClassAmbiguous:
+		return true
+	}
+	return false
+}
+
+// currentState 熔断超时后进入半开, 调用方需要持有锁
+func (cb *CircuitBreaker) currentState(now time.Time) (CircuitState, bool) {
+	if cb.state == CircuitOpen && now.Sub(cb.openedAt) >= cb.cfg.OpenTimeout {
+		cb.state = CircuitHalfOpen
+		cb.halfOpenCalls = 0
+		cb.halfOpenSuccess = 0
+		return cb.state, true
+	}
+	return cb.state, false
+}
+
+func (cb *CircuitBreaker) toOpen(now time.Time) {
+	cb.state = CircuitOpen
+	cb.openedAt = now
+}
+
+func (cb *CircuitBreaker) toClosed() {
+	cb.state = CircuitClosed
+	cb.buckets = [circuitBuckets]circuitBucket{}
+}
+
+// bucket 返回当前时间所在的桶, 过期的桶会被重置
+func (cb *CircuitBreaker) bucket(now time.Time) *circuitBucket {
+	width := cb.cfg.Window / circuitBuckets
+	start := now.Truncate(width)
+	b := &cb.buckets[(start.UnixNano()/int64(width))%circuitBuckets]
+	if !b.start.Equal(start) {
+		*b = circuitBucket{start: start}
+	}
+	return b
+}
+
+func (cb *CircuitBreaker) shouldTrip(now time.Time) bool {
+	var total, failures, slow int
+	for _, b := range cb.buckets {
+		if now.Sub(b.start) < cb.cfg.Window {
+			total += b.total
+			failures += b.failures
+			slow += b.slow
+		}
+	}
+	if total < cb.cfg.MinRequests {
+		return false
+	}
+	if float64(failures)/float64(total) >= cb.cfg.FailureRatio {
+		return true
+	}
+	return cb.cfg.SlowCallDuration > 0 && float64(slow)/float64(total) >= cb.cfg.SlowCallRatio
+}
+
+// notify 在锁外调用, 输出日志并回调
+func (cb *CircuitBreaker) notify(changed bool, from, to CircuitState) {
+	if !changed {
+		return
+	}
+	if cb.logger != nil {
+		cb.logger.Warnf("MT5#CircuitBreaker->%s state changed: %s -> %s", cb.name, from, to)
+	}
+	cb.mu.Lock()
+	fn := cb.onStateChange
+	cb.mu.Unlock()
+	if fn != nil {
+		fn(cb.name, from, to)
+	}
+}
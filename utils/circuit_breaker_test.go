@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	gatewayDown  = &APIError{HTTPStatus: http.StatusBadGateway}
+	businessFail = &APIError{Code: int(RetcodeNoMoney), HTTPStatus: http.StatusOK}
+)
+
+type transitions struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (tr *transitions) record(name string, from, to CircuitState) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.events = append(tr.events, fmt.Sprintf("%s:%s->%s", name, from, to))
+}
+
+func (tr *transitions) String() string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return fmt.Sprint(tr.events)
+}
+
+func run(t *testing.T, cb *CircuitBreaker, err error, latency time.Duration) {
+	t.Helper()
+	if aerr := cb.Allow(); aerr != nil {
+		t.Fatalf("Allow() in state %s: %v", cb.State(), aerr)
+	}
+	cb.Done(err, latency)
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	cb := NewCircuitBreaker("gw", &CircuitBreakerConfig{MinRequests: 4, FailureRatio: 0.5, OpenTimeout: 50 * time.Millisecond, HalfOpenMaxCalls: 2}, nil)
+	var tr transitions
+	cb.SetOnStateChange(tr.record)
+
+	//业务错误说明网关正常, 取消的请求不计入
+	run(t, cb, businessFail, 0)
+	run(t, cb, nil, 0)
+	run(t, cb, gatewayDown, 0)
+	run(t, cb, context.Canceled, 0)
+	if cb.State() != CircuitClosed {
+		t.Fatalf("state = %s after 1/3 failures", cb.State())
+	}
+	run(t, cb, gatewayDown, 0)
+	if cb.State() != CircuitOpen {
+		t.Fatalf("state = %s after 2/4 failures, want open", cb.State())
+	}
+
+	err := cb.Allow()
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || !errors.Is(err, ErrCircuitOpen) || openErr.Name != "gw" || openErr.RetryAfter <= 0 {
+		t.Fatalf("Allow() while open = %v", err)
+	}
+
+	//熔断超时后半开, 只放行 HalfOpenMaxCalls 个请求
+	time.Sleep(60 * time.Millisecond)
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("first half-open call: %v", err)
+	}
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("second half-open call: %v", err)
+	}
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("third half-open call = %v, want rejected", err)
+	}
+	cb.Done(nil, 0)
+	cb.Done(nil, 0)
+	if cb.State() != CircuitClosed {
+		t.Fatalf("state = %s after successful probes, want closed", cb.State())
+	}
+
+	want := "[gw:closed->open gw:open->half-open gw:half-open->closed]"
+	if got := tr.String(); got != want {
+		t.Fatalf("transitions = %s, want %s", got, want)
+	}
+}
+
+func TestCircuitBreakerHalfOpenFailure(t *testing.T) {
+	cb := NewCircuitBreaker("gw", &CircuitBreakerConfig{MinRequests: 1, OpenTimeout: 20 * time.Millisecond}, nil)
+	run(t, cb, gatewayDown, 0)
+	time.Sleep(30 * time.Millisecond)
+	run(t, cb, gatewayDown, 0)
+	if cb.State() != CircuitOpen {
+		t.Fatalf("state = %s after failed probe, want open", cb.State())
+	}
+	//重新计时
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() right after reopening = %v", err)
+	}
+}
+
+func TestCircuitBreakerSlowCalls(t *testing.T) {
+	cb := NewCircuitBreaker("gw", &CircuitBreakerConfig{MinRequests: 2, SlowCallDuration: 100 * time.Millisecond, SlowCallRatio: 1}, nil)
+	run(t, cb, nil, 200*time.Millisecond)
+	run(t, cb, nil, 10*time.Millisecond)
+	run(t, cb, nil, 200*time.Millisecond)
+	if cb.State() != CircuitClosed {
+		t.Fatalf("state = %s with 2/3 slow calls", cb.State())
+	}
+
+	cb = NewCircuitBreaker("gw", &CircuitBreakerConfig{MinRequests: 2, SlowCallDuration: 100 * time.Millisecond, SlowCallRatio: 1}, nil)
+	run(t, cb, nil, 200*time.Millisecond)
+	run(t, cb, nil, 200*time.Millisecond)
+	if cb.State() != CircuitOpen {
+		t.Fatalf("state = %s with all calls slow, want open", cb.State())
+	}
+}
+
+func TestCircuitBreakerNil(t *testing.T) {
+	cb := NewCircuitBreaker("gw", nil, nil)
+	if cb != nil {
+		t.Fatal("NewCircuitBreaker(nil) should disable the breaker")
+	}
+	if err := cb.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	cb.Done(gatewayDown, 0)
+	cb.SetOnStateChange(nil)
+	if cb.State() != CircuitClosed {
+		t.Fatal("nil breaker is not closed")
+	}
+}
+
+// 每个网关一个熔断器, 一个网关熔断不影响其他网关
+func TestRestClientBreakerPerGateway(t *testing.T) {
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":0,"success":true}`))
+	}))
+	defer good.Close()
+
+	cli := NewRestClient(nil, &ClientParams{
+		Addresses:      []string{bad.URL, good.URL},
+		Retry:          &RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond},
+		Failover:       &FailoverConfig{HealthInterval: time.Hour, FailThreshold: 100},
+		CircuitBreaker: &CircuitBreakerConfig{MinRequests: 2, OpenTimeout: time.Hour},
+	})
+	defer cli.Close()
+	var tr transitions
+	cli.SetCircuitStateListener(tr.record)
+
+	for i := 0; i < 6; i++ {
+		call := &Call{Endpoint: "ListSymbol", Method: http.MethodGet, Path: "/v1/symbol/list", Response: &CommonResp{}}
+		if err := cli.Do(context.Background(), call); err != nil {
+			t.Fatalf("query %d: %v", i, err)
+		}
+	}
+
+	states := cli.CircuitStates()
+	if states[bad.URL] != CircuitOpen || states[good.URL] != CircuitClosed {
+		t.Fatalf("states = %v", states)
+	}
+	if want := fmt.Sprintf("[%s:closed->open]", bad.URL); tr.String() != want {
+		t.Fatalf("transitions = %s, want %s", tr.String(), want)
+	}
+	//交易网关还是主网关(健康检查没有判定它不健康), 它的熔断器是打开的
+	if cli.CircuitState() != CircuitOpen {
+		t.Fatalf("CircuitState() = %s, want the trade gateway's state", cli.CircuitState())
+	}
+	err := cli.Do(context.Background(), &Call{Endpoint: "OpenPosition", Method: http.MethodPost, Path: "/v1/position/open", Request: map[string]int{}, Response: &CommonResp{}})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("trade on open breaker: err = %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+package utils
+
+import "github.com/vmihailenco/msgpack/v5"
+
+// DealAction 成交类型, 除了买卖还有出入金/信用/佣金等
+// https://support.metaquotes.net/en/docs/mt5/api/reference_trading/trading_deal/imtdeal/imtdeal_enum#endealaction
+type DealAction int
+
+const (
+	DealActionBuy                  DealAction = 0  //DEAL_BUY
+	DealActionSell                 DealAction = 1  //DEAL_SELL
+	DealActionBalance              DealAction = 2  //DEAL_BALANCE 出入金
+	DealActionCredit               DealAction = 3  //DEAL_CREDIT 信用
+	DealActionCharge               DealAction = 4  //DEAL_CHARGE 其他费用
+	DealActionCorrection           DealAction = 5  //DEAL_CORRECTION 调整
+	DealActionBonus                DealAction = 6  //DEAL_BONUS 赠金
+	DealActionCommission           DealAction = 7  //DEAL_COMMISSION 佣金
+	DealActionCommissionDaily      DealAction = 8  //DEAL_COMMISSION_DAILY
+	DealActionCommissionMonthly    DealAction = 9  //DEAL_COMMISSION_MONTHLY
+	DealActionAgentDaily           DealAction = 10 //DEAL_AGENT_DAILY
+	DealActionAgentMonthly         DealAction = 11 //DEAL_AGENT_MONTHLY
+	DealActionInterestRate         DealAction = 12 //DEAL_INTERESTRATE 利息
+	DealActionBuyCanceled          DealAction = 13 //DEAL_BUY_CANCELED 取消的buy成交
+	DealActionSellCanceled         DealAction = 14 //DEAL_SELL_CANCELED 取消的sell成交
+	DealActionDividend             DealAction = 15 //DEAL_DIVIDEND 分红
+	DealActionDividendFranked      DealAction = 16 //DEAL_DIVIDEND_FRANKED
+	DealActionTax                  DealAction = 17 //DEAL_TAX 税
+	DealActionAgent                DealAction = 18 //DEAL_AGENT 代理佣金
+	DealActionSOCompensation       DealAction = 19 //DEAL_SO_COMPENSATION 强平负余额补偿
+	DealActionSOCompensationCredit DealAction = 20 //DEAL_SO_COMPENSATION_CREDIT
+)
+
+var dealActions = newEnum("deal action", map[DealAction]string{
+	DealActionBuy:                  "buy",
+	DealActionSell:                 "sell",
+	DealActionBalance:              "balance",
+	DealActionCredit:               "credit",
+	DealActionCharge:               "charge",
+	DealActionCorrection:           "correction",
+	DealActionBonus:                "bonus",
+	DealActionCommission:           "commission",
+	DealActionCommissionDaily:      "commission_daily",
+	DealActionCommissionMonthly:    "commission_monthly",
+	DealActionAgentDaily:           "agent_daily",
+	DealActionAgentMonthly:         "agent_monthly",
+	DealActionInterestRate:         "interest_rate",
+	DealActionBuyCanceled:          "buy_canceled",
+	DealActionSellCanceled:         "sell_canceled",
+	DealActionDividend:             "dividend",
+	DealActionDividendFranked:      "dividend_franked",
+	DealActionTax:                  "tax",
+	DealActionAgent:                "agent",
+	DealActionSOCompensation:       "so_compensation",
+	DealActionSOCompensationCredit: "so_compensation_credit",
+})
+
+func ParseDealAction(s string) (DealAction, error) {
+	return dealActions.parse(s)
+}
+
+func (a DealAction) String() string {
+	return dealActions.name(a)
+}
+
+func (a DealAction) IsValid() bool {
+	return dealActions.valid(a)
+}
+
+func (a DealAction) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *DealAction) UnmarshalText(text []byte) error {
+	return dealActions.unmarshalText(text, a)
+}
+
+func (a DealAction) MarshalJSON() ([]byte, error) {
+	return dealActions.marshalJSON(a)
+}
+
+func (a *DealAction) UnmarshalJSON(data []byte) error {
+	return dealActions.unmarshalJSON(data, a)
+}
+
+func (a DealAction) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return dealActions.encodeMsgpack(enc, a)
+}
+
+func (a *DealAction) DecodeMsgpack(dec *msgpack.Decoder) error {
+	return dealActions.decodeMsgpack(dec, a)
+}
+
+// IsTrade 买卖成交(影响持仓), 其他都是余额类的操作
+func (a DealAction) IsTrade() bool {
+	return a == DealActionBuy || a == DealActionSell
+}
+
+func (a DealAction) IsBuy() bool {
+	return a == DealActionBuy
+}
+func (a DealAction) IsSell() bool {
+	return a == DealActionSell
+}
+
+// DealEntry 成交对持仓的影响
+// https://support.metaquotes.net/en/docs/mt5/api/reference_trading/trading_deal/imtdeal/imtdeal_enum#enentry
+type DealEntry int
+
+const (
+	DealEntryIn    DealEntry = 0 //ENTRY_IN 开仓/加仓
+	DealEntryOut   DealEntry = 1 //ENTRY_OUT 平仓/减仓
+	DealEntryInOut DealEntry = 2 //ENTRY_INOUT 反手
+	DealEntryOutBy DealEntry = 3 //ENTRY_OUT_BY 用反向持仓平仓
+)
+
+var dealEntrys = newEnum("deal entry", map[DealEntry]string{
+	DealEntryIn:    "in",
+	DealEntryOut:   "out",
+	DealEntryInOut: "inout",
+	DealEntryOutBy: "out_by",
+})
+
+func ParseDealEntry(s string) (DealEntry, error) {
+	return dealEntrys.parse(s)
+}
+
+func (e DealEntry) String() string {
+	return dealEntrys.name(e)
+}
+
+func (e DealEntry) IsValid() bool {
+	return dealEntrys.valid(e)
+}
+
+func (e DealEntry) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+func (e *DealEntry) UnmarshalText(text []byte) error {
+	return dealEntrys.unmarshalText(text, e)
+}
+
+func (e DealEntry) MarshalJSON() ([]byte, error) {
+	return dealEntrys.marshalJSON(e)
+}
+
+func (e *DealEntry) UnmarshalJSON(data []byte) error {
+	return dealEntrys.unmarshalJSON(data, e)
+}
+
+func (e DealEntry) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return dealEntrys.encodeMsgpack(enc, e)
+}
+
+func (e *DealEntry) DecodeMsgpack(dec *msgpack.Decoder) error {
+	return dealEntrys.decodeMsgpack(dec, e)
+}
+
+// IsIn 开仓(包括反手)
+func (e DealEntry) IsIn() bool {
+	return e == DealEntryIn || e == DealEntryInOut
+}
+
+// IsOut 平仓(包括反手), 盈亏在这类成交上
+func (e DealEntry) IsOut() bool {
+	return e == DealEntryOut || e == DealEntryInOut || e == DealEntryOutBy
+}
+
+// DealReason 成交的原因
+// https://support.metaquotes.net/en/docs/mt5/api/reference_trading/trading_deal/imtdeal/imtdeal_enum#endealreason
+type DealReason uint
+
+const (
+	DealReasonClient          DealReason = 0  //DEAL_REASON_CLIENT 客户端终端
+	DealReasonExpert          DealReason = 1  //DEAL_REASON_EXPERT EA
+	DealReasonDealer          DealReason = 2  //DEAL_REASON_DEALER 交易员
+	DealReasonSL              DealReason = 3  //DEAL_REASON_SL 止损
+	DealReasonTP              DealReason = 4  //DEAL_REASON_TP 止盈
+	DealReasonSO              DealReason = 5  //DEAL_REASON_SO 强平
+	DealReasonRollover        DealReason = 6  //DEAL_REASON_ROLLOVER
+	DealReasonExternalClient  DealReason = 7  //DEAL_REASON_EXTERNAL_CLIENT
+	DealReasonVMargin         DealReason = 8  //DEAL_REASON_VMARGIN
+	DealReasonGateway         DealReason = 9  //DEAL_REASON_GATEWAY
+	DealReasonSignal          DealReason = 10 //DEAL_REASON_SIGNAL
+	DealReasonSettlement      DealReason = 11 //DEAL_REASON_SETTLEMENT
+	DealReasonTransfer        DealReason = 12 //DEAL_REASON_TRANSFER
+	DealReasonSync            DealReason = 13 //DEAL_REASON_SYNC
+	DealReasonExternalService DealReason = 14 //DEAL_REASON_EXTERNAL_SERVICE
+	DealReasonMigration       DealReason = 15 //DEAL_REASON_MIGRATION
+	DealReasonMobile          DealReason = 16 //DEAL_REASON_MOBILE 手机终端
+	DealReasonWeb             DealReason = 17 //DEAL_REASON_WEB 网页终端
+	DealReasonSplit           DealReason = 18 //DEAL_REASON_SPLIT 拆股
+	DealReasonCorporateAction DealReason = 19 //DEAL_REASON_CORPORATE_ACTION
+)
+
+var dealReasons = newEnum("deal reason", map[DealReason]string{
+	DealReasonClient:          "client",
+	DealReasonExpert:          "expert",
+	DealReasonDealer:          "dealer",
+	DealReasonSL:              "sl",
+	DealReasonTP:              "tp",
+	DealReasonSO:              "so",
+	DealReasonRollover:        "rollover",
+	DealReasonExternalClient:  "external_client",
+	DealReasonVMargin:         "vmargin",
+	DealReasonGateway:         "gateway",
+	DealReasonSignal:          "signal",
+	DealReasonSettlement:      "settlement",
+	DealReasonTransfer:        "transfer",
+	DealReasonSync:            "sync",
+	DealReasonExternalService: "external_service",
+	DealReasonMigration:       "migration",
+	DealReasonMobile:          "mobile",
+	DealReasonWeb:             "web",
+	DealReasonSplit:           "split",
+	DealReasonCorporateAction: "corporate_action",
+})
+
+func ParseDealReason(s string) (DealReason, error) {
+	return dealReasons.parse(s)
+}
+
+func (r DealReason) String() string {
+	return dealReasons.name(r)
+}
+
+func (r DealReason) IsValid() bool {
+	return dealReasons.valid(r)
+}
+
+func (r DealReason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *DealReason) UnmarshalText(text []byte) error {
+	return dealReasons.unmarshalText(text, r)
+}
+
+func (r DealReason) MarshalJSON() ([]byte, error) {
+	return dealReasons.marshalJSON(r)
+}
+
+func (r *DealReason) UnmarshalJSON(data []byte) error {
+	return dealReasons.unmarshalJSON(data, r)
+}
+
+func (r DealReason) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return dealReasons.encodeMsgpack(enc, r)
+}
+
+func (r *DealReason) DecodeMsgpack(dec *msgpack.Decoder) error {
+	return dealReasons.decodeMsgpack(dec, r)
+}
@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/vmihailenco/msgpack/v5"
+	"strings"
+)
+
+// Price 价格, Volume 手数, Money 金额(余额/净值/盈亏), 都是十进制定点数, 不经过float, 不会有精度损失.
+//
+// 零值表示没有设置(对应以前字符串字段的""), 可以和显式设置的0区分开: 以前是omitempty的可选字段用 omitzero,
+// 没有设置就不传, 显式设置的0照常发送(比如清掉sl/tp). 反序列化兼容网关和pumping的各种格式: "1.0852", 1.0852, "" 和 null(没有设置).
+// json序列化时 Price/Volume 输出字符串("0.1", 和网关请求的格式一致), 没有设置时输出""; Money 输出数字(和出入金接口一致),
+// 没有设置时输出null; msgpack都编码为字符串, 没有设置时为nil
+type Price struct{ decimalValue }
+
+type Volume struct{ decimalValue }
+
+type Money struct{ decimalValue }
+
+func NewPrice(d decimal.Decimal) Price   { return Price{decimalValue{d: d, set: true}} }
+func NewVolume(d decimal.Decimal) Volume { return Volume{decimalValue{d: d, set: true}} }
+func NewMoney(d decimal.Decimal) Money   { return Money{decimalValue{d: d, set: true}} }
+
+// PriceFromFloat 按float的最短十进制表示转换, 比如 1.0852 就是 1.0852, 而不是 1.08519999...
+func PriceFromFloat(f float64) Price   { return NewPrice(decimal.NewFromFloat(f)) }
+func VolumeFromFloat(f float64) Volume { return NewVolume(decimal.NewFromFloat(f)) }
+func MoneyFromFloat(f float64) Money   { return NewMoney(decimal.NewFromFloat(f)) }
+
+// ParsePrice 空字符串返回没有设置的零值
+func ParsePrice(s string) (Price, error) {
+	var p Price
+	err := p.parse(s)
+	return p, err
+}
+
+func ParseVolume(s string) (Volume, error) {
+	var v Volume
+	err := v.parse(s)
+	return v, err
+}
+
+func ParseMoney(s string) (Money, error) {
+	var m Money
+	err := m.parse(s)
+	return m, err
+}
+
+// MustParsePrice 解析失败panic, 用于常量
+func MustParsePrice(s string) Price   { return must(ParsePrice(s)) }
+func MustParseVolume(s string) Volume { return must(ParseVolume(s)) }
+func MustParseMoney(s string) Money   { return must(ParseMoney(s)) }
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (p Price) MarshalJSON() ([]byte, error)  { return p.marshalJSON(true) }
+func (v Volume) MarshalJSON() ([]byte, error) { return v.marshalJSON(true) }
+func (m Money) MarshalJSON() ([]byte, error)  { return m.marshalJSON(false) }
+
+//------------------------------------------------------------------------
+
+// decimalValue Price/Volume/Money 共用的实现
+type decimalValue struct {
+	d   decimal.Decimal
+	set bool
+}
+
+// Decimal 没有设置时返回0
+func (v decimalValue) Decimal() decimal.Decimal {
+	return v.d
+}
+
+// IsSet 是否设置过, 显式设置的0也算
+func (v decimalValue) IsSet() bool {
+	return v.set
+}
+
+// String 没有设置时返回"", 否则是去掉末尾0的十进制数, 比如 1.0852
+func (v decimalValue) String() string {
+	if !v.set {
+		return ""
+	}
+	return v.d.String()
+}
+
+// Float64 只用于显示/统计这类不要求精确的地方, 计算请使用 Decimal
+func (v decimalValue) Float64() float64 {
+	f, _ := v.d.Float64()
+	return f
+}
+
+func (v *decimalValue) parse(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		*v = decimalValue{}
+		return nil
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return fmt.Errorf("invalid decimal %q", s)
+	}
+	*v = decimalValue{d: d, set: true}
+	return nil
+}
+
+func (v decimalValue) marshalJSON(quote bool) ([]byte, error) {
+	if !v.set {
+		//和以前的字符串字段一致
+		if quote {
+			return []byte(`""`), nil
+		}
+		return []byte("null"), nil
+	}
+	if quote {
+		return []byte(`"` + v.d.String() + `"`), nil
+	}
+	return []byte(v.d.String()), nil
+}
+
+// UnmarshalJSON 字符串和数字都可以, 数字直接按原文解析, 不经过float
+func (v *decimalValue) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		*v = decimalValue{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return v.parse(s)
+	}
+	return v.parse(string(data))
+}
+
+// EncodeMsgpack 编码为字符串, 没有设置则为nil
+func (v decimalValue) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if !v.set {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeString(v.d.String())
+}
+
+// DecodeMsgpack 兼容字符串/整数/浮点数(mt5推送的价格是float)
+func (v *decimalValue) DecodeMsgpack(dec *msgpack.Decoder) error {
+	x, err := dec.DecodeInterface()
+	if err != nil {
+		return err
+	}
+	switch x := x.(type) {
+	case nil:
+		*v = decimalValue{}
+	case string:
+		return v.parse(x)
+	case float64:
+		*v = decimalValue{d: decimal.NewFromFloat(x), set: true}
+	case float32:
+		*v = decimalValue{d: decimal.NewFromFloat32(x), set: true}
+	case int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		return v.parse(fmt.Sprint(x))
+	default:
+		return fmt.Errorf("invalid decimal %v (%T)", x, x)
+	}
+	return nil
+}
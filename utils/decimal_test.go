@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"encoding/json"
+	"github.com/vmihailenco/msgpack/v5"
+	"testing"
+)
+
+type decimals struct {
+	Price  Price  `json:"price" msgpack:"price"`
+	Volume Volume `json:"volume" msgpack:"volume"`
+	Money  Money  `json:"money" msgpack:"money"`
+}
+
+func (d decimals) equal(other decimals) bool {
+	same := func(a, b decimalValue) bool { return a.set == b.set && a.d.Equal(b.d) }
+	return same(d.Price.decimalValue, other.Price.decimalValue) &&
+		same(d.Volume.decimalValue, other.Volume.decimalValue) &&
+		same(d.Money.decimalValue, other.Money.decimalValue)
+}
+
+var decimalCases = []struct {
+	name string
+	v    decimals
+	json string
+}{
+	{"set", decimals{MustParsePrice("1.08520"), MustParseVolume("0.07"), MustParseMoney("-10003.5")}, `{"price":"1.0852","volume":"0.07","money":-10003.5}`},
+	{"explicit zero", decimals{MustParsePrice("0"), MustParseVolume("0"), MustParseMoney("0")}, `{"price":"0","volume":"0","money":0}`},
+	{"unset", decimals{}, `{"price":"","volume":"","money":null}`},
+	{"beyond float64", decimals{MustParsePrice("12345678901.123456789"), MustParseVolume("0.1"), MustParseMoney("0.30000000000000001")}, `{"price":"12345678901.123456789","volume":"0.1","money":0.30000000000000001}`},
+}
+
+func TestDecimalJSONRoundTrip(t *testing.T) {
+	for _, tt := range decimalCases {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.json {
+				t.Fatalf("json = %s, want %s", data, tt.json)
+			}
+			var got decimals
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !got.equal(tt.v) {
+				t.Fatalf("round trip = %+v, want %+v", got, tt.v)
+			}
+		})
+	}
+}
+
+func TestDecimalMsgpackRoundTrip(t *testing.T) {
+	for _, tt := range decimalCases {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := msgpack.Marshal(tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got decimals
+			if err := msgpack.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !got.equal(tt.v) {
+				t.Fatalf("round trip = %+v, want %+v", got, tt.v)
+			}
+		})
+	}
+}
+
+// 网关和pumping推送的各种格式
+func TestDecimalUnmarshalFormats(t *testing.T) {
+	tests := []struct {
+		json string
+		want Price
+	}{
+		{`"1.0852"`, MustParsePrice("1.0852")},
+		{`1.0852`, MustParsePrice("1.0852")},
+		{`" 2350.3 "`, MustParsePrice("2350.3")},
+		{`0`, MustParsePrice("0")},
+		{`""`, Price{}},
+		{`null`, Price{}},
+	}
+	for _, tt := range tests {
+		var got Price
+		if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.json, err)
+		}
+		if got.IsSet() != tt.want.IsSet() || !got.Decimal().Equal(tt.want.Decimal()) {
+			t.Errorf("Unmarshal(%s) = %q (set %v), want %q", tt.json, got, got.IsSet(), tt.want)
+		}
+	}
+	var p Price
+	if err := json.Unmarshal([]byte(`"abc"`), &p); err == nil {
+		t.Fatal("Unmarshal(abc) succeeded")
+	}
+
+	//mt5推送的价格是float, 按最短表示转换, 不会带出二进制误差
+	for _, v := range []interface{}{1.0852, float32(1.5), int64(-3), uint8(7)} {
+		data, err := msgpack.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := msgpack.Unmarshal(data, &p); err != nil {
+			t.Fatalf("msgpack %T: %v", v, err)
+		}
+		if want := MustParsePrice(formatAny(v)); !p.Decimal().Equal(want.Decimal()) {
+			t.Errorf("msgpack %T(%v) = %s, want %s", v, v, p, want)
+		}
+	}
+}
+
+func formatAny(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"math"
+)
+
+// tick里的ask/bid/last是放大了1e8倍的整数(E8), 下面的方法在E8和 Price 之间精确转换,
+// 以及按品种的精度(MT5SymbolBase.Digit)取整/格式化/计算点数
+
+const e8Exp = 8
+
+var (
+	maxInt64 = decimal.NewFromInt(math.MaxInt64)
+	minInt64 = decimal.NewFromInt(math.MinInt64)
+)
+
+// PriceFromE8 E8整数转成价格, 不会损失精度
+func PriceFromE8(e8 int64) Price {
+	return NewPrice(decimal.New(e8, -e8Exp))
+}
+
+// E8 转回E8整数, 和 PriceFromE8 互逆. 小数超过8位或者超出int64范围时返回错误, 不会悄悄取整
+func (p Price) E8() (int64, error) {
+	if !p.set {
+		return 0, nil
+	}
+	e8 := p.d.Shift(e8Exp)
+	if !e8.IsInteger() {
+		return 0, fmt.Errorf("price %s has more than %d decimals", p.d, e8Exp)
+	}
+	return toInt64("e8", e8)
+}
+
+// Round 按品种精度四舍五入, digits即 MT5SymbolBase.Digit
+func (p Price) Round(digits uint) Price {
+	if !p.set {
+		return p
+	}
+	return NewPrice(p.d.Round(int32(digits)))
+}
+
+// Format 按品种精度四舍五入并保留末尾的0, 比如 digits=5 时 1.085 输出 1.08500; 没有设置时返回""
+func (p Price) Format(digits uint) string {
+	if !p.set {
+		return ""
+	}
+	return p.d.StringFixed(int32(digits))
+}
+
+// Spread 点差 ask-bid
+func Spread(ask, bid Price) Price {
+	return NewPrice(ask.d.Sub(bid.d))
+}
+
+// Mid 中间价 (ask+bid)/2, 精确值(最多比ask/bid多一位小数)
+func Mid(ask, bid Price) Price {
+	return NewPrice(ask.d.Add(bid.d).Mul(decimal.New(5, -1)))
+}
+
+// Points 价格距离换算成点数(1点为 10^-digits), 比如 digits=5 时 0.0002 是20点.
+// 不是整数点时四舍五入, 超出int64范围返回错误
+func Points(distance Price, digits uint) (int64, error) {
+	return toInt64("points", distance.d.Shift(int32(digits)).Round(0))
+}
+
+// PriceFromPoints Points 的反向换算
+func PriceFromPoints(points int64, digits uint) Price {
+	return NewPrice(decimal.New(points, -int32(digits)))
+}
+
+// toInt64 d必须是整数
+func toInt64(what string, d decimal.Decimal) (int64, error) {
+	if d.GreaterThan(maxInt64) || d.LessThan(minInt64) {
+		return 0, fmt.Errorf("%s %s overflows int64", what, d)
+	}
+	return d.IntPart(), nil
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPriceE8(t *testing.T) {
+	tests := []struct {
+		price   Price
+		want    int64
+		wantErr string
+	}{
+		{MustParsePrice("1.0852"), 108520000, ""},
+		{MustParsePrice("-0.00000001"), -1, ""},
+		{MustParsePrice("0"), 0, ""},
+		{Price{}, 0, ""},
+		{MustParsePrice("92233720368.54775807"), math.MaxInt64, ""},
+		{MustParsePrice("-92233720368.54775808"), math.MinInt64, ""},
+		{MustParsePrice("92233720368.54775808"), 0, "overflows int64"},
+		{MustParsePrice("-92233720368.54775809"), 0, "overflows int64"},
+		{MustParsePrice("1e20"), 0, "overflows int64"},
+		{MustParsePrice("1.123456789"), 0, "more than 8 decimals"},
+	}
+	for _, tt := range tests {
+		got, err := tt.price.E8()
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("E8(%s) = %d, %v, want error %q", tt.price, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("E8(%s) = %d, %v, want %d", tt.price, got, err, tt.want)
+		}
+	}
+
+	//和 PriceFromE8 互逆
+	for _, e8 := range []int64{0, 1, -1, 108520000, 235030000000, math.MaxInt64, math.MinInt64} {
+		got, err := PriceFromE8(e8).E8()
+		if err != nil || got != e8 {
+			t.Errorf("PriceFromE8(%d).E8() = %d, %v", e8, got, err)
+		}
+	}
+	//超过8位的小数先取整再转换
+	if got, err := MustParsePrice("1.123456789").Round(8).E8(); err != nil || got != 112345679 {
+		t.Errorf("Round(8).E8() = %d, %v, want 112345679", got, err)
+	}
+}
+
+func TestPriceDigits(t *testing.T) {
+	tests := []struct {
+		price  string
+		digits uint
+		round  string
+		format string
+	}{
+		{"1.085245", 5, "1.08525", "1.08525"},
+		{"1.085", 5, "1.085", "1.08500"},
+		{"2350.305", 2, "2350.31", "2350.31"},
+		{"-2350.305", 2, "-2350.31", "-2350.31"},
+		{"150.5", 0, "151", "151"},
+		{"0.000001", 5, "0", "0.00000"},
+	}
+	for _, tt := range tests {
+		p := MustParsePrice(tt.price)
+		if got := p.Round(tt.digits).String(); got != tt.round {
+			t.Errorf("Round(%s, %d) = %s, want %s", tt.price, tt.digits, got, tt.round)
+		}
+		if got := p.Format(tt.digits); got != tt.format {
+			t.Errorf("Format(%s, %d) = %s, want %s", tt.price, tt.digits, got, tt.format)
+		}
+	}
+	if got := (Price{}).Round(5); got.IsSet() {
+		t.Errorf("Round of unset price = %q, want unset", got)
+	}
+	if got := (Price{}).Format(5); got != "" {
+		t.Errorf("Format of unset price = %q, want empty", got)
+	}
+}
+
+func TestPricePoints(t *testing.T) {
+	ask, bid := MustParsePrice("1.08520"), MustParsePrice("1.08500")
+	if got := Spread(ask, bid).String(); got != "0.0002" {
+		t.Errorf("Spread = %s, want 0.0002", got)
+	}
+	if got := Mid(ask, bid).String(); got != "1.0851" {
+		t.Errorf("Mid = %s, want 1.0851", got)
+	}
+	if got := Mid(MustParsePrice("1.08521"), bid).String(); got != "1.085105" {
+		t.Errorf("Mid = %s, want 1.085105", got)
+	}
+
+	tests := []struct {
+		distance string
+		digits   uint
+		want     int64
+	}{
+		{"0.0002", 5, 20},
+		{"0.30", 2, 30},
+		{"-0.00015", 4, -2},
+		{"0.000024", 5, 2},
+	}
+	for _, tt := range tests {
+		got, err := Points(MustParsePrice(tt.distance), tt.digits)
+		if err != nil || got != tt.want {
+			t.Errorf("Points(%s, %d) = %d, %v, want %d", tt.distance, tt.digits, got, err, tt.want)
+		}
+		if tt.distance == "0.0002" {
+			if back := PriceFromPoints(got, tt.digits); back.String() != tt.distance {
+				t.Errorf("PriceFromPoints(%d, %d) = %s", got, tt.digits, back)
+			}
+		}
+	}
+	if _, err := Points(MustParsePrice("1e20"), 5); err == nil {
+		t.Error("Points overflow did not return an error")
+	}
+}
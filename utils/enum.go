@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/vmihailenco/msgpack/v5"
+	"strconv"
+	"strings"
+)
+
+// 订单/成交/持仓/品种的枚举(见 order_enum.go, deal_enum.go, symbol_enum.go)共用的实现.
+//
+// 网关和pumping传的都是数字, 所以json/msgpack序列化仍然输出数字, 反序列化同时接受数字和名字("buy_limit");
+// 文本序列化(yaml/命令行参数等)使用名字. 未知的数字可以正常反序列化(网关升级后可能新增取值), 用 IsValid 检查
+
+// enumType 一种枚举的名字表, 用 newEnum 创建
+type enumType[T ~int | ~uint] struct {
+	kind   string //用于错误信息, 比如 "order type"
+	names  map[T]string
+	values map[string]T //小写的名字 -> 值, parse 用
+}
+
+// newEnum 名字不区分大小写, 所以小写后不能重复, 否则 parse 的结果不确定
+func newEnum[T ~int | ~uint](kind string, names map[T]string) enumType[T] {
+	values := make(map[string]T, len(names))
+	for v, name := range names {
+		key := strings.ToLower(name)
+		if _, ok := values[key]; ok {
+			panic(fmt.Sprintf("utils: duplicate %s name %q", kind, name))
+		}
+		values[key] = v
+	}
+	return enumType[T]{kind: kind, names: names, values: values}
+}
+
+// name 没有名字的值输出数字
+func (e enumType[T]) name(v T) string {
+	if name, ok := e.names[v]; ok {
+		return name
+	}
+	return e.format(v)
+}
+
+func (e enumType[T]) valid(v T) bool {
+	_, ok := e.names[v]
+	return ok
+}
+
+// format 不能用fmt, 它会调用T的String方法
+func (e enumType[T]) format(v T) string {
+	if v < 0 {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+// number 解析数字, 超出T的范围(包括无符号类型的负数)返回false
+func (e enumType[T]) number(s string) (T, bool) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		v := T(n)
+		return v, (v < 0) == (n < 0) && int64(v) == n
+	}
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		v := T(n)
+		return v, v >= 0 && uint64(v) == n
+	}
+	return 0, false
+}
+
+// parse 名字不区分大小写, 也接受数字
+func (e enumType[T]) parse(s string) (T, error) {
+	s = strings.TrimSpace(s)
+	if v, ok := e.values[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	if v, ok := e.number(s); ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("invalid %s %q", e.kind, s)
+}
+
+func (e enumType[T]) unmarshalText(text []byte, v *T) error {
+	parsed, err := e.parse(string(text))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+func (e enumType[T]) marshalJSON(v T) ([]byte, error) {
+	return []byte(e.format(v)), nil
+}
+
+func (e enumType[T]) unmarshalJSON(data []byte, v *T) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case string(data) == "null":
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return e.unmarshalText([]byte(s), v)
+	}
+	n, ok := e.number(string(data))
+	if !ok {
+		return fmt.Errorf("invalid %s %s", e.kind, data)
+	}
+	*v = n
+	return nil
+}
+
+func (e enumType[T]) encodeMsgpack(enc *msgpack.Encoder, v T) error {
+	if v < 0 {
+		return enc.EncodeInt(int64(v))
+	}
+	return enc.EncodeUint(uint64(v))
+}
+
+func (e enumType[T]) decodeMsgpack(dec *msgpack.Decoder, v *T) error {
+	x, err := dec.DecodeInterface()
+	if err != nil {
+		return err
+	}
+	switch x := x.(type) {
+	case nil:
+		return nil
+	case string:
+		return e.unmarshalText([]byte(x), v)
+	case int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		return e.unmarshalText([]byte(fmt.Sprint(x)), v)
+	}
+	return fmt.Errorf("invalid %s %v (%T)", e.kind, x, x)
+}
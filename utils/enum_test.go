@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"encoding/json"
+	"github.com/vmihailenco/msgpack/v5"
+	"strings"
+	"testing"
+)
+
+// 每个名字都能解析回原来的值, 大小写不影响
+func checkNames[T ~int | ~uint](t *testing.T, e enumType[T]) {
+	t.Helper()
+	for v, name := range e.names {
+		for _, s := range []string{name, strings.ToUpper(name), " " + name + " "} {
+			got, err := e.parse(s)
+			if err != nil || got != v {
+				t.Errorf("%s: parse(%q) = %v, %v, want %v", e.kind, s, got, err, v)
+			}
+		}
+		if got := e.name(v); got != name {
+			t.Errorf("%s: name(%v) = %q, want %q", e.kind, e.format(v), got, name)
+		}
+	}
+}
+
+func TestEnumNames(t *testing.T) {
+	checkNames(t, orderTypes)
+	checkNames(t, orderStates)
+	checkNames(t, orderActivations)
+	checkNames(t, orderTimes)
+	checkNames(t, positionActions)
+	checkNames(t, positionActivations)
+	checkNames(t, dealActions)
+	checkNames(t, dealEntrys)
+	checkNames(t, dealReasons)
+	checkNames(t, tradeModes)
+	checkNames(t, calcModes)
+	checkNames(t, swapModes)
+	checkNames(t, gTCModes)
+}
+
+func TestEnumDuplicateNamePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("newEnum accepted names that differ only in case")
+		}
+	}()
+	newEnum("test", map[OrderState]string{0: "placed", 1: "Placed"})
+}
+
+func TestEnumString(t *testing.T) {
+	tests := []struct {
+		v    interface{ String() string }
+		want string
+	}{
+		{OrderTypeBuyStopLimit, "buy_stop_limit"},
+		{OrderType(99), "99"},
+		{OrderType(-1), "-1"},
+		{OrderTimeSpecifiedDay, "specified_day"},
+		{OrderTime(42), "42"},
+		{DealEntry(-2), "-2"},
+	}
+	for _, tt := range tests {
+		if got := tt.v.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+	if OrderType(99).IsValid() || !OrderTypeCloseBy.IsValid() {
+		t.Error("IsValid is wrong")
+	}
+	if OrderType(-1).IsBuy() || OrderType(-2).IsBuy() || OrderType(-1).Opposite() != -1 {
+		t.Error("negative order types must not be buy/sell")
+	}
+}
+
+func TestEnumJSON(t *testing.T) {
+	type msg struct {
+		Type  OrderType `json:"type"`
+		Time  OrderTime `json:"time"`
+		Entry DealEntry `json:"entry"`
+	}
+	//网关收发的都是数字
+	data, err := json.Marshal(msg{Type: OrderTypeSellLimit, Time: OrderTimeDay, Entry: -1})
+	if err != nil || string(data) != `{"type":3,"time":1,"entry":-1}` {
+		t.Fatalf("Marshal = %s, %v", data, err)
+	}
+
+	tests := []struct {
+		json    string
+		want    msg
+		wantErr bool
+	}{
+		{`{"type":3,"time":1,"entry":-1}`, msg{Type: OrderTypeSellLimit, Time: OrderTimeDay, Entry: -1}, false},
+		{`{"type":"SELL_LIMIT","time":"day"}`, msg{Type: OrderTypeSellLimit, Time: OrderTimeDay}, false},
+		{`{"type":"7","time":null}`, msg{Type: OrderTypeSellStopLimit}, false},
+		{`{"type":99}`, msg{Type: 99}, false},
+		{`{"time":-1}`, msg{}, true},
+		{`{"type":1.5}`, msg{}, true},
+		{`{"type":"market"}`, msg{}, true},
+		{`{"time":18446744073709551616}`, msg{}, true},
+	}
+	for _, tt := range tests {
+		var got msg
+		err := json.Unmarshal([]byte(tt.json), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) err = %v, wantErr %v", tt.json, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.json, got, tt.want)
+		}
+	}
+}
+
+// yaml/命令行参数使用名字
+func TestEnumText(t *testing.T) {
+	text, err := OrderTypeBuyStop.MarshalText()
+	if err != nil || string(text) != "buy_stop" {
+		t.Fatalf("MarshalText = %s, %v", text, err)
+	}
+	var typ OrderType
+	if err := typ.UnmarshalText([]byte("Buy_Stop")); err != nil || typ != OrderTypeBuyStop {
+		t.Fatalf("UnmarshalText = %v, %v", typ, err)
+	}
+	if err := typ.UnmarshalText([]byte("-3")); err != nil || typ != -3 {
+		t.Fatalf("UnmarshalText(-3) = %v, %v", typ, err)
+	}
+	var tm OrderTime
+	if err := tm.UnmarshalText([]byte("-3")); err == nil {
+		t.Fatalf("unsigned enum accepted -3: %v", tm)
+	}
+	if _, err := ParseOrderType("nope"); err == nil || !strings.Contains(err.Error(), "invalid order type") {
+		t.Fatalf("ParseOrderType(nope) err = %v", err)
+	}
+}
+
+func TestEnumMsgpack(t *testing.T) {
+	type msg struct {
+		Type  OrderType `msgpack:"type"`
+		Time  OrderTime `msgpack:"time"`
+		Entry DealEntry `msgpack:"entry"`
+	}
+	for _, want := range []msg{
+		{Type: OrderTypeCloseBy, Time: OrderTimeSpecified, Entry: DealEntry(1)},
+		{Type: -1, Time: 42, Entry: -2},
+	} {
+		data, err := msgpack.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got msg
+		if err := msgpack.Unmarshal(data, &got); err != nil || got != want {
+			t.Fatalf("round trip = %+v, %v, want %+v", got, err, want)
+		}
+	}
+
+	//pumping也可能推名字
+	data, _ := msgpack.Marshal(map[string]interface{}{"type": "buy_limit", "time": "gtc"})
+	var got msg
+	if err := msgpack.Unmarshal(data, &got); err != nil || got.Type != OrderTypeBuyLimit || got.Time != OrderTimeGTC {
+		t.Fatalf("names = %+v, %v", got, err)
+	}
+}
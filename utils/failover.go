@@ -0,0 +1,297 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FailoverConfig 多网关故障切换配置, 零值字段使用默认值
+type FailoverConfig struct {
+	HealthPath       string        `json:"health_path" mapstructure:"health_path" config:"health_path" yaml:"health_path"`                         //健康检查的路径, 默认"/", 返回任何<500的状态码都算健康
+	HealthInterval   time.Duration `json:"health_interval" mapstructure:"health_interval" config:"health_interval" yaml:"health_interval"`         //健康检查间隔, 默认5s
+	HealthTimeout    time.Duration `json:"health_timeout" mapstructure:"health_timeout" config:"health_timeout" yaml:"health_timeout"`             //单次健康检查超时, 默认2s
+	FailThreshold    int           `json:"fail_threshold" mapstructure:"fail_threshold" config:"fail_threshold" yaml:"fail_threshold"`             //连续失败多少次标记为不健康, 默认2
+	RecoverThreshold int           `json:"recover_threshold" mapstructure:"recover_threshold" config:"recover_threshold" yaml:"recover_threshold"` //连续成功多少次才算恢复(避免来回切换), 默认3
+}
+
+// FailoverEvent 交易网关发生切换
+type FailoverEvent struct {
+	From   string //切换前的网关
+	To     string //切换后的网关
+	Reason string //切换原因
+}
+
+// GatewayStatus 网关当前的状态
+type GatewayStatus struct {
+	Address string
+	Healthy bool
+	Active  bool //是否是当前的交易网关
+}
+
+type gatewayNode struct {
+	addr      string
+	healthy   bool
+	fails     int //连续失败次数
+	successes int //连续成功次数
+}
+
+// GatewayPool 有序的网关列表, 第一个是主网关
+//
+//   - 查询请求轮询所有健康的网关
+//   - 交易请求只发往当前的交易网关(默认是主网关); 它不健康时切换到下一个健康的网关, 并一直停留在那里(sticky)
+//   - 主网关恢复健康后自动切回(failback)
+type GatewayPool struct {
+	cfg        FailoverConfig
+	httpClient *http.Client
+	logger     Logger
+
+	mu         sync.Mutex
+	nodes      []*gatewayNode
+	active     int
+	onFailover func(FailoverEvent)
+
+	next      atomic.Uint64 //查询请求轮询用
+	stop      chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewGatewayPool 创建网关列表, 空地址和重复地址会被忽略
+// 只有一个网关且cfg为nil时返回nil, 调用方直接使用该地址即可
+func NewGatewayPool(addrs []string, cfg *FailoverConfig, transport http.RoundTripper, logger Logger) *GatewayPool {
+	var nodes []*gatewayNode
+	seen := make(map[string]bool)
+	for _, addr := range addrs {
+		if addr == "" || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		nodes = append(nodes, &gatewayNode{addr: addr, healthy: true})
+	}
+	if len(nodes) == 0 || (len(nodes) == 1 && cfg == nil) {
+		return nil
+	}
+
+	c := FailoverConfig{}
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.HealthPath == "" {
+		c.HealthPath = "/"
+	}
+	c.HealthInterval = durationOr(c.HealthInterval, 5*time.Second)
+	c.HealthTimeout = durationOr(c.HealthTimeout, 2*time.Second)
+	c.FailThreshold = intOr(c.FailThreshold, 2)
+	c.RecoverThreshold = intOr(c.RecoverThreshold, 3)
+
+	return &GatewayPool{
+		cfg:        c,
+		httpClient: &http.Client{Transport: transport, Timeout: c.HealthTimeout},
+		logger:     logger,
+		nodes:      nodes,
+		stop:       make(chan struct{}),
+	}
+}
+
+// SetOnFailover 交易网关切换时的回调(在锁外调用)
+func (p *GatewayPool) SetOnFailover(fn func(FailoverEvent)) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onFailover = fn
+}
+
+// Start 启动后台健康检查, 重复调用无效
+func (p *GatewayPool) Start() {
+	if p == nil {
+		return
+	}
+	p.startOnce.Do(func() {
+		go p.probeLoop()
+	})
+}
+
+// Close 停止后台健康检查
+func (p *GatewayPool) Close() {
+	if p == nil {
+		return
+	}
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+}
+
+// Primary 交易请求使用的网关
+func (p *GatewayPool) Primary() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.nodes[p.active].addr
+}
+
+// Any 查询请求使用的网关, 在健康的网关之间轮询; 都不健康时使用交易网关
+func (p *GatewayPool) Any() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := uint64(len(p.nodes))
+	start := p.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if node := p.nodes[(start+i)%n]; node.healthy {
+			return node.addr
+		}
+	}
+	return p.nodes[p.active].addr
+}
+
+// Status 所有网关当前的状态
+func (p *GatewayPool) Status() []GatewayStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := make([]GatewayStatus, 0, len(p.nodes))
+	for i, node := range p.nodes {
+		status = append(status, GatewayStatus{Address: node.addr, Healthy: node.healthy, Active: i == p.active})
+	}
+	return status
+}
+
+// Report 上报一次请求的结果, 请求失败也会让网关更快地被标记为不健康
+func (p *GatewayPool) Report(addr string, err error) {
+	if errors.Is(err, context.Canceled) {
+		return //调用方自己取消的, 和网关无关
+	}
+	ok := !isGatewayFailure(err)
+	p.mu.Lock()
+	for i, node := range p.nodes {
+		if node.addr == addr {
+			p.update(i, ok)
+			break
+		}
+	}
+	event := p.reselect()
+	p.mu.Unlock()
+
+	p.notify(event)
+}
+
+func (p *GatewayPool) probeLoop() {
+	ticker := time.NewTicker(p.cfg.HealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.probeAll()
+		}
+	}
+}
+
+func (p *GatewayPool) probeAll() {
+	for i, addr := range p.addresses() {
+		ok := p.probe(addr)
+
+		p.mu.Lock()
+		p.update(i, ok)
+		event := p.reselect()
+		p.mu.Unlock()
+
+		p.notify(event)
+	}
+}
+
+func (p *GatewayPool) addresses() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	addrs := make([]string, len(p.nodes))
+	for i, node := range p.nodes {
+		addrs[i] = node.addr
+	}
+	return addrs
+}
+
+// probe 网关返回任何<500的状态码都算健康(包括401/404), 说明进程是活着的
+func (p *GatewayPool) probe(addr string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.HealthTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+p.cfg.HealthPath, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < 500
+}
+
+// update 更新网关的健康状态, 调用方需要持有锁
+func (p *GatewayPool) update(i int, ok bool) {
+	node := p.nodes[i]
+	if ok {
+		node.fails = 0
+		node.successes++
+		if !node.healthy && node.successes >= p.cfg.RecoverThreshold {
+			node.healthy = true
+			if p.logger != nil {
+				p.logger.Infof("MT5#GatewayPool->%s is healthy again", node.addr)
+			}
+		}
+		return
+	}
+
+	node.successes = 0
+	node.fails++
+	if node.healthy && node.fails >= p.cfg.FailThreshold {
+		node.healthy = false
+		if p.logger != nil {
+			p.logger.Warnf("MT5#GatewayPool->%s is unhealthy", node.addr)
+		}
+	}
+}
+
+// reselect 重新选择交易网关, 调用方需要持有锁
+func (p *GatewayPool) reselect() *FailoverEvent {
+	from := p.nodes[p.active]
+
+	//主网关恢复了, 切回去
+	if p.active != 0 && p.nodes[0].healthy {
+		p.active = 0
+		return &FailoverEvent{From: from.addr, To: p.nodes[0].addr, Reason: "primary recovered"}
+	}
+
+	//当前网关还健康就不切换(sticky)
+	if from.healthy {
+		return nil
+	}
+	for i, node := range p.nodes {
+		if i != p.active && node.healthy {
+			p.active = i
+			return &FailoverEvent{From: from.addr, To: node.addr, Reason: "active gateway unhealthy"}
+		}
+	}
+	return nil
+}
+
+func (p *GatewayPool) notify(event *FailoverEvent) {
+	if event == nil {
+		return
+	}
+	if p.logger != nil {
+		p.logger.Warnf("MT5#GatewayPool->failover %s -> %s: %s", event.From, event.To, event.Reason)
+	}
+	p.mu.Lock()
+	fn := p.onFailover
+	p.mu.Unlock()
+	if fn != nil {
+		fn(*event)
+	}
+}
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gateway 可以随时切换成宕机状态的网关
+type gateway struct {
+	*httptest.Server
+	down atomic.Bool
+	hits atomic.Int32
+}
+
+func newGateway(t *testing.T) *gateway {
+	t.Helper()
+	g := &gateway{}
+	g.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path != "/" {
+			g.hits.Add(1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":0,"success":true}`))
+	}))
+	t.Cleanup(g.Close)
+	return g
+}
+
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestGatewayPoolFailoverAndFailback(t *testing.T) {
+	primary, backup1, backup2 := newGateway(t), newGateway(t), newGateway(t)
+	pool := NewGatewayPool([]string{primary.URL, backup1.URL, backup2.URL, primary.URL, ""},
+		&FailoverConfig{HealthInterval: 10 * time.Millisecond, FailThreshold: 2, RecoverThreshold: 3}, http.DefaultTransport, nil)
+	var mu sync.Mutex
+	var events []FailoverEvent
+	pool.SetOnFailover(func(e FailoverEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	})
+	pool.Start()
+	defer pool.Close()
+
+	if n := len(pool.Status()); n != 3 {
+		t.Fatalf("%d gateways, duplicates and empty addresses should be dropped", n)
+	}
+	if pool.Primary() != primary.URL {
+		t.Fatalf("Primary() = %s", pool.Primary())
+	}
+
+	primary.down.Store(true)
+	waitUntil(t, "failover", func() bool { return pool.Primary() == backup1.URL })
+
+	//backup1 也挂了, 切到 backup2; backup1 恢复后不切回去(sticky)
+	backup1.down.Store(true)
+	waitUntil(t, "second failover", func() bool { return pool.Primary() == backup2.URL })
+	backup1.down.Store(false)
+	waitUntil(t, "backup1 healthy", func() bool { return pool.Status()[1].Healthy })
+	if pool.Primary() != backup2.URL {
+		t.Fatalf("Primary() = %s, should stay on %s", pool.Primary(), backup2.URL)
+	}
+
+	//主网关恢复后切回
+	primary.down.Store(false)
+	waitUntil(t, "failback", func() bool { return pool.Primary() == primary.URL })
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []FailoverEvent{
+		{From: primary.URL, To: backup1.URL, Reason: "active gateway unhealthy"},
+		{From: backup1.URL, To: backup2.URL, Reason: "active gateway unhealthy"},
+		{From: backup2.URL, To: primary.URL, Reason: "primary recovered"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v", events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+}
+
+// 请求失败会让网关更快被标记为不健康, 不用等健康检查
+func TestGatewayPoolReport(t *testing.T) {
+	pool := NewGatewayPool([]string{"http://a", "http://b"}, &FailoverConfig{HealthInterval: time.Hour, FailThreshold: 2, RecoverThreshold: 2}, nil, nil)
+
+	pool.Report("http://a", gatewayDown)
+	pool.Report("http://a", businessFail) //业务错误算成功, 连续失败次数清零
+	pool.Report("http://a", gatewayDown)
+	pool.Report("http://a", context.Canceled)
+	if pool.Primary() != "http://a" {
+		t.Fatal("failed over before reaching fail_threshold")
+	}
+	pool.Report("http://a", gatewayDown)
+	if pool.Primary() != "http://b" {
+		t.Fatalf("Primary() = %s after consecutive failures", pool.Primary())
+	}
+	//查询请求只发往健康的网关
+	for i := 0; i < 4; i++ {
+		if addr := pool.Any(); addr != "http://b" {
+			t.Fatalf("Any() = %s, want only the healthy gateway", addr)
+		}
+	}
+
+	pool.Report("http://a", nil)
+	if pool.Primary() != "http://b" {
+		t.Fatal("failed back before reaching recover_threshold")
+	}
+	pool.Report("http://a", nil)
+	if pool.Primary() != "http://a" {
+		t.Fatalf("Primary() = %s after primary recovered", pool.Primary())
+	}
+}
+
+func TestGatewayPoolSingle(t *testing.T) {
+	if pool := NewGatewayPool([]string{"http://a", "http://a"}, nil, nil, nil); pool != nil {
+		t.Fatal("a single gateway without failover config should not need a pool")
+	}
+	if pool := NewGatewayPool([]string{"http://a"}, &FailoverConfig{}, nil, nil); pool == nil {
+		t.Fatal("failover config should enable health checks for a single gateway")
+	}
+}
+
+// 交易请求只发往交易网关, 主网关宕机后切到备用网关, 恢复后切回
+func TestRestClientFailover(t *testing.T) {
+	primary, backup := newGateway(t), newGateway(t)
+	cli := NewRestClient(nil, &ClientParams{
+		Address:   primary.URL,
+		Addresses: []string{backup.URL},
+		Retry:     &RetryPolicy{MaxAttempts: 1},
+		Failover:  &FailoverConfig{HealthInterval: 10 * time.Millisecond, FailThreshold: 1, RecoverThreshold: 1},
+	})
+	defer cli.Close()
+	trade := func() error {
+		return cli.Do(context.Background(), &Call{Endpoint: "OpenPosition", Method: http.MethodPost, Path: "/v1/position/open", Request: map[string]int{}, Response: &CommonResp{}})
+	}
+
+	if err := trade(); err != nil || primary.hits.Load() != 1 {
+		t.Fatalf("err = %v, primary hits = %d", err, primary.hits.Load())
+	}
+
+	primary.down.Store(true)
+	waitUntil(t, "failover", func() bool { return cli.GatewayStatus()[1].Active })
+	if err := trade(); err != nil || backup.hits.Load() != 1 {
+		t.Fatalf("err = %v, backup hits = %d", err, backup.hits.Load())
+	}
+
+	primary.down.Store(false)
+	waitUntil(t, "failback", func() bool { return cli.GatewayStatus()[0].Active })
+	if err := trade(); err != nil || primary.hits.Load() != 2 {
+		t.Fatalf("err = %v, primary hits = %d", err, primary.hits.Load())
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig http连接池配置, 零值字段使用默认值
+type TransportConfig struct {
+	MaxIdleConns        int           `json:"max_idle_conns" mapstructure:"max_idle_conns" config:"max_idle_conns" yaml:"max_idle_conns"`                                     //所有host的空闲连接总数, 默认100
+	MaxIdleConnsPerHost int           `json:"max_idle_conns_per_host" mapstructure:"max_idle_conns_per_host" config:"max_idle_conns_per_host" yaml:"max_idle_conns_per_host"` //每个host保留的空闲连接数, 默认32
+	MaxConnsPerHost     int           `json:"max_conns_per_host" mapstructure:"max_conns_per_host" config:"max_conns_per_host" yaml:"max_conns_per_host"`                     //每个host的最大连接数, 0不限制
+	IdleConnTimeout     time.Duration `json:"idle_conn_timeout" mapstructure:"idle_conn_timeout" config:"idle_conn_timeout" yaml:"idle_conn_timeout"`                         //空闲连接多久后关闭, 默认90s
+	DialTimeout         time.Duration `json:"dial_timeout" mapstructure:"dial_timeout" config:"dial_timeout" yaml:"dial_timeout"`                                             //建立tcp连接的超时, 默认5s
+	KeepAlive           time.Duration `json:"keep_alive" mapstructure:"keep_alive" config:"keep_alive" yaml:"keep_alive"`                                                     //tcp keepalive探测间隔, 默认30s
+	TLSHandshakeTimeout time.Duration `json:"tls_handshake_timeout" mapstructure:"tls_handshake_timeout" config:"tls_handshake_timeout" yaml:"tls_handshake_timeout"`         //tls握手超时, 默认5s
+	DisableHTTP2        bool          `json:"disable_http2" mapstructure:"disable_http2" config:"disable_http2" yaml:"disable_http2"`                                         //禁用http2(默认https时会尝试协商http2)
+}
+
+// NewHTTPTransport 创建一个可以在多个goroutine之间共享的 http.Transport
+// 连接会被复用, 避免每次请求都重新做tcp/tls握手
+func NewHTTPTransport(cfg *TransportConfig, tlsConfig *tls.Config) *http.Transport {
+	if cfg == nil {
+		cfg = &TransportConfig{}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   durationOr(cfg.DialTimeout, 5*time.Second),
+		KeepAlive: durationOr(cfg.KeepAlive, 30*time.Second),
+	}
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+		MaxIdleConns:          intOr(cfg.MaxIdleConns, 100),
+		MaxIdleConnsPerHost:   intOr(cfg.MaxIdleConnsPerHost, 32),
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       durationOr(cfg.IdleConnTimeout, 90*time.Second),
+		TLSHandshakeTimeout:   durationOr(cfg.TLSHandshakeTimeout, 5*time.Second),
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+func durationOr(v, def time.Duration) time.Duration {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+func intOr(v, def int) int {
+	if v > 0 {
+		return v
+	}
+	return def
+}
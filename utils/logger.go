@@ -1,5 +1,11 @@
 package utils
 
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
 // 定义接口类
 type Logger interface {
 	Debugf(format string, args ...interface{})
@@ -7,3 +13,129 @@ type Logger interface {
 	Warnf(format string, args ...interface{})
 	Errorf(format string, args ...interface{})
 }
+
+// StructuredLogger 支持附加字段的Logger, 比如 With("login", 1001, "symbol", "EURUSD")
+// 可选实现, 只实现了 Logger 的日志库依然可以使用, 字段会追加到日志内容的末尾
+type StructuredLogger interface {
+	Logger
+	With(keyvals ...interface{}) StructuredLogger
+}
+
+// ContextLogger 可以从ctx里取出trace id等信息的Logger, 可选实现
+type ContextLogger interface {
+	Logger
+	WithContext(ctx context.Context) Logger
+}
+
+// With 给logger附加字段, logger没有实现 StructuredLogger 时字段以 key=value 的形式追加到日志末尾
+func With(logger Logger, keyvals ...interface{}) Logger {
+	if len(keyvals) == 0 {
+		return logger
+	}
+	if sl, ok := logger.(StructuredLogger); ok {
+		return sl.With(keyvals...)
+	}
+	return &fieldLogger{base: logger, suffix: formatFields(keyvals)}
+}
+
+// WithContext 带上ctx(ContextLogger)以及通过 ContextWithLogFields 放在ctx里的字段
+func WithContext(ctx context.Context, logger Logger) Logger {
+	if ctx == nil {
+		return logger
+	}
+	if cl, ok := logger.(ContextLogger); ok {
+		logger = cl.WithContext(ctx)
+	}
+	return With(logger, LogFieldsFromContext(ctx)...)
+}
+
+type logFieldsKey struct{}
+
+// ContextWithLogFields 把字段放到ctx里, SDK通过这个ctx输出的日志都会带上这些字段
+func ContextWithLogFields(ctx context.Context, keyvals ...interface{}) context.Context {
+	parent := LogFieldsFromContext(ctx)
+	fields := make([]interface{}, 0, len(parent)+len(keyvals))
+	fields = append(append(fields, parent...), keyvals...)
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// LogFieldsFromContext 取出 ContextWithLogFields 放进去的字段
+func LogFieldsFromContext(ctx context.Context) []interface{} {
+	fields, _ := ctx.Value(logFieldsKey{}).([]interface{})
+	return fields
+}
+
+// fieldLogger 给只支持printf的Logger附加字段
+type fieldLogger struct {
+	base   Logger
+	suffix string
+}
+
+func (l *fieldLogger) Debugf(format string, args ...interface{}) {
+	l.base.Debugf("%s%s", fmt.Sprintf(format, args...), l.suffix)
+}
+
+func (l *fieldLogger) Infof(format string, args ...interface{}) {
+	l.base.Infof("%s%s", fmt.Sprintf(format, args...), l.suffix)
+}
+
+func (l *fieldLogger) Warnf(format string, args ...interface{}) {
+	l.base.Warnf("%s%s", fmt.Sprintf(format, args...), l.suffix)
+}
+
+func (l *fieldLogger) Errorf(format string, args ...interface{}) {
+	l.base.Errorf("%s%s", fmt.Sprintf(format, args...), l.suffix)
+}
+
+func (l *fieldLogger) With(keyvals ...interface{}) StructuredLogger {
+	return &fieldLogger{base: l.base, suffix: l.suffix + formatFields(keyvals)}
+}
+
+// formatFields 格式化成 " k1=v1 k2=v2", 落单的key值为 MISSING
+func formatFields(keyvals []interface{}) string {
+	var sb strings.Builder
+	for i := 0; i < len(keyvals); i += 2 {
+		var v interface{} = "MISSING"
+		if i+1 < len(keyvals) {
+			v = keyvals[i+1]
+		}
+		fmt.Fprintf(&sb, " %v=%v", keyvals[i], v)
+	}
+	return sb.String()
+}
+
+// NopLogger 什么都不输出, NewClient 传入nil logger时使用
+type NopLogger struct{}
+
+func (NopLogger) Debugf(format string, args ...interface{})      {}
+func (NopLogger) Infof(format string, args ...interface{})       {}
+func (NopLogger) Warnf(format string, args ...interface{})       {}
+func (NopLogger) Errorf(format string, args ...interface{})      {}
+func (l NopLogger) With(keyvals ...interface{}) StructuredLogger { return l }
+func (l NopLogger) WithContext(ctx context.Context) Logger       { return l }
+
+// LogLevel 日志级别
+type LogLevel string
+
+const (
+	LogLevelDebug LogLevel = "debug"
+	LogLevelInfo  LogLevel = "info"
+	LogLevelWarn  LogLevel = "warn"
+	LogLevelError LogLevel = "error"
+	LogLevelOff   LogLevel = "off" //不输出
+)
+
+// LogAt 按level输出日志, 未知的level按Info输出
+func LogAt(logger Logger, level LogLevel, format string, args ...interface{}) {
+	switch level {
+	case LogLevelOff:
+	case LogLevelDebug:
+		logger.Debugf(format, args...)
+	case LogLevelWarn:
+		logger.Warnf(format, args...)
+	case LogLevelError:
+		logger.Errorf(format, args...)
+	default:
+		logger.Infof(format, args...)
+	}
+}
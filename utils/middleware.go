@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"context"
+)
+
+// Call 一次接口调用, 中间件可以读取和修改其中的内容
+type Call struct {
+	Endpoint string            //接口名, 比如 ListSymbol / OpenPosition
+	Method   string            //http method
+	Path     string            //接口路径, 比如 /v1/position/open
+	Query    map[string]string //query参数, 可以修改
+	Header   map[string]string //额外的header, 可以注入(签名相关的header会在最后计算, 不能覆盖)
+	Login    uint64            //请求涉及的mt5 login, 0表示未知
+	Symbol   string            //请求涉及的symbol, 空表示未知
+
+	Request  interface{} //请求结构体(比如 order.OpenPositionRequest), 查询接口的参数在Query里, 为nil; 可以替换成同类型的值
+	Response interface{} //返回值的指针(比如 *direct.ListSymbolResp), 请求结束后可以检查
+
+	Address    string //最后一次http请求发往的网关地址
+	StatusCode int    //最后一次http请求的状态码, 没有收到返回时为0
+	Attempts   int    //尝试次数(包含第一次), 大于1说明发生了重试
+}
+
+// Handler 处理一次接口调用
+type Handler func(ctx context.Context, call *Call) error
+
+// Middleware 包装一次接口调用, 可以在next前后加入自己的逻辑(审计/监控/注入header/修改请求/检查返回)
+// Use 安装的中间件包在重试外面, 一次调用不论重试多少次只会经过一次;
+// UseAttempt 安装的中间件包在每次http请求外面, 重试几次就经过几次(此时call.Attempts是第几次尝试)
+type Middleware func(next Handler) Handler
+
+// Chain 把中间件串起来, 第一个中间件在最外层
+func Chain(final Handler, middlewares ...Middleware) Handler {
+	h := final
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// BeforeHook 请求发出之前调用, 返回错误时请求不会发出
+func BeforeHook(fn func(ctx context.Context, call *Call) error) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			if err := fn(ctx, call); err != nil {
+				return err
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+// AfterHook 请求结束之后调用, err是请求的结果, 返回值会作为最终的错误返回给调用方
+func AfterHook(fn func(ctx context.Context, call *Call, err error) error) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			return fn(ctx, call, next(ctx, call))
+		}
+	}
+}
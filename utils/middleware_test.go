@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// trace 记录中间件进出的顺序
+func trace(log *[]string, name string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			*log = append(*log, name+" before")
+			err := next(ctx, call)
+			*log = append(*log, name+" after")
+			return err
+		}
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var log []string
+	final := func(ctx context.Context, call *Call) error {
+		log = append(log, "final")
+		return nil
+	}
+	h := Chain(final, trace(&log, "a"), trace(&log, "b"), trace(&log, "c"))
+	//Chain 只是组装, 还没有执行
+	if len(log) != 0 {
+		t.Fatalf("Chain ran middlewares: %v", log)
+	}
+	if err := h(context.Background(), &Call{}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a before", "b before", "c before", "final", "c after", "b after", "a after"}
+	if !reflect.DeepEqual(log, want) {
+		t.Fatalf("order = %v, want %v", log, want)
+	}
+
+	//多次 Use 也是先安装的在外层
+	srv, _ := newFlakyServer(t, 0, 0)
+	cli := NewRestClient(nil, &ClientParams{Address: srv.URL})
+	log = nil
+	cli.Use(trace(&log, "first"))
+	cli.Use(trace(&log, "second"), trace(&log, "third"))
+	if err := cli.Do(context.Background(), &Call{Endpoint: "ListSymbol", Method: http.MethodGet, Path: "/v1/symbol/list", Response: &CommonResp{}}); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"first before", "second before", "third before", "third after", "second after", "first after"}
+	if !reflect.DeepEqual(log, want) {
+		t.Fatalf("order = %v, want %v", log, want)
+	}
+}
+
+func TestBeforeHook(t *testing.T) {
+	var hits atomic.Int32
+	var gotHeader, gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		gotHeader, gotQuery = r.Header.Get("X-Request-Id"), r.URL.Query().Get("login")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":0,"success":true}`))
+	}))
+	defer srv.Close()
+
+	//返回错误时不发请求, 后面的中间件也不会执行
+	denied := errors.New("login 1001 is frozen")
+	cli := NewRestClient(nil, &ClientParams{Address: srv.URL, Retry: &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}})
+	var inner, attempts atomic.Int32
+	cli.Use(BeforeHook(func(ctx context.Context, call *Call) error {
+		if call.Login == 1001 {
+			return denied
+		}
+		return nil
+	}))
+	cli.Use(BeforeHook(func(ctx context.Context, call *Call) error {
+		inner.Add(1)
+		return nil
+	}))
+	cli.UseAttempt(BeforeHook(func(ctx context.Context, call *Call) error {
+		attempts.Add(1)
+		return nil
+	}))
+	call := &Call{Endpoint: "OpenPosition", Method: http.MethodPost, Path: "/v1/position/open", Login: 1001, Request: map[string]interface{}{"login": 1001}, Response: &CommonResp{}}
+	if err := cli.Do(context.Background(), call); !errors.Is(err, denied) {
+		t.Fatalf("err = %v, want %v", err, denied)
+	}
+	if hits.Load() != 0 || inner.Load() != 0 || attempts.Load() != 0 || call.Attempts != 0 {
+		t.Fatalf("hits = %d, inner = %d, attempts = %d, call.Attempts = %d after a rejected call", hits.Load(), inner.Load(), attempts.Load(), call.Attempts)
+	}
+
+	//可以修改请求
+	cli = NewRestClient(nil, &ClientParams{Address: srv.URL})
+	cli.Use(BeforeHook(func(ctx context.Context, call *Call) error {
+		call.Header = map[string]string{"X-Request-Id": "req-1"}
+		call.Query["login"] = "1002"
+		return nil
+	}))
+	if err := cli.Do(context.Background(), &Call{Endpoint: "ListPosition", Method: http.MethodGet, Path: "/v1/position/list", Query: map[string]string{"login": "1001"}, Response: &CommonResp{}}); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 1 || gotHeader != "req-1" || gotQuery != "1002" {
+		t.Fatalf("server got header %q, login %q", gotHeader, gotQuery)
+	}
+}
+
+func TestAfterHook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/position/get" {
+			w.Write([]byte(`{"code":13,"success":false,"message":"not found"}`))
+			return
+		}
+		w.Write([]byte(`{"code":0,"success":true}`))
+	}))
+	defer srv.Close()
+
+	errEmpty := errors.New("no positions")
+	cli := NewRestClient(nil, &ClientParams{Address: srv.URL})
+	var seen []error
+	cli.Use(AfterHook(func(ctx context.Context, call *Call, err error) error {
+		seen = append(seen, err)
+		switch {
+		case errors.Is(err, RetcodeNotFound):
+			return nil //当作空结果
+		case err == nil && call.Endpoint == "ListPosition":
+			return errEmpty
+		}
+		return err
+	}))
+
+	get := &Call{Endpoint: "PositionGet", Method: http.MethodGet, Path: "/v1/position/get", Query: map[string]string{"ticket": "1"}, Response: &CommonResp{}}
+	if err := cli.Do(context.Background(), get); err != nil {
+		t.Fatalf("PositionGet: err = %v, want the hook to clear it", err)
+	}
+	if err := cli.Do(context.Background(), &Call{Endpoint: "ListPosition", Method: http.MethodGet, Path: "/v1/position/list", Response: &CommonResp{}}); !errors.Is(err, errEmpty) {
+		t.Fatalf("ListPosition: err = %v, want %v", err, errEmpty)
+	}
+	if len(seen) != 2 || !errors.Is(seen[0], RetcodeNotFound) || seen[1] != nil {
+		t.Fatalf("hook saw %v", seen)
+	}
+	//请求结束后可以检查结果
+	if get.StatusCode != http.StatusOK || get.Address != srv.URL || get.Attempts != 1 {
+		t.Fatalf("call = %+v", get)
+	}
+}
+
+// Use 一次调用只经过一次, UseAttempt 每次重试都会经过
+func TestUseAndUseAttempt(t *testing.T) {
+	srv, hits := newFlakyServer(t, 2, http.StatusBadGateway)
+	cli := NewRestClient(nil, &ClientParams{Address: srv.URL, Retry: &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}})
+	var calls int
+	var attempts []int
+	var statuses []int
+	cli.Use(BeforeHook(func(ctx context.Context, call *Call) error {
+		calls++
+		return nil
+	}))
+	cli.UseAttempt(AfterHook(func(ctx context.Context, call *Call, err error) error {
+		attempts = append(attempts, call.Attempts)
+		statuses = append(statuses, call.StatusCode)
+		return err
+	}))
+
+	call := &Call{Endpoint: "ListSymbol", Method: http.MethodGet, Path: "/v1/symbol/list", Response: &CommonResp{}}
+	if err := cli.Do(context.Background(), call); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 3 || calls != 1 {
+		t.Fatalf("hits = %d, Use middleware ran %d times, want 3 and 1", hits.Load(), calls)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(attempts, want) {
+		t.Fatalf("UseAttempt saw attempts %v, want %v", attempts, want)
+	}
+	if want := []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusOK}; !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("UseAttempt saw status %v, want %v", statuses, want)
+	}
+}
@@ -0,0 +1,414 @@
+package utils
+
+import "github.com/vmihailenco/msgpack/v5"
+
+// OrderType 订单类型, 同时也是开仓/挂单请求的type
+// https://support.metaquotes.net/en/docs/mt5/api/reference_trading/trading_order/imtorder/imtorder_enum#enordertype
+type OrderType int
+
+const (
+	OrderTypeBuy           OrderType = 0 //OP_BUY
+	OrderTypeSell          OrderType = 1 //OP_SELL
+	OrderTypeBuyLimit      OrderType = 2 //OP_BUY_LIMIT
+	OrderTypeSellLimit     OrderType = 3 //OP_SELL_LIMIT
+	OrderTypeBuyStop       OrderType = 4 //OP_BUY_STOP
+	OrderTypeSellStop      OrderType = 5 //OP_SELL_STOP
+	OrderTypeBuyStopLimit  OrderType = 6 //OP_BUY_STOP_LIMIT 触发后在 trigger_price 挂limit单
+	OrderTypeSellStopLimit OrderType = 7 //OP_SELL_STOP_LIMIT
+	OrderTypeCloseBy       OrderType = 8 //OP_CLOSE_BY 用反向持仓平仓
+)
+
+var orderTypes = newEnum("order type", map[OrderType]string{
+	OrderTypeBuy:           "buy",
+	OrderTypeSell:          "sell",
+	OrderTypeBuyLimit:      "buy_limit",
+	OrderTypeSellLimit:     "sell_limit",
+	OrderTypeBuyStop:       "buy_stop",
+	OrderTypeSellStop:      "sell_stop",
+	OrderTypeBuyStopLimit:  "buy_stop_limit",
+	OrderTypeSellStopLimit: "sell_stop_limit",
+	OrderTypeCloseBy:       "close_by",
+})
+
+func ParseOrderType(s string) (OrderType, error) {
+	return orderTypes.parse(s)
+}
+
+func (t OrderType) String() string {
+	return orderTypes.name(t)
+}
+
+func (t OrderType) IsValid() bool {
+	return orderTypes.valid(t)
+}
+
+func (t OrderType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *OrderType) UnmarshalText(text []byte) error {
+	return orderTypes.unmarshalText(text, t)
+}
+
+func (t OrderType) MarshalJSON() ([]byte, error) {
+	return orderTypes.marshalJSON(t)
+}
+
+func (t *OrderType) UnmarshalJSON(data []byte) error {
+	return orderTypes.unmarshalJSON(data, t)
+}
+
+func (t OrderType) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return orderTypes.encodeMsgpack(enc, t)
+}
+
+func (t *OrderType) DecodeMsgpack(dec *msgpack.Decoder) error {
+	return orderTypes.decodeMsgpack(dec, t)
+}
+
+// IsBuy buy方向的市价单/挂单
+func (t OrderType) IsBuy() bool {
+	return t >= OrderTypeBuy && t <= OrderTypeSellStopLimit && t%2 == 0
+}
+
+// IsSell sell方向的市价单/挂单
+func (t OrderType) IsSell() bool {
+	return t >= OrderTypeBuy && t <= OrderTypeSellStopLimit && t%2 == 1
+}
+
+// IsMarket 市价单
+func (t OrderType) IsMarket() bool {
+	return t == OrderTypeBuy || t == OrderTypeSell
+}
+
+// IsPending 挂单(limit/stop/stop limit)
+func (t OrderType) IsPending() bool {
+	return t >= OrderTypeBuyLimit && t <= OrderTypeSellStopLimit
+}
+
+// IsStopLimit stop limit单, 需要 trigger_price
+func (t OrderType) IsStopLimit() bool {
+	return t == OrderTypeBuyStopLimit || t == OrderTypeSellStopLimit
+}
+
+// Opposite 反方向的同类订单, 比如 buy_limit -> sell_limit; close_by 和未知类型返回自身
+func (t OrderType) Opposite() OrderType {
+	if t < OrderTypeBuy || t > OrderTypeSellStopLimit {
+		return t
+	}
+	return t ^ 1
+}
+
+// OrderState 订单状态
+// https://support.metaquotes.net/en/docs/mt5/api/reference_trading/trading_order/imtorder/imtorder_enum#enorderstate
+type OrderState uint
+
+const (
+	OrderStateStarted       OrderState = 0 //ORDER_STATE_STARTED 已收到, 还没有检查
+	OrderStatePlaced        OrderState = 1 //ORDER_STATE_PLACED 已挂出
+	OrderStateCanceled      OrderState = 2 //ORDER_STATE_CANCELED 客户撤单
+	OrderStatePartial       OrderState = 3 //ORDER_STATE_PARTIAL 部分成交
+	OrderStateFilled        OrderState = 4 //ORDER_STATE_FILLED 全部成交
+	OrderStateRejected      OrderState = 5 //ORDER_STATE_REJECTED 被拒绝
+	OrderStateExpired       OrderState = 6 //ORDER_STATE_EXPIRED 过期
+	OrderStateRequestAdd    OrderState = 7 //ORDER_STATE_REQUEST_ADD
+	OrderStateRequestModify OrderState = 8 //ORDER_STATE_REQUEST_MODIFY
+	OrderStateRequestCancel OrderState = 9 //ORDER_STATE_REQUEST_CANCEL
+)
+
+var orderStates = newEnum("order state", map[OrderState]string{
+	OrderStateStarted:       "started",
+	OrderStatePlaced:        "placed",
+	OrderStateCanceled:      "canceled",
+	OrderStatePartial:       "partial",
+	OrderStateFilled:        "filled",
+	OrderStateRejected:      "rejected",
+	OrderStateExpired:       "expired",
+	OrderStateRequestAdd:    "request_add",
+	OrderStateRequestModify: "request_modify",
+	OrderStateRequestCancel: "request_cancel",
+})
+
+func ParseOrderState(s string) (OrderState, error) {
+	return orderStates.parse(s)
+}
+
+func (s OrderState) String() string {
+	return orderStates.name(s)
+}
+
+func (s OrderState) IsValid() bool {
+	return orderStates.valid(s)
+}
+
+func (s OrderState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *OrderState) UnmarshalText(text []byte) error {
+	return orderStates.unmarshalText(text, s)
+}
+
+func (s OrderState) MarshalJSON() ([]byte, error) {
+	return orderStates.marshalJSON(s)
+}
+
+func (s *OrderState) UnmarshalJSON(data []byte) error {
+	return orderStates.unmarshalJSON(data, s)
+}
+
+func (s OrderState) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return orderStates.encodeMsgpack(enc, s)
+}
+
+func (s *OrderState) DecodeMsgpack(dec *msgpack.Decoder) error {
+	return orderStates.decodeMsgpack(dec, s)
+}
+
+// IsFinal 订单已经结束(撤单/成交/拒绝/过期), 状态不会再变
+func (s OrderState) IsFinal() bool {
+	switch s {
+	case OrderStateCanceled, OrderStateFilled, OrderStateRejected, OrderStateExpired:
+		return true
+	}
+	return false
+}
+
+// OrderActivation 挂单的激活状态
+// https://support.metaquotes.net/en/docs/mt5/api/reference_trading/trading_order/imtorder/imtorder_enum#enorderactivation
+type OrderActivation uint
+
+const (
+	OrderActivationNone       OrderActivation = 0 //ACTIVATION_NONE
+	OrderActivationPending    OrderActivation = 1 //ACTIVATION_PENDING 挂单价格已到, 等待成交
+	OrderActivationStopLimit  OrderActivation = 2 //ACTIVATION_STOPLIMIT stop limit单已触发, 等待挂出limit单
+	OrderActivationExpiration OrderActivation = 3 //ACTIVATION_EXPIRATION 已过期, 等待撤单
+	OrderActivationStopout    OrderActivation = 4 //ACTIVATION_STOPOUT 强平撤单
+)
+
+var orderActivations = newEnum("order activation", map[OrderActivation]string{
+	OrderActivationNone:       "none",
+	OrderActivationPending:    "pending",
+	OrderActivationStopLimit:  "stop_limit",
+	OrderActivationExpiration: "expiration",
+	OrderActivationStopout:    "stopout",
+})
+
+func ParseOrderActivation(s string) (OrderActivation, error) {
+	return orderActivations.parse(s)
+}
+
+func (a OrderActivation) String() string {
+	return orderActivations.name(a)
+}
+
+func (a OrderActivation) IsValid() bool {
+	return orderActivations.valid(a)
+}
+
+func (a OrderActivation) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *OrderActivation) UnmarshalText(text []byte) error {
+	return orderActivations.unmarshalText(text, a)
+}
+
+func (a OrderActivation) MarshalJSON() ([]byte, error) {
+	return orderActivations.marshalJSON(a)
+}
+
+func (a *OrderActivation) UnmarshalJSON(data []byte) error {
+	return orderActivations.unmarshalJSON(data, a)
+}
+
+func (a OrderActivation) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return orderActivations.encodeMsgpack(enc, a)
+}
+
+func (a *OrderActivation) DecodeMsgpack(dec *msgpack.Decoder) error {
+	return orderActivations.decodeMsgpack(dec, a)
+}
+
+// OrderTime 挂单挂到什么时候
+// https://support.metaquotes.net/en/docs/mt5/api/reference_trading/trading_order/imtorder/imtorder_enum#enordertime
+type OrderTime uint
+
+const (
+	OrderTimeGTC          OrderTime = 0 //ORDER_TIME_GTC 撤单前一直有效
+	OrderTimeDay          OrderTime = 1 //ORDER_TIME_DAY 当天有效
+	OrderTimeSpecified    OrderTime = 2 //ORDER_TIME_SPECIFIED 到 expire_time 为止
+	OrderTimeSpecifiedDay OrderTime = 3 //ORDER_TIME_SPECIFIED_DAY 到 expire_time 当天结束为止
+)
+
+var orderTimes = newEnum("order time", map[OrderTime]string{
+	OrderTimeGTC:          "gtc",
+	OrderTimeDay:          "day",
+	OrderTimeSpecified:    "specified",
+	OrderTimeSpecifiedDay: "specified_day",
+})
+
+func ParseOrderTime(s string) (OrderTime, error) {
+	return orderTimes.parse(s)
+}
+
+func (t OrderTime) String() string {
+	return orderTimes.name(t)
+}
+
+func (t OrderTime) IsValid() bool {
+	return orderTimes.valid(t)
+}
+
+func (t OrderTime) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *OrderTime) UnmarshalText(text []byte) error {
+	return orderTimes.unmarshalText(text, t)
+}
+
+func (t OrderTime) MarshalJSON() ([]byte, error) {
+	return orderTimes.marshalJSON(t)
+}
+
+func (t *OrderTime) UnmarshalJSON(data []byte) error {
+	return orderTimes.unmarshalJSON(data, t)
+}
+
+func (t OrderTime) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return orderTimes.encodeMsgpack(enc, t)
+}
+
+func (t *OrderTime) DecodeMsgpack(dec *msgpack.Decoder) error {
+	return orderTimes.decodeMsgpack(dec, t)
+}
+
+// HasExpiration 是否需要指定 expire_time
+func (t OrderTime) HasExpiration() bool {
+	return t == OrderTimeSpecified || t == OrderTimeSpecifiedDay
+}
+
+// PositionAction 持仓方向
+// https://support.metaquotes.net/en/docs/mt5/api/reference_trading/trading_position/imtposition/imtposition_enum#enpositionaction
+type PositionAction uint
+
+const (
+	PositionActionBuy  PositionAction = 0 //POSITION_BUY
+	PositionActionSell PositionAction = 1 //POSITION_SELL
+)
+
+var positionActions = newEnum("position action", map[PositionAction]string{
+	PositionActionBuy:  "buy",
+	PositionActionSell: "sell",
+})
+
+func ParsePositionAction(s string) (PositionAction, error) {
+	return positionActions.parse(s)
+}
+
+func (a PositionAction) String() string {
+	return positionActions.name(a)
+}
+
+func (a PositionAction) IsValid() bool {
+	return positionActions.valid(a)
+}
+
+func (a PositionAction) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *PositionAction) UnmarshalText(text []byte) error {
+	return positionActions.unmarshalText(text, a)
+}
+
+func (a PositionAction) MarshalJSON() ([]byte, error) {
+	return positionActions.marshalJSON(a)
+}
+
+func (a *PositionAction) UnmarshalJSON(data []byte) error {
+	return positionActions.unmarshalJSON(data, a)
+}
+
+func (a PositionAction) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return positionActions.encodeMsgpack(enc, a)
+}
+
+func (a *PositionAction) DecodeMsgpack(dec *msgpack.Decoder) error {
+	return positionActions.decodeMsgpack(dec, a)
+}
+
+func (a PositionAction) IsBuy() bool {
+	return a == PositionActionBuy
+}
+func (a PositionAction) IsSell() bool {
+	return a == PositionActionSell
+}
+
+// Opposite 平仓方向
+func (a PositionAction) Opposite() PositionAction {
+	if a > PositionActionSell {
+		return a
+	}
+	return a ^ 1
+}
+
+// OrderType 开这个方向的持仓用的市价单类型
+func (a PositionAction) OrderType() OrderType {
+	return OrderType(a)
+}
+
+// PositionActivation 持仓被平掉的原因
+// https://support.metaquotes.net/en/docs/mt5/api/reference_trading/trading_position/imtposition/imtposition_enum#enactivation
+type PositionActivation uint
+
+const (
+	PositionActivationNone    PositionActivation = 0 //ACTIVATION_NONE
+	PositionActivationSL      PositionActivation = 1 //ACTIVATION_SL 止损
+	PositionActivationTP      PositionActivation = 2 //ACTIVATION_TP 止盈
+	PositionActivationStopout PositionActivation = 3 //ACTIVATION_STOPOUT 强平
+)
+
+var positionActivations = newEnum("position activation", map[PositionActivation]string{
+	PositionActivationNone:    "none",
+	PositionActivationSL:      "sl",
+	PositionActivationTP:      "tp",
+	PositionActivationStopout: "stopout",
+})
+
+func ParsePositionActivation(s string) (PositionActivation, error) {
+	return positionActivations.parse(s)
+}
+
+func (a PositionActivation) String() string {
+	return positionActivations.name(a)
+}
+
+func (a PositionActivation) IsValid() bool {
+	return positionActivations.valid(a)
+}
+
+func (a PositionActivation) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *PositionActivation) UnmarshalText(text []byte) error {
+	return positionActivations.unmarshalText(text, a)
+}
+
+func (a PositionActivation) MarshalJSON() ([]byte, error) {
+	return positionActivations.marshalJSON(a)
+}
+
+func (a *PositionActivation) UnmarshalJSON(data []byte) error {
+	return positionActivations.unmarshalJSON(data, a)
+}
+
+func (a PositionActivation) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return positionActivations.encodeMsgpack(enc, a)
+}
+
+func (a *PositionActivation) DecodeMsgpack(dec *msgpack.Decoder) error {
+	return positionActivations.decodeMsgpack(dec, a)
+}
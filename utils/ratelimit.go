@@ -0,0 +1,254 @@
+package utils
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit 令牌桶配置
+type RateLimit struct {
+	Rate  float64 `json:"rate" mapstructure:"rate" config:"rate" yaml:"rate"`     //每秒补充的令牌数, 0则不限制(可以让Routes里的接口不受Default限制)
+	Burst int     `json:"burst" mapstructure:"burst" config:"burst" yaml:"burst"` //桶的容量(允许的突发请求数), 默认等于rate向上取整
+}
+
+// RateLimitConfig 客户端限流配置
+type RateLimitConfig struct {
+	Default  *RateLimit           `json:"default,omitempty" mapstructure:"default" config:"default" yaml:"default"`         //没有在Routes里单独配置的接口使用该配置(每个接口一个桶), nil则不限制
+	Routes   map[string]RateLimit `json:"routes,omitempty" mapstructure:"routes" config:"routes" yaml:"routes"`             //按接口路径单独配置, 比如 /v1/position/open
+	PerLogin *RateLimit           `json:"per_login,omitempty" mapstructure:"per_login" config:"per_login" yaml:"per_login"` //每个mt5 login一个桶(所有接口共享), nil则不限制
+	FailFast bool                 `json:"fail_fast" mapstructure:"fail_fast" config:"fail_fast" yaml:"fail_fast"`           //没有令牌时直接返回 RateLimitError, 否则阻塞等待直到有令牌或ctx结束
+}
+
+// ErrRateLimited 被客户端限流
+var ErrRateLimited = errors.New("mt5: rate limited")
+
+// RateLimitError 被限流时返回的错误, errors.Is(err, ErrRateLimited) 为true
+type RateLimitError struct {
+	Endpoint   string        //接口路径
+	Login      uint64        //按login限流时的login, 否则为0
+	RetryAfter time.Duration //大约多久之后会有令牌
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Login != 0 {
+		return fmt.Sprintf("mt5 %s: rate limited for login %d, retry after %v", e.Endpoint, e.Login, e.RetryAfter)
+	}
+	return fmt.Sprintf("mt5 %s: rate limited, retry after %v", e.Endpoint, e.RetryAfter)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+//------------------------------------------------------------------------
+
+// TokenBucket 令牌桶, 可以在多个goroutine之间共享
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket 创建令牌桶, 初始是满的; rate<=0 时返回nil(不限流)
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// refill 调用方需要持有锁
+func (b *TokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+	}
+	b.last = now
+}
+
+// Allow 有令牌则取走一个并返回true, 否则返回false和大约需要等待的时间
+func (b *TokenBucket) Allow() (bool, time.Duration) {
+	if b == nil {
+		return true, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, b.waitFor(1 - b.tokens)
+}
+
+// Wait 阻塞直到取到令牌, ctx结束时返回ctx的错误(令牌会归还)
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	b.refill(time.Now())
+	b.tokens-- //先预定, 可能为负数, 后来的请求会排在后面
+	wait := b.waitFor(-b.tokens)
+	b.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	if err := SleepContext(ctx, wait); err != nil {
+		b.refund()
+		return err
+	}
+	return nil
+}
+
+// refund 归还一个令牌
+func (b *TokenBucket) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+func (b *TokenBucket) waitFor(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / b.rate * float64(time.Second))
+}
+
+//------------------------------------------------------------------------
+
+// maxLoginBuckets login桶最多保留这么多个, 超过时淘汰最久没有用过的
+const maxLoginBuckets = 10000
+
+// loginEntry loginLRU 里的元素
+type loginEntry struct {
+	login  uint64
+	bucket *TokenBucket
+}
+
+// RateLimiter 按接口和login限流, 可以被多个client共享
+type RateLimiter struct {
+	cfg RateLimitConfig
+
+	mu        sync.Mutex
+	routes    map[string]*TokenBucket
+	logins    map[uint64]*list.Element
+	loginLRU  *list.List //最近用过的在前面
+	maxLogins int
+}
+
+// NewRateLimiter cfg为nil时返回nil(不限流)
+func NewRateLimiter(cfg *RateLimitConfig) *RateLimiter {
+	if cfg == nil {
+		return nil
+	}
+	return &RateLimiter{
+		cfg:       *cfg,
+		routes:    make(map[string]*TokenBucket),
+		logins:    make(map[uint64]*list.Element),
+		loginLRU:  list.New(),
+		maxLogins: maxLoginBuckets,
+	}
+}
+
+// Acquire 获取一次请求的令牌, 先按接口再按login
+// FailFast 时没有令牌直接返回 *RateLimitError, 否则阻塞等待
+func (l *RateLimiter) Acquire(ctx context.Context, endpoint string, login uint64) error {
+	if l == nil {
+		return nil
+	}
+
+	routeBucket := l.routeBucket(endpoint)
+	loginBucket := l.loginBucket(login)
+
+	if l.cfg.FailFast {
+		if routeBucket != nil {
+			if ok, wait := routeBucket.Allow(); !ok {
+				return &RateLimitError{Endpoint: endpoint, RetryAfter: wait}
+			}
+		}
+		if loginBucket != nil {
+			if ok, wait := loginBucket.Allow(); !ok {
+				if routeBucket != nil {
+					routeBucket.refund()
+				}
+				return &RateLimitError{Endpoint: endpoint, Login: login, RetryAfter: wait}
+			}
+		}
+		return nil
+	}
+
+	if routeBucket != nil {
+		if err := routeBucket.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if loginBucket != nil {
+		if err := loginBucket.Wait(ctx); err != nil {
+			if routeBucket != nil {
+				routeBucket.refund()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *RateLimiter) routeBucket(endpoint string) *TokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, ok := l.routes[endpoint]; ok {
+		return b
+	}
+
+	var b *TokenBucket
+	if limit, ok := l.cfg.Routes[endpoint]; ok {
+		b = NewTokenBucket(limit.Rate, limit.Burst)
+	} else if l.cfg.Default != nil {
+		b = NewTokenBucket(l.cfg.Default.Rate, l.cfg.Default.Burst)
+	}
+	l.routes[endpoint] = b //不限流的接口也记下来, 避免重复判断
+	return b
+}
+
+func (l *RateLimiter) loginBucket(login uint64) *TokenBucket {
+	if login == 0 || l.cfg.PerLogin == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.logins[login]; ok {
+		l.loginLRU.MoveToFront(e)
+		return e.Value.(*loginEntry).bucket
+	}
+	for l.loginLRU.Len() >= l.maxLogins {
+		oldest := l.loginLRU.Back()
+		l.loginLRU.Remove(oldest)
+		delete(l.logins, oldest.Value.(*loginEntry).login)
+	}
+	b := NewTokenBucket(l.cfg.PerLogin.Rate, l.cfg.PerLogin.Burst)
+	l.logins[login] = l.loginLRU.PushFront(&loginEntry{login: login, bucket: b})
+	return b
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := NewTokenBucket(10, 2)
+	for i := 0; i < 2; i++ {
+		if ok, _ := b.Allow(); !ok {
+			t.Fatalf("request %d within burst was rejected", i+1)
+		}
+	}
+	ok, wait := b.Allow()
+	if ok || wait <= 0 || wait > 100*time.Millisecond {
+		t.Fatalf("Allow() after burst = %v, %v", ok, wait)
+	}
+
+	//每秒10个, 大约100ms补充一个
+	time.Sleep(110 * time.Millisecond)
+	if ok, _ := b.Allow(); !ok {
+		t.Fatal("token was not refilled")
+	}
+
+	start := time.Now()
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Fatalf("Wait returned after %v, want about 100ms", d)
+	}
+}
+
+func TestTokenBucketWaitCanceled(t *testing.T) {
+	b := NewTokenBucket(1, 1)
+	b.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait = %v, want deadline exceeded", err)
+	}
+	//预定的令牌已经归还, 后面的请求不用多等一秒
+	b.mu.Lock()
+	tokens := b.tokens
+	b.mu.Unlock()
+	if tokens < -0.1 {
+		t.Fatalf("tokens = %v after canceled Wait, reservation was not refunded", tokens)
+	}
+}
+
+// rate 为0表示不限流, 不能一直阻塞
+func TestTokenBucketZeroRate(t *testing.T) {
+	if b := NewTokenBucket(0, 5); b != nil {
+		t.Fatalf("NewTokenBucket(0) = %+v, want nil", b)
+	}
+	var b *TokenBucket
+	if ok, _ := b.Allow(); !ok {
+		t.Fatal("nil bucket rejected a request")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := b.Wait(ctx); err != nil {
+		t.Fatalf("Wait on nil bucket: %v", err)
+	}
+
+	l := NewRateLimiter(&RateLimitConfig{
+		Default: &RateLimit{Rate: 1, Burst: 1},
+		Routes:  map[string]RateLimit{"/v1/symbol/list": {Rate: 0}},
+	})
+	for i := 0; i < 5; i++ {
+		if err := l.Acquire(ctx, "/v1/symbol/list", 0); err != nil {
+			t.Fatalf("unlimited route: %v", err)
+		}
+	}
+}
+
+func TestRateLimiterFailFast(t *testing.T) {
+	l := NewRateLimiter(&RateLimitConfig{
+		Default:  &RateLimit{Rate: 1, Burst: 2},
+		PerLogin: &RateLimit{Rate: 1, Burst: 1},
+		FailFast: true,
+	})
+	ctx := context.Background()
+	const open = "/v1/position/open"
+
+	if err := l.Acquire(ctx, open, 7); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	//接口还有令牌, login没有了
+	err := l.Acquire(ctx, open, 7)
+	var rlErr *RateLimitError
+	if !errors.As(err, &rlErr) || !errors.Is(err, ErrRateLimited) || rlErr.Login != 7 || rlErr.RetryAfter <= 0 {
+		t.Fatalf("login limited: err = %v", err)
+	}
+	//被login拒绝时归还了接口的令牌, 其他login还能用
+	if err := l.Acquire(ctx, open, 8); err != nil {
+		t.Fatalf("other login: %v", err)
+	}
+	err = l.Acquire(ctx, open, 9)
+	if !errors.As(err, &rlErr) || rlErr.Login != 0 || rlErr.Endpoint != open {
+		t.Fatalf("route limited: err = %v", err)
+	}
+	//其他接口各自一个桶
+	if err := l.Acquire(ctx, "/v1/position/close", 0); err != nil {
+		t.Fatalf("other route: %v", err)
+	}
+
+	var nilLimiter *RateLimiter
+	if err := nilLimiter.Acquire(ctx, open, 7); err != nil {
+		t.Fatalf("nil limiter: %v", err)
+	}
+}
+
+// login桶按LRU淘汰, 不管桶是不是满的, 数量都不会超过上限
+func TestRateLimiterLoginEviction(t *testing.T) {
+	l := NewRateLimiter(&RateLimitConfig{PerLogin: &RateLimit{Rate: 0.001, Burst: 1}, FailFast: true})
+	l.maxLogins = 3
+	ctx := context.Background()
+
+	for login := uint64(1); login <= 3; login++ {
+		if err := l.Acquire(ctx, "/v1/position/open", login); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//login 1 最近用过, 淘汰的是 2
+	if err := l.Acquire(ctx, "/v1/position/open", 1); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("login 1: err = %v, want rate limited", err)
+	}
+	for login := uint64(4); login <= 10; login++ {
+		l.Acquire(ctx, "/v1/position/open", login)
+		if n := len(l.logins); n > 3 || l.loginLRU.Len() != n {
+			t.Fatalf("%d login buckets (lru %d), cap is 3", n, l.loginLRU.Len())
+		}
+	}
+	if _, ok := l.logins[10]; !ok {
+		t.Fatal("newest login was evicted")
+	}
+	if _, ok := l.logins[2]; ok {
+		t.Fatal("least recently used login was kept")
+	}
+}
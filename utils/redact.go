@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RedactedValue 被脱敏字段的替换值
+const RedactedValue = "***"
+
+// DefaultRedactFields 默认脱敏的json字段/query参数(不区分大小写)
+var DefaultRedactFields = []string{
+	"master_pass", "investor_pass", "password", "pass", "secret", "token", "api_key",
+}
+
+// DefaultRedactHeaders 默认脱敏的header
+var DefaultRedactHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", HeaderAPIKey, HeaderSignature,
+}
+
+// LogConfig 请求/返回日志的配置
+// 默认: 成功的查询请求输出Debug, 成功的交易请求输出Info, 失败输出Warn
+type LogConfig struct {
+	RedactFields  []string            `json:"redact_fields" mapstructure:"redact_fields" config:"redact_fields" yaml:"redact_fields"`     //需要脱敏的json字段/query参数, 为空则使用 DefaultRedactFields
+	RedactHeaders []string            `json:"redact_headers" mapstructure:"redact_headers" config:"redact_headers" yaml:"redact_headers"` //需要脱敏的header, 为空则使用 DefaultRedactHeaders
+	MaxBodySize   int                 `json:"max_body_size" mapstructure:"max_body_size" config:"max_body_size" yaml:"max_body_size"`     //body超过这个长度会被截断, 默认4096, <0不截断
+	Levels        map[string]LogLevel `json:"levels" mapstructure:"levels" config:"levels" yaml:"levels"`                                 //按接口名(比如 ListSymbol)或路径单独指定成功时的日志级别
+}
+
+// Level 一次请求的日志级别, 失败一律Warn
+func (c *LogConfig) Level(endpoint, path, method string, err error) LogLevel {
+	if err != nil {
+		return LogLevelWarn
+	}
+	if c != nil {
+		if level, ok := c.Levels[endpoint]; ok {
+			return level
+		}
+		if level, ok := c.Levels[path]; ok {
+			return level
+		}
+	}
+	if method == http.MethodGet {
+		return LogLevelDebug
+	}
+	return LogLevelInfo
+}
+
+// RestyLog 生成脱敏和截断后的请求/返回日志, c为nil时使用默认配置
+func (c *LogConfig) RestyLog(resp *resty.Response) RestyLog {
+	r := newRedactor(c)
+
+	return RestyLog{
+		Request: RestyRequest{
+			Method:  resp.Request.Method,
+			Url:     r.redactURL(resp.Request.URL),
+			Headers: r.redactHeaders(resp.Request.Header, "User-Agent"),
+			Body:    r.requestBody(resp.Request.Body),
+		},
+		Response: RestyResponse{
+			StatusCode: resp.StatusCode(),
+			Status:     resp.Status(),
+			Headers:    r.redactHeaders(resp.Header()),
+			Body:       r.body(resp.Body()),
+			ReceivedAt: resp.ReceivedAt(),
+		},
+	}
+}
+
+// RedactBody json按字段脱敏(会按key排序重新序列化), 不截断, 不是json则原样返回; c为nil时使用默认配置
+func (c *LogConfig) RedactBody(b []byte) []byte {
+	r := newRedactor(c)
+	r.maxBodySize = -1
+	return []byte(r.body(b))
+}
+
+// RedactHeader 返回脱敏后的header副本
+func (c *LogConfig) RedactHeader(h http.Header) http.Header {
+	return newRedactor(c).redactHeaders(h)
+}
+
+// RedactQuery 返回脱敏后的query参数副本
+func (c *LogConfig) RedactQuery(query url.Values) url.Values {
+	return newRedactor(c).redactQuery(query)
+}
+
+//------------------------------------------------------------------------
+
+type redactor struct {
+	fields      map[string]bool
+	headers     map[string]bool
+	maxBodySize int
+}
+
+func newRedactor(c *LogConfig) *redactor {
+	if c == nil {
+		c = &LogConfig{}
+	}
+	fields := c.RedactFields
+	if len(fields) == 0 {
+		fields = DefaultRedactFields
+	}
+	headers := c.RedactHeaders
+	if len(headers) == 0 {
+		headers = DefaultRedactHeaders
+	}
+
+	r := &redactor{
+		fields:      make(map[string]bool, len(fields)),
+		headers:     make(map[string]bool, len(headers)),
+		maxBodySize: c.MaxBodySize,
+	}
+	if r.maxBodySize == 0 {
+		r.maxBodySize = 4096
+	}
+	for _, f := range fields {
+		r.fields[strings.ToLower(f)] = true
+	}
+	for _, h := range headers {
+		r.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	return r
+}
+
+// redactHeaders 复制一份再脱敏, 不修改原始的header
+func (r *redactor) redactHeaders(h http.Header, drop ...string) http.Header {
+	out := h.Clone()
+	for _, k := range drop {
+		out.Del(k)
+	}
+	for k := range out {
+		if r.headers[http.CanonicalHeaderKey(k)] {
+			out[k] = []string{RedactedValue}
+		}
+	}
+	return out
+}
+
+func (r *redactor) redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+	u.RawQuery = r.redactQuery(u.Query()).Encode()
+	return u.String()
+}
+
+func (r *redactor) redactQuery(query url.Values) url.Values {
+	out := make(url.Values, len(query))
+	for k, v := range query {
+		if r.fields[strings.ToLower(k)] {
+			out[k] = []string{RedactedValue}
+		} else {
+			out[k] = append([]string(nil), v...)
+		}
+	}
+	return out
+}
+
+// requestBody 请求体是已经序列化好的json时, 原样输出而不是base64
+func (r *redactor) requestBody(body interface{}) interface{} {
+	b, ok := body.([]byte)
+	if !ok {
+		if body == nil {
+			return nil
+		}
+		var err error
+		if b, err = json.Marshal(body); err != nil {
+			return body
+		}
+	}
+	redacted := r.body(b)
+	if json.Valid([]byte(redacted)) {
+		return json.RawMessage(redacted)
+	}
+	return redacted
+}
+
+// body json按字段脱敏, 然后截断
+func (r *redactor) body(b []byte) string {
+	if json.Valid(b) {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err == nil {
+			if out, err := json.Marshal(r.redact(v)); err == nil {
+				b = out
+			}
+		}
+	}
+
+	if r.maxBodySize > 0 && len(b) > r.maxBodySize {
+		return fmt.Sprintf("%s...(truncated %d bytes)", b[:r.maxBodySize], len(b)-r.maxBodySize)
+	}
+	return string(b)
+}
+
+func (r *redactor) redact(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if r.fields[strings.ToLower(k)] {
+				val[k] = RedactedValue
+			} else {
+				val[k] = r.redact(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = r.redact(child)
+		}
+	}
+	return v
+}
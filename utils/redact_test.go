@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *LogConfig
+		in   string
+		want string
+	}{
+		{"default fields", nil,
+			`{"login":1001,"master_pass":"m","investor_pass":"i","Password":"p","name":"bob"}`,
+			`{"Password":"***","investor_pass":"***","login":1001,"master_pass":"***","name":"bob"}`},
+		{"nested and arrays", nil,
+			`{"data":[{"token":"t","id":1},{"user":{"secret":{"k":"v"}}}]}`,
+			`{"data":[{"id":1,"token":"***"},{"user":{"secret":"***"}}]}`},
+		{"numbers keep precision", nil, `{"balance":12345678901234567890.123456789}`, `{"balance":12345678901234567890.123456789}`},
+		{"custom fields replace defaults", &LogConfig{RedactFields: []string{"Email"}},
+			`{"email":"a@b.c","password":"p"}`, `{"email":"***","password":"p"}`},
+		{"not json", nil, `password=p`, `password=p`},
+		{"empty", nil, ``, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(tt.cfg.RedactBody([]byte(tt.in))); got != tt.want {
+				t.Fatalf("RedactBody(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactHeaderAndQuery(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer x")
+	h.Set(HeaderAPIKey, "key")
+	h.Set(HeaderSignature, "sig")
+	h.Set("X-Request-Id", "r1")
+
+	got := (*LogConfig)(nil).RedactHeader(h)
+	for _, k := range []string{"Authorization", HeaderAPIKey, HeaderSignature} {
+		if got.Get(k) != RedactedValue {
+			t.Errorf("header %s = %q, want redacted", k, got.Get(k))
+		}
+	}
+	if got.Get("X-Request-Id") != "r1" || h.Get("Authorization") != "Bearer x" {
+		t.Fatalf("RedactHeader changed the wrong headers: %v / original %v", got, h)
+	}
+	custom := (&LogConfig{RedactHeaders: []string{"x-request-id"}}).RedactHeader(h)
+	if custom.Get("X-Request-Id") != RedactedValue || custom.Get("Authorization") != "Bearer x" {
+		t.Fatalf("custom RedactHeader = %v", custom)
+	}
+
+	q := url.Values{"login": {"1001"}, "TOKEN": {"t"}, "pass": {"a", "b"}}
+	gotQ := (*LogConfig)(nil).RedactQuery(q)
+	if gotQ.Encode() != "TOKEN=%2A%2A%2A&login=1001&pass=%2A%2A%2A" || q.Get("TOKEN") != "t" {
+		t.Fatalf("RedactQuery = %s, original %v", gotQ.Encode(), q)
+	}
+}
+
+func TestRedactTruncate(t *testing.T) {
+	r := newRedactor(&LogConfig{MaxBodySize: 10})
+	if got := r.body([]byte("0123456789abcdef")); got != "0123456789...(truncated 6 bytes)" {
+		t.Fatalf("body = %q", got)
+	}
+	//先脱敏再截断, 敏感字段不会因为截断位置露出来
+	if got := r.body([]byte(`{"password":"secret-value"}`)); strings.Contains(got, "secret") {
+		t.Fatalf("body = %q", got)
+	}
+	r = newRedactor(&LogConfig{MaxBodySize: -1})
+	if got := r.body([]byte(strings.Repeat("x", 5000))); len(got) != 5000 {
+		t.Fatalf("MaxBodySize<0 truncated the body to %d bytes", len(got))
+	}
+}
+
+// captureLogger 记录所有日志
+type captureLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *captureLogger) log(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) Debugf(format string, args ...interface{}) { l.log(format, args...) }
+func (l *captureLogger) Infof(format string, args ...interface{})  { l.log(format, args...) }
+func (l *captureLogger) Warnf(format string, args ...interface{})  { l.log(format, args...) }
+func (l *captureLogger) Errorf(format string, args ...interface{}) { l.log(format, args...) }
+
+// 请求日志里不能出现密码/签名/api key
+func TestRestClientLogRedacted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=server-cookie")
+		w.Write([]byte(`{"code":0,"success":true,"data":{"login":1001,"token":"server-token"}}`))
+	}))
+	defer srv.Close()
+
+	logger := &captureLogger{}
+	cli := NewRestClient(logger, &ClientParams{Address: srv.URL, Sign: &SignConfig{APIKey: "my-api-key", Secret: "my-secret"}})
+	err := cli.Do(context.Background(), &Call{
+		Endpoint: "UserCreate", Method: http.MethodPost, Path: "/v1/user/create",
+		Query:    map[string]string{"password": "query-pass"},
+		Request:  map[string]interface{}{"login": 1001, "master_pass": "master-pass", "investor_pass": "investor-pass"},
+		Response: &CommonResp{},
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	logs := strings.Join(logger.lines, "\n")
+	if !strings.Contains(logs, "UserCreate") || !strings.Contains(logs, "1001") {
+		t.Fatalf("request was not logged: %s", logs)
+	}
+	for _, secret := range []string{"query-pass", "master-pass", "investor-pass", "my-api-key", "server-cookie", "server-token"} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("log contains %q: %s", secret, logs)
+		}
+	}
+}
@@ -28,26 +28,7 @@ type RestyLog struct {
 
 //--------------------------------------------------------
 
+// GetRestyLog 使用默认配置生成请求/返回日志(密码/签名等字段会被脱敏, body超长会被截断)
 func GetRestyLog(resp *resty.Response) RestyLog {
-	//request header
-	reqHeaders := map[string][]string(resp.Request.Header)
-	delete(reqHeaders, "User-Agent")
-
-	return RestyLog{
-		Request: RestyRequest{
-			Method:  resp.Request.Method,
-			Url:     resp.Request.URL,
-			Headers: reqHeaders, //resp.Request.Header,
-			Body:    resp.Request.Body,
-			//"time":    resp.Request.Time,
-		},
-		Response: RestyResponse{
-			StatusCode: resp.StatusCode(),
-			Status:     resp.Status(),
-			Headers:    resp.Header(),
-			Body:       resp.String(),
-			ReceivedAt: resp.ReceivedAt(),
-			//"time":        resp.Time(),
-		},
-	}
+	return (*LogConfig)(nil).RestyLog(resp)
 }
@@ -0,0 +1,449 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/json-iterator/go"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// ClientParams direct.InitParams 和 order.InitParams 的定义
+type ClientParams struct {
+	Address   string        `json:"address" mapstructure:"address" config:"address" yaml:"address"`         // http://ip:port这样的地址, 主网关
+	Addresses []string      `json:"addresses" mapstructure:"addresses" config:"addresses" yaml:"addresses"` // 备用网关, 按优先级排列; 也可以不填Address, 只用Addresses(第一个为主网关)
+	Timeout   time.Duration `json:"timeout" mapstructure:"timeout" config:"timeout" yaml:"timeout"`         // 单次http请求的超时(每次重试单独计算), 同时受调用方ctx的控制. 0则不限制
+
+	TLS       *TLSConfig       `json:"tls,omitempty" mapstructure:"tls" config:"tls" yaml:"tls"`                             // https配置(CA/mTLS/SNI/证书锁定), nil则使用系统CA校验
+	Transport *TransportConfig `json:"transport,omitempty" mapstructure:"transport" config:"transport" yaml:"transport"`     // 连接池配置, nil则使用默认值
+	Retry     *RetryPolicy     `json:"retry,omitempty" mapstructure:"retry" config:"retry" yaml:"retry"`                     // 重试策略, nil则使用 DefaultRetryPolicy
+	Sign      *SignConfig      `json:"sign,omitempty" mapstructure:"sign" config:"sign" yaml:"sign"`                         // 请求签名(api key + HMAC-SHA256), nil则不签名
+	RateLimit *RateLimitConfig `json:"rate_limit,omitempty" mapstructure:"rate_limit" config:"rate_limit" yaml:"rate_limit"` // 客户端限流(按接口/按login), nil则不限流
+
+	Failover       *FailoverConfig       `json:"failover,omitempty" mapstructure:"failover" config:"failover" yaml:"failover"`                             // 多网关健康检查/切换配置, 配置了多个网关时nil使用默认值
+	Log            *LogConfig            `json:"log,omitempty" mapstructure:"log" config:"log" yaml:"log"`                                                 // 请求日志的脱敏/截断/级别, nil则使用默认配置
+	CircuitBreaker *CircuitBreakerConfig `json:"circuit_breaker,omitempty" mapstructure:"circuit_breaker" config:"circuit_breaker" yaml:"circuit_breaker"` // 熔断配置, nil则不熔断
+}
+
+// CommonResp direct接口返回值内嵌的公共部分, direct.CommonResp 是它的别名
+type CommonResp struct {
+	Code    int    `json:"code"`    //错误码 0是成功
+	Success bool   `json:"success"` //是否成功
+	Message string `json:"message"` //错误信息
+	//Data    interface{} `json:"data,omitempty"` //数据
+}
+
+// CommonResult 所有接口的返回值都实现了它(内嵌CommonResp), 用它统一判断业务是否成功
+type CommonResult interface {
+	CommonResult() (success bool, code int, message string)
+}
+
+func (r *CommonResp) CommonResult() (bool, int, string) {
+	return r.Success, r.Code, r.Message
+}
+
+//------------------------------------------------------------------------
+
+// RestClient direct.Client 和 order.Client 共用的请求流程: 中间件 -> 限流 -> 熔断 -> 选网关 -> 签名 -> 发送 -> 重试/确认
+// 可以在多个goroutine之间共享, 底层的连接池只在NewRestClient时配置一次
+type RestClient struct {
+	Params *ClientParams
+
+	ryClient  *resty.Client
+	debugMode atomic.Bool
+	logger    Logger
+
+	initErr   error                      //初始化失败的原因(比如证书读取失败), 不为空时所有请求直接返回该错误
+	confirmer TradeConfirmer             //交易请求结果不明确时, 用来确认是否落地
+	limiter   *RateLimiter               //客户端限流, nil则不限流
+	breakers  map[string]*CircuitBreaker //每个网关一个熔断器, nil则不熔断
+	gateways  *GatewayPool               //多网关, nil则只使用Params.Address
+
+	middlewares        []Middleware //每次调用都会经过的中间件
+	attemptMiddlewares []Middleware //每次http请求(包括重试)都会经过的中间件
+}
+
+// NewRestClient logger为nil时不输出日志; 实现了 StructuredLogger 的logger会带上login/symbol等字段
+func NewRestClient(logger Logger, params *ClientParams) *RestClient {
+	if logger == nil {
+		logger = NopLogger{}
+	}
+
+	cli := &RestClient{
+		Params: params,
+
+		ryClient: resty.New(), //client实例
+		logger:   logger,
+		limiter:  NewRateLimiter(params.RateLimit),
+	}
+
+	//只配置了Addresses时, 第一个作为主网关
+	addresses := append([]string{params.Address}, params.Addresses...)
+	if params.Address == "" && len(params.Addresses) > 0 {
+		params.Address = params.Addresses[0]
+	}
+	if params.CircuitBreaker != nil {
+		cli.breakers = make(map[string]*CircuitBreaker)
+		for _, addr := range addresses {
+			if addr != "" && cli.breakers[addr] == nil {
+				cli.breakers[addr] = NewCircuitBreaker(addr, params.CircuitBreaker, logger)
+			}
+		}
+	}
+
+	//tls和连接池只在初始化时设置一次, 之后的请求不再修改client, 避免并发下的data race
+	tlsConfig, err := BuildTLSConfig(params.TLS)
+	if err != nil {
+		cli.initErr = err
+		logger.Errorf("MT5#NewClient->invalid tls config: %v", err)
+	} else {
+		transport := NewHTTPTransport(params.Transport, tlsConfig)
+		cli.ryClient.SetTransport(transport)
+
+		//多网关时后台做健康检查, 健康检查和请求共用连接池
+		cli.gateways = NewGatewayPool(addresses, params.Failover, transport, logger)
+		cli.gateways.Start()
+	}
+
+	return cli
+}
+
+func (cli *RestClient) SetDebugModel(debugModel bool) {
+	cli.debugMode.Store(debugModel)
+}
+
+// SetTradeConfirmer 设置交易请求结果不明确(超时/5xx)时的确认逻辑, 需要在发起请求前设置
+// 没有设置时, 结果不明确的交易请求会直接返回 AmbiguousError, 不会重试
+func (cli *RestClient) SetTradeConfirmer(confirmer TradeConfirmer) {
+	cli.confirmer = confirmer
+}
+
+// SetRateLimiter 替换限流器, 可以让多个client共享同一个限流器(比如按login限流), 需要在发起请求前设置
+func (cli *RestClient) SetRateLimiter(limiter *RateLimiter) {
+	cli.limiter = limiter
+}
+
+// Use 安装中间件, 对所有接口生效, 先安装的在外层; 需要在发起请求前设置
+func (cli *RestClient) Use(middlewares ...Middleware) {
+	cli.middlewares = append(cli.middlewares, middlewares...)
+}
+
+// UseAttempt 安装每次http请求都会经过的中间件(在重试循环里面, 比如给每次尝试单独创建一个span), 先安装的在外层; 需要在发起请求前设置
+func (cli *RestClient) UseAttempt(middlewares ...Middleware) {
+	cli.attemptMiddlewares = append(cli.attemptMiddlewares, middlewares...)
+}
+
+// WrapTransport 包装底层的http transport(比如 cassette 录制/回放), 只影响请求, 不影响多网关的健康检查; 需要在发起请求前设置
+func (cli *RestClient) WrapTransport(wrap func(next http.RoundTripper) http.RoundTripper) {
+	cli.ryClient.SetTransport(wrap(cli.ryClient.GetClient().Transport))
+}
+
+// SetCircuitStateListener 熔断器状态变化时回调(状态变化同时会输出Warn日志), name是网关地址; 没有配置熔断时无效
+func (cli *RestClient) SetCircuitStateListener(fn func(name string, from, to CircuitState)) {
+	for _, breaker := range cli.breakers {
+		breaker.SetOnStateChange(fn)
+	}
+}
+
+// CircuitState 当前交易网关的熔断器状态, 没有配置熔断时一直是 CircuitClosed
+func (cli *RestClient) CircuitState() CircuitState {
+	return cli.breakers[cli.address(false)].State()
+}
+
+// CircuitStates 每个网关的熔断器状态, 没有配置熔断时返回nil
+func (cli *RestClient) CircuitStates() map[string]CircuitState {
+	if cli.breakers == nil {
+		return nil
+	}
+	states := make(map[string]CircuitState, len(cli.breakers))
+	for addr, breaker := range cli.breakers {
+		states[addr] = breaker.State()
+	}
+	return states
+}
+
+// SetFailoverListener 交易网关切换时回调(切换同时会输出Warn日志), 只配置了一个网关时无效
+func (cli *RestClient) SetFailoverListener(fn func(event FailoverEvent)) {
+	cli.gateways.SetOnFailover(fn)
+}
+
+// GatewayStatus 所有网关当前的健康状态, 只配置了一个网关时返回nil
+func (cli *RestClient) GatewayStatus() []GatewayStatus {
+	if cli.gateways == nil {
+		return nil
+	}
+	return cli.gateways.Status()
+}
+
+// Close 停止后台的健康检查, client不再使用时调用
+func (cli *RestClient) Close() {
+	cli.gateways.Close()
+}
+
+// address 交易请求发往当前的交易网关, 查询请求发往任意健康的网关
+func (cli *RestClient) address(idempotent bool) string {
+	switch {
+	case cli.gateways == nil:
+		return cli.Params.Address
+	case idempotent:
+		return cli.gateways.Any()
+	}
+	return cli.gateways.Primary()
+}
+
+//------------------------------------------------------------------------
+
+// Do 所有接口统一走这里, 返回值会反序列化到call.Response里
+// 请求先经过 Use 安装的中间件, 再进入重试逻辑; call.Header 为nil时会自动创建
+func (cli *RestClient) Do(ctx context.Context, call *Call) error {
+	if cli.initErr != nil {
+		return cli.initErr
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if call.Header == nil {
+		call.Header = map[string]string{}
+	}
+	return Chain(cli.invoke, cli.middlewares...)(ctx, call)
+}
+
+// invoke 中间件链的最后一环, 按照(可能被中间件修改过的)call发起请求
+func (cli *RestClient) invoke(ctx context.Context, call *Call) error {
+	err := cli.retry(ctx, call)
+	call.StatusCode = HTTPStatusOf(err)
+	return err
+}
+
+// retry ctx 的 deadline/cancel 会直接作用到http请求上
+// 查询类(GET)接口失败时按 RetryPolicy 自动重试; 交易类接口结果不明确时先确认是否落地, 不会盲目重试
+// 返回值会反序列化到call.Response里, 尝试次数记录在call.Attempts
+func (cli *RestClient) retry(ctx context.Context, call *Call) error {
+	policy := cli.Params.Retry
+	if policy == nil {
+		policy = DefaultRetryPolicy()
+	}
+	idempotent := call.Method == http.MethodGet
+	send := Chain(cli.send, cli.attemptMiddlewares...)
+
+	for attempt := 1; ; attempt++ {
+		call.Attempts = attempt
+
+		//限流, 重试也需要消耗令牌
+		if err := cli.limiter.Acquire(ctx, call.Path, call.Login); err != nil {
+			return err
+		}
+
+		//熔断中直接失败, 请求确定没有发出去; 多网关时查询请求换一个网关重试
+		addr := cli.address(idempotent)
+		breaker := cli.breakers[addr]
+		if err := breaker.Allow(); err != nil {
+			if !idempotent || cli.gateways == nil || attempt >= policy.MaxAttempts {
+				return err
+			}
+			continue
+		}
+		call.Address = addr
+		start := time.Now()
+		err := send(ctx, call)
+		breaker.Done(err, time.Since(start))
+		if cli.gateways != nil {
+			cli.gateways.Report(addr, err)
+		}
+
+		switch ClassifyError(err) {
+		case ErrorClassNone:
+			return nil
+		case ErrorClassPermanent:
+			return err
+		case ErrorClassAmbiguous:
+			if !idempotent {
+				//交易请求可能已经执行了, 确认没有落地才能重新提交
+				landed, cerr := cli.confirm(ctx, call, policy)
+				if cerr != nil {
+					return &AmbiguousError{Endpoint: call.Path, Err: fmt.Errorf("%w (confirm failed: %v)", err, cerr)}
+				}
+				if landed {
+					return &AmbiguousError{Endpoint: call.Path, Landed: true, Err: err}
+				}
+			}
+		}
+
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		backoff := policy.Backoff(attempt)
+		cli.callLogger(ctx, call).Warnf("MT5#%s->attempt %d/%d failed, retry after %v: %v", call.Endpoint, attempt, policy.MaxAttempts, backoff, err)
+		if SleepContext(ctx, backoff) != nil {
+			return err
+		}
+	}
+}
+
+// callLogger 日志带上login/symbol以及ctx里的字段
+func (cli *RestClient) callLogger(ctx context.Context, call *Call) Logger {
+	var keyvals []interface{}
+	if call.Login != 0 {
+		keyvals = append(keyvals, "login", call.Login)
+	}
+	if call.Symbol != "" {
+		keyvals = append(keyvals, "symbol", call.Symbol)
+	}
+	return With(WithContext(ctx, cli.logger), keyvals...)
+}
+
+// confirm 交易请求结果不明确时, 通过 TradeConfirmer 确认是否已经落地
+// 网关可能还在处理这个请求, 刚开始查不到不代表不会落地: 在确认窗口内每隔一段时间确认一次, 任意一次确认落地就返回true;
+// 整个窗口内都确认没有落地才返回false(可以重新提交), 窗口结束时最后一次确认失败则返回错误(结果不明确, 不能重新提交)
+func (cli *RestClient) confirm(ctx context.Context, call *Call, policy *RetryPolicy) (bool, error) {
+	if cli.confirmer == nil {
+		return false, errors.New("no trade confirmer configured")
+	}
+	window, interval := policy.ConfirmSchedule()
+	deadline := time.Now().Add(window)
+	for {
+		if err := SleepContext(ctx, interval); err != nil {
+			return false, err
+		}
+		landed, err := cli.confirmer(ctx, call.Path, call.Request)
+		switch {
+		case err == nil && landed:
+			return true, nil
+		case errors.Is(err, ErrUnconfirmable):
+			return false, err
+		case !time.Now().Before(deadline):
+			return false, err
+		}
+		if err != nil {
+			cli.callLogger(ctx, call).Warnf("MT5#%s->confirm failed, will check again: %v", call.Endpoint, err)
+		}
+	}
+}
+
+// send UseAttempt 中间件链的最后一环, 发起一次http请求
+func (cli *RestClient) send(ctx context.Context, call *Call) error {
+	err := cli.executeWithTimeout(ctx, call.Address, call)
+	call.StatusCode = HTTPStatusOf(err)
+	return err
+}
+
+// executeWithTimeout 每次尝试单独使用 Params.Timeout 作为超时, 同时受调用方ctx的控制
+func (cli *RestClient) executeWithTimeout(ctx context.Context, addr string, call *Call) error {
+	if cli.Params.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cli.Params.Timeout)
+		defer cancel()
+	}
+	return cli.execute(ctx, addr, call)
+}
+
+// execute 向addr网关发送一次http请求
+func (cli *RestClient) execute(ctx context.Context, addr string, call *Call) error {
+	rawURL := addr + call.Path
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	//body和query自己序列化, 保证签名的内容和实际发送的完全一致
+	var body []byte
+	if call.Request != nil {
+		if body, err = json.Marshal(call.Request); err != nil {
+			return fmt.Errorf("marshal request body: %w", err)
+		}
+	}
+	query := url.Values{}
+	for k, v := range call.Query {
+		query.Set(k, v)
+	}
+	rawQuery := query.Encode()
+
+	r := cli.ryClient.R().
+		SetContext(ctx).
+		SetHeaders(cli.headers(call.Header, call.Method, u.Path, rawQuery, body)).
+		SetDebug(cli.debugMode.Load()).
+		SetResult(call.Response).
+		SetError(call.Response)
+
+	if rawQuery != "" {
+		r.SetQueryString(rawQuery)
+	}
+	if body != nil {
+		r.SetBody(body)
+	}
+
+	resp, err := r.Execute(call.Method, rawURL)
+	if err == nil {
+		err = checkResponse(call, resp)
+	}
+
+	//print log, 按结果选择日志级别, 敏感字段脱敏
+	if level := cli.Params.Log.Level(call.Endpoint, call.Path, call.Method, err); level != LogLevelOff && resp != nil && resp.Request != nil {
+		restLog, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(cli.Params.Log.RestyLog(resp))
+		if err != nil {
+			LogAt(cli.callLogger(ctx, call), level, "MT5#%s->%+v, err: %v", call.Endpoint, string(restLog), err)
+		} else {
+			LogAt(cli.callLogger(ctx, call), level, "MT5#%s->%+v", call.Endpoint, string(restLog))
+		}
+	}
+
+	return err
+}
+
+// headers extra是中间件注入的header, 签名相关的header最后计算, 不会被覆盖
+func (cli *RestClient) headers(extra map[string]string, method, path, rawQuery string, body []byte) map[string]string {
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	for k, v := range extra {
+		headers[k] = v
+	}
+
+	//开启了签名的话, 带上api key/时间戳/nonce/签名
+	if cli.Params.Sign != nil {
+		for k, v := range cli.Params.Sign.Headers(method, path, rawQuery, body) {
+			headers[k] = v
+		}
+	}
+	return headers
+}
+
+// checkResponse http状态码/反序列化/业务结果的检查
+func checkResponse(call *Call, resp *resty.Response) error {
+	if resp.StatusCode() != 200 {
+		return newAPIError(call, resp)
+	}
+
+	if resp.Error() != nil {
+		//反序列化错误会在此捕捉
+		return fmt.Errorf("%v, body:%s", resp.Error(), resp.Body())
+	}
+
+	//http成功, 但业务失败
+	if cr, ok := call.Response.(CommonResult); ok {
+		if success, _, _ := cr.CommonResult(); !success {
+			return newAPIError(call, resp)
+		}
+	}
+
+	return nil
+}
+
+// newAPIError 把失败的返回包装成 APIError
+func newAPIError(call *Call, resp *resty.Response) *APIError {
+	apiErr := &APIError{
+		Endpoint:   call.Path,
+		HTTPStatus: resp.StatusCode(),
+		Body:       resp.Body(),
+	}
+	if cr, ok := call.Response.(CommonResult); ok {
+		_, apiErr.Code, apiErr.Message = cr.CommonResult()
+	}
+	return apiErr
+}
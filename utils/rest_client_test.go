@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFlakyServer 前failures次请求超时(或返回502), 之后正常返回
+func newFlakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= failures {
+			if status == 0 {
+				//等到客户端超时断开
+				select {
+				case <-r.Context().Done():
+				case <-done:
+				}
+				return
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":0,"success":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(done) })
+	return srv, &hits
+}
+
+// stubConfirmer 依次返回results里的结果, 用完后一直返回最后一个
+func stubConfirmer(calls *atomic.Int32, results ...func() (bool, error)) TradeConfirmer {
+	return func(ctx context.Context, endpoint string, req interface{}) (bool, error) {
+		n := int(calls.Add(1))
+		if n > len(results) {
+			n = len(results)
+		}
+		return results[n-1]()
+	}
+}
+
+var (
+	notLanded  = func() (bool, error) { return false, nil }
+	landed     = func() (bool, error) { return true, nil }
+	lookupFail = func() (bool, error) { return false, errors.New("list positions: eof") }
+)
+
+func TestRestClientConfirmBeforeResubmit(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int //0表示超时
+		confirmer []func() (bool, error)
+
+		wantHits   int32
+		wantLanded bool
+		wantAmbig  bool
+		wantErr    bool
+		minConfirm int32
+		maxConfirm int32
+	}{
+		{name: "landed while polling", confirmer: []func() (bool, error){notLanded, notLanded, landed},
+			wantHits: 1, wantAmbig: true, wantLanded: true, wantErr: true, minConfirm: 3, maxConfirm: 3},
+		{name: "not landed in window resubmits", confirmer: []func() (bool, error){notLanded},
+			wantHits: 2, minConfirm: 2, maxConfirm: 10},
+		{name: "5xx not landed resubmits", status: http.StatusBadGateway, confirmer: []func() (bool, error){notLanded},
+			wantHits: 2, minConfirm: 2, maxConfirm: 10},
+		{name: "lookup keeps failing", confirmer: []func() (bool, error){lookupFail},
+			wantHits: 1, wantAmbig: true, wantErr: true, minConfirm: 2, maxConfirm: 10},
+		{name: "lookup recovers", confirmer: []func() (bool, error){lookupFail, landed},
+			wantHits: 1, wantAmbig: true, wantLanded: true, wantErr: true, minConfirm: 2, maxConfirm: 2},
+		{name: "failure at window end wins", confirmer: []func() (bool, error){notLanded, notLanded, lookupFail},
+			wantHits: 1, wantAmbig: true, wantErr: true, minConfirm: 3, maxConfirm: 10},
+		{name: "unconfirmable stops polling", confirmer: []func() (bool, error){func() (bool, error) {
+			return false, fmt.Errorf("partial close: %w", ErrUnconfirmable)
+		}}, wantHits: 1, wantAmbig: true, wantErr: true, minConfirm: 1, maxConfirm: 1},
+		{name: "no confirmer", wantHits: 1, wantAmbig: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, hits := newFlakyServer(t, 1, tt.status)
+			cli := NewRestClient(nil, &ClientParams{
+				Address: srv.URL,
+				Timeout: 50 * time.Millisecond,
+				Retry:   &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, ConfirmWindow: 60 * time.Millisecond, ConfirmInterval: 10 * time.Millisecond},
+			})
+			var confirms atomic.Int32
+			if tt.confirmer != nil {
+				cli.SetTradeConfirmer(stubConfirmer(&confirms, tt.confirmer...))
+			}
+
+			err := cli.Do(context.Background(), &Call{Endpoint: "OpenPosition", Method: http.MethodPost, Path: "/v1/position/open", Request: map[string]string{"comment": "t1"}, Response: &CommonResp{}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Fatalf("server saw %d requests, want %d", got, tt.wantHits)
+			}
+			var ambig *AmbiguousError
+			if errors.As(err, &ambig) != tt.wantAmbig {
+				t.Fatalf("err = %v, want AmbiguousError %v", err, tt.wantAmbig)
+			}
+			if tt.wantAmbig && (ambig.Landed != tt.wantLanded || errors.Is(err, ErrTradeLanded) != tt.wantLanded || errors.Is(err, ErrAmbiguousResult) == tt.wantLanded) {
+				t.Fatalf("err = %v, landed = %v, want %v", err, ambig.Landed, tt.wantLanded)
+			}
+			if n := confirms.Load(); n < tt.minConfirm || n > tt.maxConfirm {
+				t.Fatalf("confirmer called %d times, want %d..%d", n, tt.minConfirm, tt.maxConfirm)
+			}
+		})
+	}
+}
+
+// 查询接口是幂等的, 超时直接重试, 不需要确认
+func TestRestClientRetriesQueries(t *testing.T) {
+	srv, hits := newFlakyServer(t, 1, 0)
+	cli := NewRestClient(nil, &ClientParams{Address: srv.URL, Timeout: 50 * time.Millisecond, Retry: &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}})
+	var confirms atomic.Int32
+	cli.SetTradeConfirmer(stubConfirmer(&confirms, landed))
+
+	call := &Call{Endpoint: "ListSymbol", Method: http.MethodGet, Path: "/v1/symbol/list", Response: &CommonResp{}}
+	if err := cli.Do(context.Background(), call); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if hits.Load() != 2 || call.Attempts != 2 || confirms.Load() != 0 {
+		t.Fatalf("hits = %d, attempts = %d, confirms = %d", hits.Load(), call.Attempts, confirms.Load())
+	}
+}
+
+// 调用方ctx结束时停止确认, 结果不明确
+func TestRestClientConfirmCanceled(t *testing.T) {
+	srv, hits := newFlakyServer(t, 1, http.StatusBadGateway)
+	cli := NewRestClient(nil, &ClientParams{Address: srv.URL, Retry: &RetryPolicy{MaxAttempts: 3, ConfirmWindow: time.Hour, ConfirmInterval: 10 * time.Millisecond}})
+	var confirms atomic.Int32
+	cli.SetTradeConfirmer(stubConfirmer(&confirms, notLanded))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := cli.Do(ctx, &Call{Endpoint: "OpenPosition", Method: http.MethodPost, Path: "/v1/position/open", Request: map[string]string{}, Response: &CommonResp{}})
+	if !errors.Is(err, ErrAmbiguousResult) || hits.Load() != 1 {
+		t.Fatalf("err = %v after %d requests", err, hits.Load())
+	}
+}
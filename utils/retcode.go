@@ -0,0 +1,175 @@
+package utils
+
+import "fmt"
+
+// Retcode mt5的返回码, 本身实现了error接口, 可以直接作为 errors.Is 的target
+// https://support.metaquotes.net/en/docs/mt5/api/reference_retcodes
+type Retcode int
+
+// 通用错误
+const (
+	RetcodeOK            Retcode = 0  //MT_RET_OK
+	RetcodeOKNone        Retcode = 1  //MT_RET_OK_NONE
+	RetcodeError         Retcode = 2  //MT_RET_ERROR
+	RetcodeInvalidParams Retcode = 3  //MT_RET_ERR_PARAMS
+	RetcodeInvalidData   Retcode = 4  //MT_RET_ERR_DATA
+	RetcodeDisk          Retcode = 5  //MT_RET_ERR_DISK
+	RetcodeMemory        Retcode = 6  //MT_RET_ERR_MEM
+	RetcodeNetwork       Retcode = 7  //MT_RET_ERR_NETWORK
+	RetcodePermissions   Retcode = 8  //MT_RET_ERR_PERMISSIONS
+	RetcodeTimeout       Retcode = 9  //MT_RET_ERR_TIMEOUT
+	RetcodeConnection    Retcode = 10 //MT_RET_ERR_CONNECTION
+	RetcodeNoService     Retcode = 11 //MT_RET_ERR_NOSERVICE
+	RetcodeTooFrequent   Retcode = 12 //MT_RET_ERR_FREQUENT
+	RetcodeNotFound      Retcode = 13 //MT_RET_ERR_NOTFOUND
+	RetcodePartial       Retcode = 14 //MT_RET_ERR_PARTIAL
+	RetcodeShutdown      Retcode = 15 //MT_RET_ERR_SHUTDOWN
+	RetcodeCancel        Retcode = 16 //MT_RET_ERR_CANCEL
+	RetcodeDuplicate     Retcode = 17 //MT_RET_ERR_DUPLICATE
+)
+
+// 交易请求相关
+const (
+	RetcodeRequote            Retcode = 10004 //MT_RET_REQUEST_REQUOTE 重新报价
+	RetcodeReject             Retcode = 10006 //MT_RET_REQUEST_REJECT 请求被拒绝
+	RetcodeCanceled           Retcode = 10007 //MT_RET_REQUEST_CANCEL 请求被取消
+	RetcodePlaced             Retcode = 10008 //MT_RET_REQUEST_PLACED 挂单成功
+	RetcodeDone               Retcode = 10009 //MT_RET_REQUEST_DONE 执行成功
+	RetcodeDonePartial        Retcode = 10010 //MT_RET_REQUEST_DONE_PARTIAL 部分成交
+	RetcodeRequestError       Retcode = 10011 //MT_RET_REQUEST_ERROR
+	RetcodeRequestTimeout     Retcode = 10012 //MT_RET_REQUEST_TIMEOUT
+	RetcodeInvalidRequest     Retcode = 10013 //MT_RET_REQUEST_INVALID
+	RetcodeInvalidVolume      Retcode = 10014 //MT_RET_REQUEST_INVALID_VOLUME 手数不合法
+	RetcodeInvalidPrice       Retcode = 10015 //MT_RET_REQUEST_INVALID_PRICE 价格不合法
+	RetcodeInvalidStops       Retcode = 10016 //MT_RET_REQUEST_INVALID_STOPS sl/tp不合法
+	RetcodeTradeDisabled      Retcode = 10017 //MT_RET_REQUEST_TRADE_DISABLED 禁止交易
+	RetcodeMarketClosed       Retcode = 10018 //MT_RET_REQUEST_MARKET_CLOSED 休市
+	RetcodeNoMoney            Retcode = 10019 //MT_RET_REQUEST_NO_MONEY 保证金不足
+	RetcodePriceChanged       Retcode = 10020 //MT_RET_REQUEST_PRICE_CHANGED
+	RetcodePriceOff           Retcode = 10021 //MT_RET_REQUEST_PRICE_OFF 没有报价
+	RetcodeInvalidExpiration  Retcode = 10022 //MT_RET_REQUEST_INVALID_EXP 过期时间不合法
+	RetcodeOrderChanged       Retcode = 10023 //MT_RET_REQUEST_ORDER_CHANGED
+	RetcodeTooManyRequests    Retcode = 10024 //MT_RET_REQUEST_TOO_MANY
+	RetcodeNoChanges          Retcode = 10025 //MT_RET_REQUEST_NO_CHANGES
+	RetcodeAutoTradingServer  Retcode = 10026 //MT_RET_REQUEST_AT_DISABLED_SERVER
+	RetcodeAutoTradingClient  Retcode = 10027 //MT_RET_REQUEST_AT_DISABLED_CLIENT
+	RetcodeLocked             Retcode = 10028 //MT_RET_REQUEST_LOCKED
+	RetcodeFrozen             Retcode = 10029 //MT_RET_REQUEST_FROZEN
+	RetcodeInvalidFill        Retcode = 10030 //MT_RET_REQUEST_INVALID_FILL
+	RetcodeNoConnection       Retcode = 10031 //MT_RET_REQUEST_CONNECTION
+	RetcodeOnlyReal           Retcode = 10032 //MT_RET_REQUEST_ONLY_REAL
+	RetcodeLimitOrders        Retcode = 10033 //MT_RET_REQUEST_LIMIT_ORDERS 挂单数量达到上限
+	RetcodeLimitVolume        Retcode = 10034 //MT_RET_REQUEST_LIMIT_VOLUME 持仓量达到上限
+	RetcodeInvalidOrder       Retcode = 10035 //MT_RET_REQUEST_INVALID_ORDER
+	RetcodePositionClosed     Retcode = 10036 //MT_RET_REQUEST_POSITION_CLOSED 持仓已经被平掉
+	RetcodeInvalidCloseVolume Retcode = 10038 //MT_RET_REQUEST_INVALID_CLOSE_VOLUME
+	RetcodeCloseOrderExist    Retcode = 10039 //MT_RET_REQUEST_CLOSE_ORDER_EXIST
+	RetcodeLimitPositions     Retcode = 10040 //MT_RET_REQUEST_LIMIT_POSITIONS
+	RetcodeRejectCancel       Retcode = 10041 //MT_RET_REQUEST_REJECT_CANCEL
+	RetcodeLongOnly           Retcode = 10042 //MT_RET_REQUEST_LONG_ONLY
+	RetcodeShortOnly          Retcode = 10043 //MT_RET_REQUEST_SHORT_ONLY
+	RetcodeCloseOnly          Retcode = 10044 //MT_RET_REQUEST_CLOSE_ONLY
+	RetcodeProhibitedByFIFO   Retcode = 10045 //MT_RET_REQUEST_PROHIBITED_BY_FIFO
+	RetcodeHedgeProhibited    Retcode = 10046 //MT_RET_REQUEST_HEDGE_PROHIBITED
+)
+
+var retcodeNames = map[Retcode]string{
+	RetcodeOK:            "MT_RET_OK",
+	RetcodeOKNone:        "MT_RET_OK_NONE",
+	RetcodeError:         "MT_RET_ERROR",
+	RetcodeInvalidParams: "MT_RET_ERR_PARAMS",
+	RetcodeInvalidData:   "MT_RET_ERR_DATA",
+	RetcodeDisk:          "MT_RET_ERR_DISK",
+	RetcodeMemory:        "MT_RET_ERR_MEM",
+	RetcodeNetwork:       "MT_RET_ERR_NETWORK",
+	RetcodePermissions:   "MT_RET_ERR_PERMISSIONS",
+	RetcodeTimeout:       "MT_RET_ERR_TIMEOUT",
+	RetcodeConnection:    "MT_RET_ERR_CONNECTION",
+	RetcodeNoService:     "MT_RET_ERR_NOSERVICE",
+	RetcodeTooFrequent:   "MT_RET_ERR_FREQUENT",
+	RetcodeNotFound:      "MT_RET_ERR_NOTFOUND",
+	RetcodePartial:       "MT_RET_ERR_PARTIAL",
+	RetcodeShutdown:      "MT_RET_ERR_SHUTDOWN",
+	RetcodeCancel:        "MT_RET_ERR_CANCEL",
+	RetcodeDuplicate:     "MT_RET_ERR_DUPLICATE",
+
+	RetcodeRequote:            "MT_RET_REQUEST_REQUOTE",
+	RetcodeReject:             "MT_RET_REQUEST_REJECT",
+	RetcodeCanceled:           "MT_RET_REQUEST_CANCEL",
+	RetcodePlaced:             "MT_RET_REQUEST_PLACED",
+	RetcodeDone:               "MT_RET_REQUEST_DONE",
+	RetcodeDonePartial:        "MT_RET_REQUEST_DONE_PARTIAL",
+	RetcodeRequestError:       "MT_RET_REQUEST_ERROR",
+	RetcodeRequestTimeout:     "MT_RET_REQUEST_TIMEOUT",
+	RetcodeInvalidRequest:     "MT_RET_REQUEST_INVALID",
+	RetcodeInvalidVolume:      "MT_RET_REQUEST_INVALID_VOLUME",
+	RetcodeInvalidPrice:       "MT_RET_REQUEST_INVALID_PRICE",
+	RetcodeInvalidStops:       "MT_RET_REQUEST_INVALID_STOPS",
+	RetcodeTradeDisabled:      "MT_RET_REQUEST_TRADE_DISABLED",
+	RetcodeMarketClosed:       "MT_RET_REQUEST_MARKET_CLOSED",
+	RetcodeNoMoney:            "MT_RET_REQUEST_NO_MONEY",
+	RetcodePriceChanged:       "MT_RET_REQUEST_PRICE_CHANGED",
+	RetcodePriceOff:           "MT_RET_REQUEST_PRICE_OFF",
+	RetcodeInvalidExpiration:  "MT_RET_REQUEST_INVALID_EXP",
+	RetcodeOrderChanged:       "MT_RET_REQUEST_ORDER_CHANGED",
+	RetcodeTooManyRequests:    "MT_RET_REQUEST_TOO_MANY",
+	RetcodeNoChanges:          "MT_RET_REQUEST_NO_CHANGES",
+	RetcodeAutoTradingServer:  "MT_RET_REQUEST_AT_DISABLED_SERVER",
+	RetcodeAutoTradingClient:  "MT_RET_REQUEST_AT_DISABLED_CLIENT",
+	RetcodeLocked:             "MT_RET_REQUEST_LOCKED",
+	RetcodeFrozen:             "MT_RET_REQUEST_FROZEN",
+	RetcodeInvalidFill:        "MT_RET_REQUEST_INVALID_FILL",
+	RetcodeNoConnection:       "MT_RET_REQUEST_CONNECTION",
+	RetcodeOnlyReal:           "MT_RET_REQUEST_ONLY_REAL",
+	RetcodeLimitOrders:        "MT_RET_REQUEST_LIMIT_ORDERS",
+	RetcodeLimitVolume:        "MT_RET_REQUEST_LIMIT_VOLUME",
+	RetcodeInvalidOrder:       "MT_RET_REQUEST_INVALID_ORDER",
+	RetcodePositionClosed:     "MT_RET_REQUEST_POSITION_CLOSED",
+	RetcodeInvalidCloseVolume: "MT_RET_REQUEST_INVALID_CLOSE_VOLUME",
+	RetcodeCloseOrderExist:    "MT_RET_REQUEST_CLOSE_ORDER_EXIST",
+	RetcodeLimitPositions:     "MT_RET_REQUEST_LIMIT_POSITIONS",
+	RetcodeRejectCancel:       "MT_RET_REQUEST_REJECT_CANCEL",
+	RetcodeLongOnly:           "MT_RET_REQUEST_LONG_ONLY",
+	RetcodeShortOnly:          "MT_RET_REQUEST_SHORT_ONLY",
+	RetcodeCloseOnly:          "MT_RET_REQUEST_CLOSE_ONLY",
+	RetcodeProhibitedByFIFO:   "MT_RET_REQUEST_PROHIBITED_BY_FIFO",
+	RetcodeHedgeProhibited:    "MT_RET_REQUEST_HEDGE_PROHIBITED",
+}
+
+// String 返回retcode对应的MT_RET_xxx名字
+func (c Retcode) String() string {
+	if name, ok := retcodeNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("MT_RET_%d", int(c))
+}
+
+func (c Retcode) Error() string {
+	return c.String()
+}
+
+// IsKnown 是否是SDK认识的retcode(有对应的 RetcodeXxx 常量)
+func (c Retcode) IsKnown() bool {
+	_, ok := retcodeNames[c]
+	return ok
+}
+
+// IsSuccess 是否是成功类的返回码(包括挂单成功/部分成交)
+func (c Retcode) IsSuccess() bool {
+	switch c {
+	case RetcodeOK, RetcodeOKNone, RetcodePlaced, RetcodeDone, RetcodeDonePartial:
+		return true
+	}
+	return false
+}
+
+// IsTemporary 是否是临时性的错误(价格变动/超时/限频等), 稍后重新提交可能成功
+func (c Retcode) IsTemporary() bool {
+	switch c {
+	case RetcodeTimeout, RetcodeNetwork, RetcodeConnection, RetcodeTooFrequent,
+		RetcodeRequote, RetcodeRequestTimeout, RetcodePriceChanged, RetcodePriceOff,
+		RetcodeTooManyRequests, RetcodeLocked, RetcodeNoConnection:
+		return true
+	}
+	return false
+}
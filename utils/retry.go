@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RetryPolicy 重试策略
+// 查询类接口遇到网络错误/5xx会按指数退避自动重试;
+// 交易类接口只有在确定请求没有被执行时才会重试, 结果不明确时需要先通过 TradeConfirmer 确认
+type RetryPolicy struct {
+	MaxAttempts    int           `json:"max_attempts" mapstructure:"max_attempts" config:"max_attempts" yaml:"max_attempts"`             //总尝试次数(包含第一次), <=1则不重试
+	InitialBackoff time.Duration `json:"initial_backoff" mapstructure:"initial_backoff" config:"initial_backoff" yaml:"initial_backoff"` //第一次重试前的等待时间, 默认100ms
+	MaxBackoff     time.Duration `json:"max_backoff" mapstructure:"max_backoff" config:"max_backoff" yaml:"max_backoff"`                 //最大等待时间, 默认2s
+	Multiplier     float64       `json:"multiplier" mapstructure:"multiplier" config:"multiplier" yaml:"multiplier"`                     //每次等待时间的倍数, 默认2
+	Jitter         float64       `json:"jitter" mapstructure:"jitter" config:"jitter" yaml:"jitter"`                                     //随机抖动比例(0~1), 默认0.2
+
+	ConfirmWindow   time.Duration `json:"confirm_window" mapstructure:"confirm_window" config:"confirm_window" yaml:"confirm_window"`         //交易请求结果不明确时, 在这段时间内反复确认是否落地, 默认3s
+	ConfirmInterval time.Duration `json:"confirm_interval" mapstructure:"confirm_interval" config:"confirm_interval" yaml:"confirm_interval"` //两次确认之间的间隔, 第一次确认前也会等待, 默认500ms
+}
+
+// DefaultRetryPolicy 默认重试策略: 最多3次, 100ms起步, 最长2s
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+
+		ConfirmWindow:   3 * time.Second,
+		ConfirmInterval: 500 * time.Millisecond,
+	}
+}
+
+// Backoff 第attempt次(从1开始)失败后, 下一次重试前需要等待的时间
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	initial := durationOr(p.InitialBackoff, 100*time.Millisecond)
+	maxBackoff := durationOr(p.MaxBackoff, 2*time.Second)
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	d := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if d > float64(maxBackoff) {
+		d = float64(maxBackoff)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(d)
+}
+
+// ConfirmSchedule 确认窗口和确认间隔, 零值使用默认值
+func (p *RetryPolicy) ConfirmSchedule() (window, interval time.Duration) {
+	return durationOr(p.ConfirmWindow, 3*time.Second), durationOr(p.ConfirmInterval, 500*time.Millisecond)
+}
+
+// SleepContext 等待d, ctx结束时提前返回ctx的错误
+func SleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+//------------------------------------------------------------------------
+
+// ErrorClass 请求失败的分类, 决定能否重试
+type ErrorClass int
+
+const (
+	ErrorClassNone        ErrorClass = iota //成功
+	ErrorClassPermanent                     //业务错误/4xx/证书错误等, 重试也没用
+	ErrorClassNotExecuted                   //请求确定没有被执行(连接失败/429), 任何请求都可以安全重试
+	ErrorClassAmbiguous                     //请求可能已经被网关执行(超时/5xx/连接中断), 交易请求不能盲目重试
+)
+
+// ClassifyError 对请求的错误进行分类
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassNone
+	}
+
+	if apiErr, ok := AsAPIError(err); ok {
+		switch {
+		case apiErr.HTTPStatus == http.StatusTooManyRequests:
+			return ErrorClassNotExecuted
+		case apiErr.HTTPStatus >= 500:
+			return ErrorClassAmbiguous
+		}
+		return ErrorClassPermanent
+	}
+
+	//连接都没有建立起来, 请求肯定没有发出去
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return ErrorClassNotExecuted
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorClassNotExecuted
+	}
+
+	//证书问题, 重试也没用
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuthErr) || errors.As(err, &hostnameErr) || errors.Is(err, ErrPinMismatch) {
+		return ErrorClassPermanent
+	}
+
+	//其他传输层的错误(超时/连接被重置/EOF等), 请求可能已经发出去了
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return ErrorClassAmbiguous
+	}
+
+	return ErrorClassPermanent
+}
+
+//------------------------------------------------------------------------
+
+// TradeConfirmer 交易请求结果不明确时(超时/5xx/连接中断), 用来确认请求是否已经在网关落地
+// endpoint 是接口路径(比如 /v1/position/open), req 是原始的请求结构体.
+// 网关可能还在处理这个请求, 所以会在 RetryPolicy.ConfirmWindow 内被反复调用, 只有整个窗口内都返回(false, nil)才会重新提交
+type TradeConfirmer func(ctx context.Context, endpoint string, req interface{}) (landed bool, err error)
+
+var (
+	// ErrAmbiguousResult 交易请求结果不明确, 可能已经被执行, 调用方需要自行核对, 不要直接重新提交
+	ErrAmbiguousResult = errors.New("mt5: request result is unknown, it may have been executed")
+	// ErrTradeLanded 交易请求的返回丢失了, 但已经确认请求在网关落地
+	ErrTradeLanded = errors.New("mt5: request response was lost but it has been executed")
+	// ErrUnconfirmable TradeConfirmer 无法确认这类请求(比如部分平仓/没有唯一comment), 返回包装了它的错误时不再反复确认
+	ErrUnconfirmable = errors.New("mt5: request cannot be confirmed")
+)
+
+// AmbiguousError 交易请求结果不明确时返回的错误
+// errors.Is(err, ErrAmbiguousResult) 表示无法确认, errors.Is(err, ErrTradeLanded) 表示已经确认落地
+type AmbiguousError struct {
+	Endpoint string //接口路径
+	Landed   bool   //是否已经确认落地
+	Err      error  //原始错误
+}
+
+func (e *AmbiguousError) Error() string {
+	if e.Landed {
+		return fmt.Sprintf("mt5 %s: %v: %v", e.Endpoint, ErrTradeLanded, e.Err)
+	}
+	return fmt.Sprintf("mt5 %s: %v: %v", e.Endpoint, ErrAmbiguousResult, e.Err)
+}
+
+func (e *AmbiguousError) Unwrap() []error {
+	if e.Landed {
+		return []error{ErrTradeLanded, e.Err}
+	}
+	return []error{ErrAmbiguousResult, e.Err}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"nil", nil, ErrorClassNone},
+		{"business failure", &APIError{Code: int(RetcodeNoMoney), HTTPStatus: http.StatusOK}, ErrorClassPermanent},
+		{"bad request", &APIError{HTTPStatus: http.StatusBadRequest}, ErrorClassPermanent},
+		{"too many requests", &APIError{HTTPStatus: http.StatusTooManyRequests}, ErrorClassNotExecuted},
+		{"bad gateway", &APIError{HTTPStatus: http.StatusBadGateway}, ErrorClassAmbiguous},
+		{"wrapped 5xx", fmt.Errorf("open: %w", &APIError{HTTPStatus: http.StatusInternalServerError}), ErrorClassAmbiguous},
+		{"dial refused", &url.Error{Op: "Post", URL: "http://gw", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}, ErrorClassNotExecuted},
+		{"dns", &url.Error{Op: "Post", URL: "http://gw", Err: &net.DNSError{Err: "no such host", Name: "gw"}}, ErrorClassNotExecuted},
+		{"connection reset", &url.Error{Op: "Post", URL: "http://gw", Err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}}, ErrorClassAmbiguous},
+		{"timeout", &url.Error{Op: "Post", URL: "http://gw", Err: context.DeadlineExceeded}, ErrorClassAmbiguous},
+		{"deadline", context.DeadlineExceeded, ErrorClassAmbiguous},
+		{"canceled", context.Canceled, ErrorClassAmbiguous},
+		{"unknown authority", &url.Error{Op: "Get", URL: "https://gw", Err: x509.UnknownAuthorityError{}}, ErrorClassPermanent},
+		{"hostname", &url.Error{Op: "Get", URL: "https://gw", Err: x509.HostnameError{Host: "gw"}}, ErrorClassPermanent},
+		{"pin mismatch", &url.Error{Op: "Get", URL: "https://gw", Err: ErrPinMismatch}, ErrorClassPermanent},
+		{"circuit open", ErrCircuitOpen, ErrorClassPermanent},
+		{"other", errors.New("unmarshal failed"), ErrorClassPermanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Fatalf("ClassifyError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 2}
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond} {
+		if got := p.Backoff(attempt + 1); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt+1, got, want)
+		}
+	}
+
+	window, interval := (&RetryPolicy{}).ConfirmSchedule()
+	if window != 3*time.Second || interval != 500*time.Millisecond {
+		t.Fatalf("ConfirmSchedule() = %v, %v", window, interval)
+	}
+}
@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 签名相关的header
+const (
+	HeaderAPIKey    = "X-Api-Key"
+	HeaderTimestamp = "X-Timestamp" //unix时间戳(ms毫秒)
+	HeaderNonce     = "X-Nonce"     //随机串, 防重放
+	HeaderSignature = "X-Signature" //hex(HMAC-SHA256(secret, StringToSign))
+)
+
+// SignConfig 请求签名配置
+type SignConfig struct {
+	APIKey string `json:"api_key" mapstructure:"api_key" config:"api_key" yaml:"api_key"`
+	Secret string `json:"secret" mapstructure:"secret" config:"secret" yaml:"secret"`
+}
+
+// StringToSign 生成待签名的字符串, 每部分用换行分隔:
+//
+//	METHOD
+//	PATH
+//	QUERY(按key排序后url编码)
+//	TIMESTAMP
+//	NONCE
+//	hex(SHA256(BODY))
+func StringToSign(method, path, rawQuery, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		canonicalQuery(rawQuery),
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+}
+
+// Sign 计算签名 hex(HMAC-SHA256(secret, StringToSign))
+func Sign(secret, method, path, rawQuery, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(StringToSign(method, path, rawQuery, timestamp, nonce, body)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Headers 生成一次请求需要携带的签名header
+func (c *SignConfig) Headers(method, path, rawQuery string, body []byte) map[string]string {
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	nonce := newNonce()
+	return map[string]string{
+		HeaderAPIKey:    c.APIKey,
+		HeaderTimestamp: timestamp,
+		HeaderNonce:     nonce,
+		HeaderSignature: Sign(c.Secret, method, path, rawQuery, timestamp, nonce, body),
+	}
+}
+
+func newNonce() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// canonicalQuery query按key排序, 保证客户端和服务端计算一致
+func canonicalQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	return values.Encode()
+}
+
+//------------------------------------------------------------------------
+
+var (
+	ErrSignMissing   = errors.New("sign: missing signature headers")
+	ErrSignAPIKey    = errors.New("sign: unknown api key")
+	ErrSignExpired   = errors.New("sign: timestamp out of range")
+	ErrSignReplay    = errors.New("sign: nonce already used")
+	ErrSignMismatch  = errors.New("sign: signature mismatch")
+	errSignTimestamp = errors.New("sign: invalid timestamp")
+)
+
+// SignVerifier 服务端校验签名(用于网关的替身/测试桩)
+type SignVerifier struct {
+	Secrets func(apiKey string) (secret string, ok bool) //根据api key查找secret
+	MaxSkew time.Duration                                //允许的时间偏差, 默认5分钟
+
+	mu     sync.Mutex
+	nonces map[string]struct{} //已经使用过的nonce
+	queue  []usedNonce         //按使用时间排序, 过期的从头部清理
+}
+
+type usedNonce struct {
+	key string
+	at  time.Time
+}
+
+// NewSignVerifier 使用固定的 api key -> secret 映射创建校验器
+func NewSignVerifier(secrets map[string]string) *SignVerifier {
+	return &SignVerifier{
+		Secrets: func(apiKey string) (string, bool) {
+			secret, ok := secrets[apiKey]
+			return secret, ok
+		},
+	}
+}
+
+// Verify 校验请求的签名, 会读取body后再放回去, 不影响后续处理
+func (v *SignVerifier) Verify(r *http.Request) error {
+	apiKey := r.Header.Get(HeaderAPIKey)
+	timestamp := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	signature := r.Header.Get(HeaderSignature)
+	if apiKey == "" || timestamp == "" || nonce == "" || signature == "" {
+		return ErrSignMissing
+	}
+
+	secret, ok := v.Secrets(apiKey)
+	if !ok {
+		return ErrSignAPIKey
+	}
+
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errSignTimestamp
+	}
+	maxSkew := durationOr(v.MaxSkew, 5*time.Minute)
+	if skew := time.Since(time.UnixMilli(ms)); skew > maxSkew || skew < -maxSkew {
+		return ErrSignExpired
+	}
+
+	var body []byte
+	if r.Body != nil {
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return fmt.Errorf("sign: read body: %w", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	expected := Sign(secret, r.Method, r.URL.Path, r.URL.RawQuery, timestamp, nonce, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrSignMismatch
+	}
+
+	return v.useNonce(apiKey+":"+nonce, maxSkew)
+}
+
+// useNonce 时间戳允许前后偏差maxSkew, nonce要保留2*maxSkew才能挡住所有重放
+func (v *SignVerifier) useNonce(key string, maxSkew time.Duration) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	//持有锁时取时间, 队列天然有序, 每次只清理头部过期的部分
+	now := time.Now()
+	expired := 0
+	for expired < len(v.queue) && now.Sub(v.queue[expired].at) > 2*maxSkew {
+		delete(v.nonces, v.queue[expired].key)
+		v.queue[expired] = usedNonce{}
+		expired++
+	}
+	v.queue = v.queue[expired:]
+
+	if v.nonces == nil {
+		v.nonces = make(map[string]struct{})
+	}
+	if _, used := v.nonces[key]; used {
+		return ErrSignReplay
+	}
+	v.nonces[key] = struct{}{}
+	v.queue = append(v.queue, usedNonce{key: key, at: now})
+	return nil
+}
+
+// Middleware 校验失败时返回401
+func (v *SignVerifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := v.Verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testSign = &SignConfig{APIKey: "key-1", Secret: "s3cr3t"}
+
+// signedRequest 按客户端的方式签名, 构造服务端收到的请求
+func signedRequest(cfg *SignConfig, method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range cfg.Headers(method, r.URL.Path, r.URL.RawQuery, []byte(body)) {
+		r.Header.Set(k, v)
+	}
+	return r
+}
+
+// clone 原样重放一次请求
+func clone(t *testing.T, r *http.Request, body string) *http.Request {
+	t.Helper()
+	c := httptest.NewRequest(r.Method, r.URL.String(), strings.NewReader(body))
+	c.Header = r.Header.Clone()
+	return c
+}
+
+func TestSignedRestClientRequest(t *testing.T) {
+	verifier := NewSignVerifier(map[string]string{testSign.APIKey: testSign.Secret})
+	var (
+		mu       sync.Mutex
+		received []*http.Request
+		bodies   []string
+	)
+	srv := httptest.NewServer(verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, r.Clone(context.Background()))
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":0,"success":true}`))
+	})))
+	defer srv.Close()
+
+	cli := NewRestClient(nil, &ClientParams{Address: srv.URL, Sign: testSign})
+	defer cli.Close()
+	calls := []*Call{
+		{Endpoint: "ListPosition", Method: http.MethodGet, Path: "/v1/position/list", Query: map[string]string{"login": "1001", "symbol": "EURUSD"}, Response: &CommonResp{}},
+		{Endpoint: "OpenPosition", Method: http.MethodPost, Path: "/v1/position/open", Request: map[string]interface{}{"login": 1001, "lots": "0.1"}, Response: &CommonResp{}},
+	}
+	for _, call := range calls {
+		if err := cli.Do(context.Background(), call); err != nil {
+			t.Fatalf("%s: %v", call.Endpoint, err)
+		}
+	}
+	if len(received) != 2 || bodies[1] == "" {
+		t.Fatalf("server received %d requests, bodies %q", len(received), bodies)
+	}
+
+	//原样重放会被拒绝
+	for i, r := range received {
+		if err := verifier.Verify(clone(t, r, bodies[i])); !errors.Is(err, ErrSignReplay) {
+			t.Errorf("replayed %s: err = %v, want ErrSignReplay", r.URL.Path, err)
+		}
+	}
+	replay, _ := http.NewRequest(received[1].Method, srv.URL+received[1].URL.String(), strings.NewReader(bodies[1]))
+	replay.Header = received[1].Header.Clone()
+	resp, err := http.DefaultClient.Do(replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("replay through middleware: status %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestSignVerifierRejects(t *testing.T) {
+	expired := func(skew time.Duration) func() *http.Request {
+		return func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/v1/symbol/list", nil)
+			ts := strconv.FormatInt(time.Now().Add(skew).UnixMilli(), 10)
+			r.Header.Set(HeaderAPIKey, testSign.APIKey)
+			r.Header.Set(HeaderTimestamp, ts)
+			r.Header.Set(HeaderNonce, "n-1")
+			r.Header.Set(HeaderSignature, Sign(testSign.Secret, r.Method, r.URL.Path, "", ts, "n-1", nil))
+			return r
+		}
+	}
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		want    error
+	}{
+		{"valid", func() *http.Request {
+			return signedRequest(testSign, http.MethodPost, "/v1/position/open?login=1", `{"lots":"0.1"}`)
+		}, nil},
+		{"query order does not matter", func() *http.Request {
+			r := signedRequest(testSign, http.MethodGet, "/v1/position/list?a=1&b=2", "")
+			r.URL.RawQuery = "b=2&a=1"
+			return r
+		}, nil},
+		{"tampered body", func() *http.Request {
+			r := signedRequest(testSign, http.MethodPost, "/v1/position/open", `{"lots":"0.1"}`)
+			r.Body = io.NopCloser(strings.NewReader(`{"lots":"10"}`))
+			return r
+		}, ErrSignMismatch},
+		{"tampered query", func() *http.Request {
+			r := signedRequest(testSign, http.MethodGet, "/v1/position/list?login=1", "")
+			r.URL.RawQuery = "login=2"
+			return r
+		}, ErrSignMismatch},
+		{"tampered path", func() *http.Request {
+			r := signedRequest(testSign, http.MethodPost, "/v1/position/open", "")
+			r.URL.Path = "/v1/position/close"
+			return r
+		}, ErrSignMismatch},
+		{"wrong secret", func() *http.Request {
+			return signedRequest(&SignConfig{APIKey: testSign.APIKey, Secret: "other"}, http.MethodGet, "/v1/symbol/list", "")
+		}, ErrSignMismatch},
+		{"unknown api key", func() *http.Request {
+			return signedRequest(&SignConfig{APIKey: "key-2", Secret: testSign.Secret}, http.MethodGet, "/v1/symbol/list", "")
+		}, ErrSignAPIKey},
+		{"timestamp too old", expired(-6 * time.Minute), ErrSignExpired},
+		{"timestamp in the future", expired(6 * time.Minute), ErrSignExpired},
+	}
+	for _, header := range []string{HeaderAPIKey, HeaderTimestamp, HeaderNonce, HeaderSignature} {
+		tests = append(tests, struct {
+			name    string
+			request func() *http.Request
+			want    error
+		}{"missing " + header, func() *http.Request {
+			r := signedRequest(testSign, http.MethodGet, "/v1/symbol/list", "")
+			r.Header.Del(header)
+			return r
+		}, ErrSignMissing})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewSignVerifier(map[string]string{testSign.APIKey: testSign.Secret})
+			err := verifier.Verify(tt.request())
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// 过期的nonce从队列头部清理, 不会一直增长
+func TestSignVerifierPrunesNonces(t *testing.T) {
+	verifier := NewSignVerifier(map[string]string{testSign.APIKey: testSign.Secret})
+	verifier.MaxSkew = 20 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		if err := verifier.useNonce(fmt.Sprintf("nonce-%d", i), verifier.MaxSkew); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := verifier.useNonce("nonce-0", verifier.MaxSkew); !errors.Is(err, ErrSignReplay) {
+		t.Fatalf("reused nonce: err = %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if err := verifier.useNonce("nonce-0", verifier.MaxSkew); err != nil {
+		t.Fatalf("nonce after expiry: %v", err)
+	}
+	if len(verifier.nonces) != 1 || len(verifier.queue) != 1 {
+		t.Fatalf("%d nonces, %d queued after expiry, want 1", len(verifier.nonces), len(verifier.queue))
+	}
+
+	//并发校验同一个nonce只有一个能通过
+	r := signedRequest(testSign, http.MethodGet, "/v1/symbol/list?"+url.Values{"login": {"1"}}.Encode(), "")
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	passed := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if verifier.Verify(clone(t, r, "")) == nil {
+				mu.Lock()
+				passed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if passed != 1 {
+		t.Fatalf("%d concurrent verifications of one nonce passed, want 1", passed)
+	}
+}
@@ -0,0 +1,271 @@
+package utils
+
+import "github.com/vmihailenco/msgpack/v5"
+
+// TradeMode 品种的交易模式
+// https://support.metaquotes.net/en/docs/mt5/api/config_symbol/imtconsymbol/imtconsymbol_enum#entrademode
+type TradeMode uint
+
+const (
+	TradeModeDisabled  TradeMode = 0 //TRADE_DISABLED 禁止交易
+	TradeModeLongOnly  TradeMode = 1 //TRADE_LONGONLY 只能开多
+	TradeModeShortOnly TradeMode = 2 //TRADE_SHORTONLY 只能开空
+	TradeModeCloseOnly TradeMode = 3 //TRADE_CLOSEONLY 只能平仓
+	TradeModeFull      TradeMode = 4 //TRADE_FULL 没有限制
+)
+
+var tradeModes = newEnum("trade mode", map[TradeMode]string{
+	TradeModeDisabled:  "disabled",
+	TradeModeLongOnly:  "long_only",
+	TradeModeShortOnly: "short_only",
+	TradeModeCloseOnly: "close_only",
+	TradeModeFull:      "full",
+})
+
+func ParseTradeMode(s string) (TradeMode, error) {
+	return tradeModes.parse(s)
+}
+
+func (m TradeMode) String() string {
+	return tradeModes.name(m)
+}
+
+func (m TradeMode) IsValid() bool {
+	return tradeModes.valid(m)
+}
+
+func (m TradeMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *TradeMode) UnmarshalText(text []byte) error {
+	return tradeModes.unmarshalText(text, m)
+}
+
+func (m TradeMode) MarshalJSON() ([]byte, error) {
+	return tradeModes.marshalJSON(m)
+}
+
+func (m *TradeMode) UnmarshalJSON(data []byte) error {
+	return tradeModes.unmarshalJSON(data, m)
+}
+
+func (m TradeMode) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return tradeModes.encodeMsgpack(enc, m)
+}
+
+func (m *TradeMode) DecodeMsgpack(dec *msgpack.Decoder) error {
+	return tradeModes.decodeMsgpack(dec, m)
+}
+
+// CanOpen 是否可以开这个方向的仓位/挂单
+func (m TradeMode) CanOpen(buy bool) bool {
+	switch m {
+	case TradeModeFull:
+		return true
+	case TradeModeLongOnly:
+		return buy
+	case TradeModeShortOnly:
+		return !buy
+	}
+	return false
+}
+
+// CalcMode 利润/保证金的计算方式
+// https://support.metaquotes.net/en/docs/mt5/api/config_symbol/imtconsymbol/imtconsymbol_enum#encalcmode
+type CalcMode uint
+
+const (
+	CalcModeForex             CalcMode = 0  //TRADE_MODE_FOREX
+	CalcModeFutures           CalcMode = 1  //TRADE_MODE_FUTURES
+	CalcModeCFD               CalcMode = 2  //TRADE_MODE_CFD
+	CalcModeCFDIndex          CalcMode = 3  //TRADE_MODE_CFDINDEX
+	CalcModeCFDLeverage       CalcMode = 4  //TRADE_MODE_CFDLEVERAGE
+	CalcModeForexNoLeverage   CalcMode = 5  //TRADE_MODE_FOREX_NO_LEVERAGE
+	CalcModeExchStocks        CalcMode = 32 //TRADE_MODE_EXCH_STOCKS
+	CalcModeExchFutures       CalcMode = 33 //TRADE_MODE_EXCH_FUTURES
+	CalcModeExchFuturesForts  CalcMode = 34 //TRADE_MODE_EXCH_FUTURES_FORTS
+	CalcModeExchOptions       CalcMode = 35 //TRADE_MODE_EXCH_OPTIONS
+	CalcModeExchOptionsMargin CalcMode = 36 //TRADE_MODE_EXCH_OPTIONS_MARGIN
+	CalcModeExchBonds         CalcMode = 37 //TRADE_MODE_EXCH_BONDS
+	CalcModeExchStocksMOEX    CalcMode = 38 //TRADE_MODE_EXCH_STOCKS_MOEX
+	CalcModeExchBondsMOEX     CalcMode = 39 //TRADE_MODE_EXCH_BONDS_MOEX
+	CalcModeServCollateral    CalcMode = 64 //TRADE_MODE_SERV_COLLATERAL
+)
+
+var calcModes = newEnum("calc mode", map[CalcMode]string{
+	CalcModeForex:             "forex",
+	CalcModeFutures:           "futures",
+	CalcModeCFD:               "cfd",
+	CalcModeCFDIndex:          "cfd_index",
+	CalcModeCFDLeverage:       "cfd_leverage",
+	CalcModeForexNoLeverage:   "forex_no_leverage",
+	CalcModeExchStocks:        "exch_stocks",
+	CalcModeExchFutures:       "exch_futures",
+	CalcModeExchFuturesForts:  "exch_futures_forts",
+	CalcModeExchOptions:       "exch_options",
+	CalcModeExchOptionsMargin: "exch_options_margin",
+	CalcModeExchBonds:         "exch_bonds",
+	CalcModeExchStocksMOEX:    "exch_stocks_moex",
+	CalcModeExchBondsMOEX:     "exch_bonds_moex",
+	CalcModeServCollateral:    "serv_collateral",
+})
+
+func ParseCalcMode(s string) (CalcMode, error) {
+	return calcModes.parse(s)
+}
+
+func (m CalcMode) String() string {
+	return calcModes.name(m)
+}
+
+func (m CalcMode) IsValid() bool {
+	return calcModes.valid(m)
+}
+
+func (m CalcMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *CalcMode) UnmarshalText(text []byte) error {
+	return calcModes.unmarshalText(text, m)
+}
+
+func (m CalcMode) MarshalJSON() ([]byte, error) {
+	return calcModes.marshalJSON(m)
+}
+
+func (m *CalcMode) UnmarshalJSON(data []byte) error {
+	return calcModes.unmarshalJSON(data, m)
+}
+
+func (m CalcMode) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return calcModes.encodeMsgpack(enc, m)
+}
+
+func (m *CalcMode) DecodeMsgpack(dec *msgpack.Decoder) error {
+	return calcModes.decodeMsgpack(dec, m)
+}
+
+// IsExchange 交易所品种
+func (m CalcMode) IsExchange() bool {
+	return m >= CalcModeExchStocks && m < CalcModeServCollateral
+}
+
+// SwapMode 库存费的计算方式
+// https://support.metaquotes.net/en/docs/mt5/api/config_symbol/imtconsymbol/imtconsymbol_enum#enswapmode
+type SwapMode uint
+
+const (
+	SwapModeDisabled           SwapMode = 0 //SWAP_DISABLED 没有库存费
+	SwapModeByPoints           SwapMode = 1 //SWAP_BY_POINTS 按点数
+	SwapModeBySymbolCurrency   SwapMode = 2 //SWAP_BY_SYMBOL_CURRENCY
+	SwapModeByMarginCurrency   SwapMode = 3 //SWAP_BY_MARGIN_CURRENCY
+	SwapModeByGroupCurrency    SwapMode = 4 //SWAP_BY_GROUP_CURRENCY
+	SwapModeByInterestCurrent  SwapMode = 5 //SWAP_BY_INTEREST_CURRENT 按当前价格的年利率
+	SwapModeByInterestOpen     SwapMode = 6 //SWAP_BY_INTEREST_OPEN 按开仓价格的年利率
+	SwapModeReopenByClosePrice SwapMode = 7 //SWAP_REOPEN_BY_CLOSE_PRICE
+	SwapModeReopenByBid        SwapMode = 8 //SWAP_REOPEN_BY_BID
+	SwapModeByProfitCurrency   SwapMode = 9 //SWAP_BY_PROFIT_CURRENCY
+)
+
+var swapModes = newEnum("swap mode", map[SwapMode]string{
+	SwapModeDisabled:           "disabled",
+	SwapModeByPoints:           "by_points",
+	SwapModeBySymbolCurrency:   "by_symbol_currency",
+	SwapModeByMarginCurrency:   "by_margin_currency",
+	SwapModeByGroupCurrency:    "by_group_currency",
+	SwapModeByInterestCurrent:  "by_interest_current",
+	SwapModeByInterestOpen:     "by_interest_open",
+	SwapModeReopenByClosePrice: "reopen_by_close_price",
+	SwapModeReopenByBid:        "reopen_by_bid",
+	SwapModeByProfitCurrency:   "by_profit_currency",
+})
+
+func ParseSwapMode(s string) (SwapMode, error) {
+	return swapModes.parse(s)
+}
+
+func (m SwapMode) String() string {
+	return swapModes.name(m)
+}
+
+func (m SwapMode) IsValid() bool {
+	return swapModes.valid(m)
+}
+
+func (m SwapMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *SwapMode) UnmarshalText(text []byte) error {
+	return swapModes.unmarshalText(text, m)
+}
+
+func (m SwapMode) MarshalJSON() ([]byte, error) {
+	return swapModes.marshalJSON(m)
+}
+
+func (m *SwapMode) UnmarshalJSON(data []byte) error {
+	return swapModes.unmarshalJSON(data, m)
+}
+
+func (m SwapMode) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return swapModes.encodeMsgpack(enc, m)
+}
+
+func (m *SwapMode) DecodeMsgpack(dec *msgpack.Decoder) error {
+	return swapModes.decodeMsgpack(dec, m)
+}
+
+// GTCMode 挂单和sl/tp的有效期
+// https://support.metaquotes.net/en/docs/mt5/api/config_symbol/imtconsymbol/imtconsymbol_enum#engtcmode
+type GTCMode uint
+
+const (
+	GTCModeGTC          GTCMode = 0 //ORDERS_GTC 撤单前一直有效
+	GTCModeDaily        GTCMode = 1 //ORDERS_DAILY 当天有效, 包括sl/tp
+	GTCModeDailyNoStops GTCMode = 2 //ORDERS_DAILY_NO_STOPS 挂单当天有效, sl/tp一直有效
+)
+
+var gTCModes = newEnum("GTC mode", map[GTCMode]string{
+	GTCModeGTC:          "gtc",
+	GTCModeDaily:        "daily",
+	GTCModeDailyNoStops: "daily_no_stops",
+})
+
+func ParseGTCMode(s string) (GTCMode, error) {
+	return gTCModes.parse(s)
+}
+
+func (m GTCMode) String() string {
+	return gTCModes.name(m)
+}
+
+func (m GTCMode) IsValid() bool {
+	return gTCModes.valid(m)
+}
+
+func (m GTCMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *GTCMode) UnmarshalText(text []byte) error {
+	return gTCModes.unmarshalText(text, m)
+}
+
+func (m GTCMode) MarshalJSON() ([]byte, error) {
+	return gTCModes.marshalJSON(m)
+}
+
+func (m *GTCMode) UnmarshalJSON(data []byte) error {
+	return gTCModes.unmarshalJSON(data, m)
+}
+
+func (m GTCMode) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return gTCModes.encodeMsgpack(enc, m)
+}
+
+func (m *GTCMode) DecodeMsgpack(dec *msgpack.Decoder) error {
+	return gTCModes.decodeMsgpack(dec, m)
+}
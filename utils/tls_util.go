@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSConfig https相关配置, 默认会校验服务端证书
+type TLSConfig struct {
+	CAFile   string `json:"ca_file" mapstructure:"ca_file" config:"ca_file" yaml:"ca_file"`         //CA证书(pem)文件, 空则使用系统CA
+	CAPEM    string `json:"ca_pem" mapstructure:"ca_pem" config:"ca_pem" yaml:"ca_pem"`             //CA证书(pem)内容, 和CAFile二选一
+	CertFile string `json:"cert_file" mapstructure:"cert_file" config:"cert_file" yaml:"cert_file"` //mTLS: 客户端证书文件
+	KeyFile  string `json:"key_file" mapstructure:"key_file" config:"key_file" yaml:"key_file"`     //mTLS: 客户端私钥文件
+	CertPEM  string `json:"cert_pem" mapstructure:"cert_pem" config:"cert_pem" yaml:"cert_pem"`     //mTLS: 客户端证书内容, 和CertFile二选一
+	KeyPEM   string `json:"key_pem" mapstructure:"key_pem" config:"key_pem" yaml:"key_pem"`         //mTLS: 客户端私钥内容, 和KeyFile二选一
+
+	ServerName string   `json:"server_name" mapstructure:"server_name" config:"server_name" yaml:"server_name"` //SNI, 为空则使用address里的host
+	PinnedSPKI []string `json:"pinned_spki" mapstructure:"pinned_spki" config:"pinned_spki" yaml:"pinned_spki"` //证书公钥(SPKI)的sha256, base64编码. 校验通过的证书链中任意一个命中即可, InsecureSkipVerify 时只比对服务端证书
+
+	InsecureSkipVerify bool `json:"insecure_skip_verify" mapstructure:"insecure_skip_verify" config:"insecure_skip_verify" yaml:"insecure_skip_verify"` //跳过证书校验, 仅限测试使用
+}
+
+// ErrPinMismatch 服务端证书没有命中任何一个pin
+var ErrPinMismatch = errors.New("tls: server certificate does not match any pinned SPKI")
+
+// BuildTLSConfig 根据配置生成 *tls.Config, cfg为nil时返回默认配置(校验证书)
+func BuildTLSConfig(cfg *TLSConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if cfg == nil {
+		return tlsCfg, nil
+	}
+
+	tlsCfg.ServerName = cfg.ServerName
+	tlsCfg.InsecureSkipVerify = cfg.InsecureSkipVerify
+
+	//CA
+	caPEM := []byte(cfg.CAPEM)
+	if cfg.CAFile != "" {
+		data, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: read ca file: %w", err)
+		}
+		caPEM = data
+	}
+	if len(caPEM) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("tls: no valid certificate found in ca")
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	//mTLS
+	certPEM, keyPEM := []byte(cfg.CertPEM), []byte(cfg.KeyPEM)
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		var err error
+		if certPEM, err = os.ReadFile(cfg.CertFile); err != nil {
+			return nil, fmt.Errorf("tls: read cert file: %w", err)
+		}
+		if keyPEM, err = os.ReadFile(cfg.KeyFile); err != nil {
+			return nil, fmt.Errorf("tls: read key file: %w", err)
+		}
+	}
+	if len(certPEM) > 0 || len(keyPEM) > 0 {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("tls: load client key pair: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	//证书锁定
+	if len(cfg.PinnedSPKI) > 0 {
+		pins := make(map[string]struct{}, len(cfg.PinnedSPKI))
+		for _, pin := range cfg.PinnedSPKI {
+			raw, err := base64.StdEncoding.DecodeString(pin)
+			if err != nil || len(raw) != sha256.Size {
+				return nil, fmt.Errorf("tls: invalid pinned spki %q", pin)
+			}
+			pins[string(raw)] = struct{}{}
+		}
+		tlsCfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPins(cs, pins)
+		}
+	}
+
+	return tlsCfg, nil
+}
+
+// SPKIPin 计算证书公钥的pin值, 可直接填到 TLSConfig.PinnedSPKI
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func verifyPins(cs tls.ConnectionState, pins map[string]struct{}) error {
+	//校验过证书的话, 用校验通过的链; 否则对端发来的其他证书可以随便附带, 只认叶子证书
+	chains := cs.VerifiedChains
+	if len(chains) == 0 {
+		if len(cs.PeerCertificates) == 0 {
+			return ErrPinMismatch
+		}
+		chains = [][]*x509.Certificate{cs.PeerCertificates[:1]}
+	}
+	for _, chain := range chains {
+		for _, cert := range chain {
+			sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			if _, ok := pins[string(sum[:])]; ok {
+				return nil
+			}
+		}
+	}
+	return ErrPinMismatch
+}
@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func certPEM(cert *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
+
+// newClientCert 生成一个自签的CA和它签发的客户端证书, 返回CA, 证书pem, 私钥pem
+func newClientCert(t *testing.T) (*x509.Certificate, string, string) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test client ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca,
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+// newServerChain 生成一个自签的CA和它签发的127.0.0.1服务端证书, 服务端会把CA附在证书链里一起发送
+func newServerChain(t *testing.T) (*x509.Certificate, tls.Certificate) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test server ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca, tls.Certificate{Certificate: [][]byte{der, caDER}, PrivateKey: key}
+}
+
+func get(t *testing.T, cfg *TLSConfig, url string) error {
+	t.Helper()
+	tlsCfg, err := BuildTLSConfig(cfg)
+	if err != nil {
+		t.Fatalf("BuildTLSConfig: %v", err)
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}, Timeout: 5 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func TestTLSServerVerification(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte(certPEM(srv.Certificate())), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	otherCA, _, _ := newClientCert(t)
+
+	tests := []struct {
+		name    string
+		cfg     *TLSConfig
+		wantErr bool
+	}{
+		{"system ca rejects test cert", nil, true},
+		{"ca pem", &TLSConfig{CAPEM: certPEM(srv.Certificate())}, false},
+		{"ca file", &TLSConfig{CAFile: caFile}, false},
+		{"wrong ca", &TLSConfig{CAPEM: certPEM(otherCA)}, true},
+		{"server name mismatch", &TLSConfig{CAPEM: certPEM(srv.Certificate()), ServerName: "mt5.invalid"}, true},
+		{"insecure skip verify", &TLSConfig{InsecureSkipVerify: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := get(t, tt.cfg, srv.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			//证书错误不能重试
+			if err != nil && ClassifyError(err) != ErrorClassPermanent {
+				t.Fatalf("ClassifyError(%v) = %v, want permanent", err, ClassifyError(err))
+			}
+		})
+	}
+}
+
+func TestTLSMutual(t *testing.T) {
+	clientCA, clientCert, clientKey := newClientCert(t)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	pool := x509.NewCertPool()
+	pool.AddCert(clientCA)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	srv.StartTLS()
+	defer srv.Close()
+	ca := certPEM(srv.Certificate())
+
+	if err := get(t, &TLSConfig{CAPEM: ca}, srv.URL); err == nil {
+		t.Fatal("request without client certificate succeeded")
+	}
+	if err := get(t, &TLSConfig{CAPEM: ca, CertPEM: clientCert, KeyPEM: clientKey}, srv.URL); err != nil {
+		t.Fatalf("request with client certificate: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	os.WriteFile(certFile, []byte(clientCert), 0o600)
+	os.WriteFile(keyFile, []byte(clientKey), 0o600)
+	if err := get(t, &TLSConfig{CAPEM: ca, CertFile: certFile, KeyFile: keyFile}, srv.URL); err != nil {
+		t.Fatalf("request with client certificate files: %v", err)
+	}
+}
+
+func TestTLSPinning(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	ca := certPEM(srv.Certificate())
+	clientCA, _, _ := newClientCert(t)
+
+	if err := get(t, &TLSConfig{CAPEM: ca, PinnedSPKI: []string{SPKIPin(srv.Certificate())}}, srv.URL); err != nil {
+		t.Fatalf("matching pin: %v", err)
+	}
+	//证书没有校验时也要检查pin
+	if err := get(t, &TLSConfig{InsecureSkipVerify: true, PinnedSPKI: []string{SPKIPin(srv.Certificate())}}, srv.URL); err != nil {
+		t.Fatalf("matching pin without verification: %v", err)
+	}
+
+	err := get(t, &TLSConfig{CAPEM: ca, PinnedSPKI: []string{SPKIPin(clientCA)}}, srv.URL)
+	if !errors.Is(err, ErrPinMismatch) {
+		t.Fatalf("mismatched pin: err = %v, want ErrPinMismatch", err)
+	}
+	if ClassifyError(err) != ErrorClassPermanent {
+		t.Fatalf("pin mismatch must not be retried, got class %v", ClassifyError(err))
+	}
+
+	//通过 RestClient 发请求时同样直接失败, 不会重试
+	cli := NewRestClient(nil, &ClientParams{Address: srv.URL, TLS: &TLSConfig{CAPEM: ca, PinnedSPKI: []string{SPKIPin(clientCA)}}})
+	defer cli.Close()
+	call := &Call{Endpoint: "ListSymbol", Method: http.MethodGet, Path: "/v1/symbol/list", Response: &CommonResp{}}
+	if err := cli.Do(context.Background(), call); !errors.Is(err, ErrPinMismatch) || call.Attempts != 1 {
+		t.Fatalf("RestClient: err = %v after %d attempts", err, call.Attempts)
+	}
+}
+
+// 证书链里带上了pin的证书, 但叶子证书不是它签的: 没有校验证书时不能通过
+func TestTLSPinningLeafOnly(t *testing.T) {
+	ca, chain := newServerChain(t)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{chain}}
+	srv.StartTLS()
+	defer srv.Close()
+	leaf, _ := x509.ParseCertificate(chain.Certificate[0])
+
+	//pin CA: 校验过证书时链是可信的, 可以pin CA
+	if err := get(t, &TLSConfig{CAPEM: certPEM(ca), PinnedSPKI: []string{SPKIPin(ca)}}, srv.URL); err != nil {
+		t.Fatalf("pinned ca with verification: %v", err)
+	}
+	//没有校验时对端可以附带任意证书, 只认叶子证书
+	err := get(t, &TLSConfig{InsecureSkipVerify: true, PinnedSPKI: []string{SPKIPin(ca)}}, srv.URL)
+	if !errors.Is(err, ErrPinMismatch) {
+		t.Fatalf("pinned ca without verification: err = %v, want ErrPinMismatch", err)
+	}
+	if err := get(t, &TLSConfig{InsecureSkipVerify: true, PinnedSPKI: []string{SPKIPin(leaf)}}, srv.URL); err != nil {
+		t.Fatalf("pinned leaf without verification: %v", err)
+	}
+}
+
+func TestBuildTLSConfigErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	tests := []struct {
+		name string
+		cfg  *TLSConfig
+		want string
+	}{
+		{"missing ca file", &TLSConfig{CAFile: missing}, "read ca file"},
+		{"bad ca pem", &TLSConfig{CAPEM: "not a certificate"}, "no valid certificate"},
+		{"missing cert file", &TLSConfig{CertFile: missing, KeyFile: missing}, "read cert file"},
+		{"bad key pair", &TLSConfig{CertPEM: "x", KeyPEM: "y"}, "load client key pair"},
+		{"bad pin", &TLSConfig{PinnedSPKI: []string{"c2hvcnQ="}}, "invalid pinned spki"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BuildTLSConfig(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
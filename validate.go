@@ -0,0 +1,235 @@
+package mt5
+
+import (
+	"fmt"
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConfigError 配置里所有有问题的字段, 不会在第一个错误处停下
+type ConfigError struct {
+	Fields []FieldError
+}
+
+// FieldError 一个有问题的字段
+type FieldError struct {
+	Field  string //yaml里的路径, 比如 gateway.retry.max_attempts
+	Source string //值的来源: 文件名:行号, 环境变量名或命令行参数; 来自默认值或代码时为空
+	Reason string
+}
+
+func (e FieldError) Error() string {
+	if e.Source == "" {
+		return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+	}
+	return fmt.Sprintf("%s (%s): %s", e.Field, e.Source, e.Reason)
+}
+
+func (e *ConfigError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return "mt5: invalid config: " + strings.Join(msgs, "; ")
+}
+
+func (e *ConfigError) add(field, source, reason string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Source: source, Reason: reason})
+}
+
+func (e *ConfigError) has(field string) bool {
+	for _, f := range e.Fields {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate 检查配置, 返回 *ConfigError; 零值表示使用默认值, 不会报错
+func (c *Config) Validate() error {
+	var v validator
+	v.gateway("gateway", &c.Gateway)
+	if c.Trading != nil {
+		v.gateway("trading", c.Trading)
+	}
+	if c.Pumping != nil {
+		v.pumping("pumping", c.Pumping)
+	}
+	if len(v.errs.Fields) == 0 {
+		return nil
+	}
+	return &v.errs
+}
+
+type validator struct {
+	errs ConfigError
+}
+
+func (v *validator) check(ok bool, field, format string, args ...interface{}) {
+	if !ok {
+		v.errs.add(field, "", fmt.Sprintf(format, args...))
+	}
+}
+
+func (v *validator) nonNegative(field string, n int) {
+	v.check(n >= 0, field, "must not be negative")
+}
+
+func (v *validator) duration(field string, d time.Duration) {
+	v.check(d >= 0, field, "must not be negative")
+}
+
+func (v *validator) ratio(field string, r float64) {
+	v.check(r >= 0 && r <= 1, field, "must be between 0 and 1")
+}
+
+func (v *validator) gateway(path string, c *GatewayConfig) {
+	v.check(c.Address != "" || len(c.Addresses) > 0, path+".address", "required")
+	if c.Address != "" {
+		v.url(path+".address", c.Address)
+	}
+	for i, addr := range c.Addresses {
+		v.url(fmt.Sprintf("%s.addresses[%d]", path, i), addr)
+	}
+	v.duration(path+".timeout", c.Timeout)
+
+	if c.TLS != nil {
+		v.tls(path+".tls", c.TLS)
+	}
+	if t := c.Transport; t != nil {
+		p := path + ".transport"
+		v.nonNegative(p+".max_idle_conns", t.MaxIdleConns)
+		v.nonNegative(p+".max_idle_conns_per_host", t.MaxIdleConnsPerHost)
+		v.nonNegative(p+".max_conns_per_host", t.MaxConnsPerHost)
+		v.duration(p+".idle_conn_timeout", t.IdleConnTimeout)
+		v.duration(p+".dial_timeout", t.DialTimeout)
+		v.duration(p+".keep_alive", t.KeepAlive)
+		v.duration(p+".tls_handshake_timeout", t.TLSHandshakeTimeout)
+	}
+	if r := c.Retry; r != nil {
+		p := path + ".retry"
+		v.nonNegative(p+".max_attempts", r.MaxAttempts)
+		v.duration(p+".initial_backoff", r.InitialBackoff)
+		v.duration(p+".max_backoff", r.MaxBackoff)
+		v.check(r.InitialBackoff <= 0 || r.MaxBackoff <= 0 || r.MaxBackoff >= r.InitialBackoff,
+			p+".max_backoff", "must not be less than initial_backoff")
+		v.check(r.Multiplier == 0 || r.Multiplier >= 1, p+".multiplier", "must be 0 (default) or at least 1")
+		v.ratio(p+".jitter", r.Jitter)
+		v.duration(p+".confirm_window", r.ConfirmWindow)
+		v.duration(p+".confirm_interval", r.ConfirmInterval)
+	}
+	if s := c.Sign; s != nil {
+		v.check((s.APIKey == "") == (s.Secret == ""), path+".sign", "api_key and secret must be set together")
+	}
+	if r := c.RateLimit; r != nil {
+		p := path + ".rate_limit"
+		v.rateLimit(p+".default", r.Default)
+		v.rateLimit(p+".per_login", r.PerLogin)
+		for _, route := range sortedKeys(r.Routes) {
+			limit := r.Routes[route]
+			v.rateLimit(p+".routes."+route, &limit)
+		}
+	}
+	if f := c.Failover; f != nil {
+		p := path + ".failover"
+		v.check(f.HealthPath == "" || strings.HasPrefix(f.HealthPath, "/"), p+".health_path", "must start with /")
+		v.duration(p+".health_interval", f.HealthInterval)
+		v.duration(p+".health_timeout", f.HealthTimeout)
+		v.nonNegative(p+".fail_threshold", f.FailThreshold)
+		v.nonNegative(p+".recover_threshold", f.RecoverThreshold)
+	}
+	if l := c.Log; l != nil {
+		for _, name := range sortedKeys(l.Levels) {
+			switch level := l.Levels[name]; level {
+			case utils.LogLevelDebug, utils.LogLevelInfo, utils.LogLevelWarn, utils.LogLevelError, utils.LogLevelOff:
+			default:
+				v.check(false, path+".log.levels."+name, "unknown level %q, use debug/info/warn/error/off", level)
+			}
+		}
+	}
+	if cb := c.CircuitBreaker; cb != nil {
+		p := path + ".circuit_breaker"
+		v.duration(p+".window", cb.Window)
+		v.nonNegative(p+".min_requests", cb.MinRequests)
+		v.ratio(p+".failure_ratio", cb.FailureRatio)
+		v.duration(p+".slow_call_duration", cb.SlowCallDuration)
+		v.ratio(p+".slow_call_ratio", cb.SlowCallRatio)
+		v.duration(p+".open_timeout", cb.OpenTimeout)
+		v.nonNegative(p+".half_open_max_calls", cb.HalfOpenMaxCalls)
+	}
+}
+
+func (v *validator) url(field, addr string) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		v.check(false, field, "invalid url %q", addr)
+		return
+	}
+	v.check((u.Scheme == "http" || u.Scheme == "https") && u.Host != "", field, "must be an http(s)://host:port url, got %q", addr)
+}
+
+func (v *validator) tls(path string, t *utils.TLSConfig) {
+	v.check(t.CAFile == "" || t.CAPEM == "", path+".ca_pem", "ca_file and ca_pem are mutually exclusive")
+	v.check(t.CertFile == "" || t.CertPEM == "", path+".cert_pem", "cert_file and cert_pem are mutually exclusive")
+	v.check(t.KeyFile == "" || t.KeyPEM == "", path+".key_pem", "key_file and key_pem are mutually exclusive")
+	hasCert := t.CertFile != "" || t.CertPEM != ""
+	hasKey := t.KeyFile != "" || t.KeyPEM != ""
+	v.check(hasCert == hasKey, path+".key_file", "client certificate and key must be set together")
+	if v.errs.has(path+".ca_pem") || v.errs.has(path+".cert_pem") || v.errs.has(path+".key_pem") || v.errs.has(path+".key_file") {
+		return
+	}
+
+	//文件能读、证书能解析、pin格式正确, 否则要到第一次请求时才会报错
+	caField, certField := path+".ca_pem", path+".cert_pem"
+	if t.CAFile != "" {
+		caField = path + ".ca_file"
+	}
+	if t.CertFile != "" {
+		certField = path + ".cert_file"
+	}
+	v.buildTLS(caField, &utils.TLSConfig{CAFile: t.CAFile, CAPEM: t.CAPEM})
+	v.buildTLS(certField, &utils.TLSConfig{CertFile: t.CertFile, KeyFile: t.KeyFile, CertPEM: t.CertPEM, KeyPEM: t.KeyPEM})
+	v.buildTLS(path+".pinned_spki", &utils.TLSConfig{PinnedSPKI: t.PinnedSPKI})
+}
+
+func (v *validator) buildTLS(field string, t *utils.TLSConfig) {
+	if _, err := utils.BuildTLSConfig(t); err != nil {
+		v.errs.add(field, "", err.Error())
+	}
+}
+
+func (v *validator) rateLimit(path string, r *utils.RateLimit) {
+	if r == nil {
+		return
+	}
+	v.check(r.Rate >= 0, path+".rate", "must not be negative (0 means unlimited)")
+	v.nonNegative(path+".burst", r.Burst)
+}
+
+func (v *validator) pumping(path string, c *pumping.Config) {
+	if c.ServerAddr == "" {
+		v.check(false, path+".server_addr", "required")
+	} else if _, port, err := net.SplitHostPort(c.ServerAddr); err != nil || port == "" {
+		v.check(false, path+".server_addr", "must be host:port, got %q", c.ServerAddr)
+	}
+	v.duration(path+".timeout", c.Timeout)
+	v.nonNegative(path+".max_reconnects", c.MaxReconnects)
+	v.duration(path+".reconnect_interval", c.ReconnectInterval)
+	v.duration(path+".heartbeat_interval", c.HeartbeatInterval)
+	v.nonNegative(path+".buffer_size", c.BufferSize)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,121 @@
+package mt5_test
+
+import (
+	"errors"
+	"github.com/asaka1234/go-mt5-sdk"
+	"github.com/asaka1234/go-mt5-sdk/pumping"
+	"github.com/asaka1234/go-mt5-sdk/utils"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func fields(err error) []string {
+	var cfgErr *mt5.ConfigError
+	if !errors.As(err, &cfgErr) {
+		return nil
+	}
+	list := make([]string, len(cfgErr.Fields))
+	for i, f := range cfgErr.Fields {
+		list[i] = f.Field
+	}
+	return list
+}
+
+// 错误的tls配置在 New 时就要报错, 不能等到第一次请求
+func TestNewRejectsBadTLS(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	tests := []struct {
+		name  string
+		tls   *utils.TLSConfig
+		field string
+	}{
+		{"missing ca file", &utils.TLSConfig{CAFile: missing}, "gateway.tls.ca_file"},
+		{"bad ca pem", &utils.TLSConfig{CAPEM: "not a certificate"}, "gateway.tls.ca_pem"},
+		{"missing cert file", &utils.TLSConfig{CertFile: missing, KeyFile: missing}, "gateway.tls.cert_file"},
+		{"bad key pair", &utils.TLSConfig{CertPEM: "x", KeyPEM: "y"}, "gateway.tls.cert_pem"},
+		{"bad pin", &utils.TLSConfig{PinnedSPKI: []string{"c2hvcnQ="}}, "gateway.tls.pinned_spki"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mt5.New(mt5.Config{Gateway: mt5.GatewayConfig{Address: "https://127.0.0.1:8443", TLS: tt.tls}})
+			if got := fields(err); len(got) != 1 || got[0] != tt.field {
+				t.Fatalf("err = %v, want a single error on %s", err, tt.field)
+			}
+		})
+	}
+
+	cli, err := mt5.New(mt5.Config{Gateway: mt5.GatewayConfig{Address: "https://127.0.0.1:8443", TLS: &utils.TLSConfig{InsecureSkipVerify: true}}})
+	if err != nil {
+		t.Fatalf("valid tls config: %v", err)
+	}
+	cli.Close()
+}
+
+func TestValidate(t *testing.T) {
+	gateway := func(modify func(c *mt5.GatewayConfig)) mt5.Config {
+		cfg := mt5.Config{Gateway: mt5.GatewayConfig{Address: "http://127.0.0.1:8080"}}
+		modify(&cfg.Gateway)
+		return cfg
+	}
+	withPumping := func(addr string) mt5.Config {
+		cfg := mt5.Config{Gateway: mt5.GatewayConfig{Address: "http://127.0.0.1:8080"}, Pumping: pumping.DefaultConfig()}
+		cfg.Pumping.ServerAddr = addr
+		return cfg
+	}
+	tests := []struct {
+		name   string
+		cfg    mt5.Config
+		fields []string
+	}{
+		{"zero values use defaults", gateway(func(c *mt5.GatewayConfig) {
+			c.Retry, c.CircuitBreaker, c.Transport = &utils.RetryPolicy{}, &utils.CircuitBreakerConfig{}, &utils.TransportConfig{}
+		}), nil},
+		{"missing address", mt5.Config{}, []string{"gateway.address"}},
+		{"backup addresses only", mt5.Config{Gateway: mt5.GatewayConfig{Addresses: []string{"https://10.0.0.2:8443"}}}, nil},
+		{"bad urls", gateway(func(c *mt5.GatewayConfig) {
+			c.Address, c.Addresses = "127.0.0.1:8080", []string{"http://10.0.0.2:8080", "ftp://10.0.0.3"}
+		}), []string{"gateway.address", "gateway.addresses[1]"}},
+		{"negative timeout", gateway(func(c *mt5.GatewayConfig) { c.Timeout = -time.Second }), []string{"gateway.timeout"}},
+		{"backoff ordering", gateway(func(c *mt5.GatewayConfig) {
+			c.Retry = &utils.RetryPolicy{InitialBackoff: 2 * time.Second, MaxBackoff: time.Second}
+		}), []string{"gateway.retry.max_backoff"}},
+		{"max backoff alone", gateway(func(c *mt5.GatewayConfig) {
+			c.Retry = &utils.RetryPolicy{MaxBackoff: time.Second}
+		}), nil},
+		{"retry values", gateway(func(c *mt5.GatewayConfig) {
+			c.Retry = &utils.RetryPolicy{MaxAttempts: -1, Multiplier: 0.5, Jitter: 1.5, ConfirmWindow: -time.Second}
+		}), []string{"gateway.retry.max_attempts", "gateway.retry.multiplier", "gateway.retry.jitter", "gateway.retry.confirm_window"}},
+		{"ratios", gateway(func(c *mt5.GatewayConfig) {
+			c.CircuitBreaker = &utils.CircuitBreakerConfig{FailureRatio: -0.1, SlowCallRatio: 1.01}
+		}), []string{"gateway.circuit_breaker.failure_ratio", "gateway.circuit_breaker.slow_call_ratio"}},
+		{"ratio bounds", gateway(func(c *mt5.GatewayConfig) {
+			c.CircuitBreaker = &utils.CircuitBreakerConfig{FailureRatio: 1}
+			c.Retry = &utils.RetryPolicy{Jitter: 1}
+		}), nil},
+		{"sign needs both", gateway(func(c *mt5.GatewayConfig) { c.Sign = &utils.SignConfig{APIKey: "key"} }), []string{"gateway.sign"}},
+		{"rate limits", gateway(func(c *mt5.GatewayConfig) {
+			c.RateLimit = &utils.RateLimitConfig{Default: &utils.RateLimit{Rate: -1}, Routes: map[string]utils.RateLimit{"OpenPosition": {Burst: -1}}}
+		}), []string{"gateway.rate_limit.default.rate", "gateway.rate_limit.routes.OpenPosition.burst"}},
+		{"failover", gateway(func(c *mt5.GatewayConfig) {
+			c.Failover = &utils.FailoverConfig{HealthPath: "health", FailThreshold: -1}
+		}), []string{"gateway.failover.health_path", "gateway.failover.fail_threshold"}},
+		{"log level", gateway(func(c *mt5.GatewayConfig) {
+			c.Log = &utils.LogConfig{Levels: map[string]utils.LogLevel{"ListSymbol": "verbose"}}
+		}), []string{"gateway.log.levels.ListSymbol"}},
+		{"trading gateway", mt5.Config{Gateway: mt5.GatewayConfig{Address: "http://127.0.0.1:8080"}, Trading: &mt5.GatewayConfig{}}, []string{"trading.address"}},
+		{"pumping", withPumping("127.0.0.1:9000"), nil},
+		{"pumping missing server_addr", withPumping(""), []string{"pumping.server_addr"}},
+		{"pumping server_addr without port", withPumping("localhost"), []string{"pumping.server_addr"}},
+		{"pumping server_addr with url", withPumping("tcp://127.0.0.1:9000"), []string{"pumping.server_addr"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if got := fields(err); !reflect.DeepEqual(got, tt.fields) {
+				t.Fatalf("Validate = %v, want errors on %v", err, tt.fields)
+			}
+		})
+	}
+}